@@ -7,6 +7,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/avarian/online-shopping-cart/service/audit"
+	"github.com/avarian/online-shopping-cart/util"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -83,6 +85,9 @@ func newMysqlDB(profile string) *gorm.DB {
 	if err != nil {
 		logCtx.Fatal(err)
 	}
+	if err := audit.Register(db); err != nil {
+		logCtx.Fatal(err)
+	}
 	log.WithField("dsn", dsn).Info("database connected")
 
 	return db
@@ -111,6 +116,9 @@ func newPostgresDB(profile string) *gorm.DB {
 	if err != nil {
 		logCtx.Fatal(err)
 	}
+	if err := audit.Register(db); err != nil {
+		logCtx.Fatal(err)
+	}
 	log.WithField("dsn", dsn).Info("database connected")
 
 	return db
@@ -166,3 +174,25 @@ func newS3Session(profile string) *session.Session {
 
 	return s3Session
 }
+
+// Return elastic mailer, or a dummy one when no api key is configured
+func newMailer() util.Mailer {
+	apiKey := viper.GetString("mailer.elastic_api_key")
+	if apiKey == "" {
+		log.Info("mailer api key is empty, using dummy mailer")
+		return util.NewDummyMailer()
+	}
+
+	return util.NewElasticMailer(apiKey, viper.GetString("mailer.elastic_channel"))
+}
+
+// Return infobip messenger, or a dummy one when no api key is configured
+func newMessenger() util.Messenger {
+	apiKey := viper.GetString("messenger.infobip_api_key")
+	if apiKey == "" {
+		log.Info("messenger api key is empty, using dummy messenger")
+		return util.NewDummyMessenger()
+	}
+
+	return util.NewInfobipMessenger(apiKey, viper.GetString("messenger.infobip_callback_url"), viper.GetString("messenger.infobip_sender"))
+}
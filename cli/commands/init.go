@@ -28,7 +28,18 @@ func init() {
 	viper.BindPFlag("queue.idle_wait", workerCmd.Flags().Lookup("idle-wait"))
 	viper.BindPFlag("queue.max_retry", workerCmd.Flags().Lookup("max-retry"))
 
+	// Command flags for "keys"
+	keysGenerateCmd.Flags().StringVar(&keysAlgorithm, "alg", keysAlgorithm, "signing algorithm (RS256 or EdDSA)")
+	keysRotateCmd.Flags().StringVar(&keysAlgorithm, "alg", keysAlgorithm, "signing algorithm (RS256 or EdDSA)")
+	keysCmd.AddCommand(keysListCmd)
+	keysCmd.AddCommand(keysGenerateCmd)
+	keysCmd.AddCommand(keysRotateCmd)
+	keysCmd.AddCommand(keysRetireCmd)
+	rolesCmd.AddCommand(rolesGrantCmd)
+
 	rootCmd.AddCommand(serveCmd)
 	rootCmd.AddCommand(workerCmd)
 	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(keysCmd)
+	rootCmd.AddCommand(rolesCmd)
 }
@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/auth"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var (
+	keysAlgorithm = "RS256"
+
+	keysCmd = &cobra.Command{
+		Use:   "keys",
+		Short: "Manage JWT signing keys",
+	}
+
+	keysListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List signing keys",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return keysListCommand()
+		},
+	}
+
+	keysGenerateCmd = &cobra.Command{
+		Use:   "generate",
+		Short: "Generate a signing key, it signs new tokens right away",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return keysGenerateCommand()
+		},
+	}
+
+	keysRotateCmd = &cobra.Command{
+		Use:   "rotate",
+		Short: "Generate a signing key and retire all but the previous signing key",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return keysRotateCommand()
+		},
+	}
+
+	keysRetireCmd = &cobra.Command{
+		Use:   "retire [kid]",
+		Short: "Retire a signing key, tokens signed by it are refused",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return keysRetireCommand(args[0])
+		},
+	}
+)
+
+func keysListCommand() error {
+	db := newMysqlDB("mysql")
+
+	var keys []model.SigningKey
+	if err := db.Order("id desc").Find(&keys).Error; err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KID\tALGORITHM\tSTATUS\tCREATED AT")
+	for _, v := range keys {
+		createdAt := ""
+		if v.CreatedAt != nil {
+			createdAt = v.CreatedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", v.Kid, v.Algorithm, v.Status, createdAt)
+	}
+	return w.Flush()
+}
+
+func keysGenerateCommand() error {
+	db := newMysqlDB("mysql")
+
+	_, err := generateSigningKey(db)
+	return err
+}
+
+func keysRotateCommand() error {
+	db := newMysqlDB("mysql")
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		signingKeyRepo := repository.NewSigningKeyRepository(tx)
+		active, result := signingKeyRepo.AllActive()
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if _, err := generateSigningKey(tx); err != nil {
+			return err
+		}
+
+		// the previous signing key keeps verifying tokens it signed until they expire
+		for i, v := range active {
+			if i == 0 {
+				continue
+			}
+			if result := signingKeyRepo.Retire(int(v.ID), "SYSTEM"); result.Error != nil {
+				return result.Error
+			}
+			log.WithField("kid", v.Kid).Info("signing key retired")
+		}
+		return nil
+	})
+}
+
+func keysRetireCommand(kid string) error {
+	db := newMysqlDB("mysql")
+
+	signingKeyRepo := repository.NewSigningKeyRepository(db)
+	key, result := signingKeyRepo.OneByKid(kid)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("signing key not found")
+	}
+	if result := signingKeyRepo.Retire(int(key.ID), "SYSTEM"); result.Error != nil {
+		return result.Error
+	}
+
+	log.WithField("kid", kid).Info("signing key retired")
+	return nil
+}
+
+func generateSigningKey(db *gorm.DB) (model.SigningKey, error) {
+	key, err := auth.GenerateSigningKey(keysAlgorithm)
+	if err != nil {
+		return model.SigningKey{}, err
+	}
+
+	signingKeyRepo := repository.NewSigningKeyRepository(db)
+	key, result := signingKeyRepo.Create(key)
+	if result.Error != nil {
+		return model.SigningKey{}, result.Error
+	}
+
+	log.WithFields(log.Fields{
+		"kid":       key.Kid,
+		"algorithm": key.Algorithm,
+	}).Info("signing key generated")
+	return key, nil
+}
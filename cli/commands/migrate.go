@@ -5,9 +5,15 @@ import (
 	"time"
 
 	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/auth"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"gorm.io/gorm"
 )
 
+const superAdminRole = "SUPER_ADMIN"
+
 var (
 	migrateCmd = &cobra.Command{
 		Use:   "migrate",
@@ -24,14 +30,88 @@ var (
 func migrateCommand() (err error) {
 	// Postgres database
 	db := newMysqlDB("mysql")
-	db.AutoMigrate(
+	if err := db.AutoMigrate(
 		&model.Account{},
+		&model.AccountAddress{},
+		&model.RecoveryCode{},
+		&model.AccountIdentity{},
+		&model.OidcState{},
+		&model.ApiKey{},
+		&model.ApiKeyPermission{},
+		&model.AccountErasure{},
+		&model.AdminAction{},
+		&model.AuditLog{},
+		&model.AccountRole{},
 		&model.Item{},
+		&model.ItemSubscription{},
 		&model.Cart{},
+		&model.CartReminder{},
 		&model.Order{},
 		&model.Voucher{},
 		&model.OrderItem{},
 		&model.OrderVoucher{},
-	)
-	return nil
+		&model.PasswordReset{},
+		&model.RefreshToken{},
+		&model.Review{},
+		&model.ReviewImage{},
+		&model.Role{},
+		&model.RolePermission{},
+		&model.SigningKey{},
+		&model.Verification{},
+		&model.Wishlist{},
+		&model.WishlistItem{},
+	); err != nil {
+		return err
+	}
+
+	return seedSuperAdminRole(db)
+}
+
+// seedSuperAdminRole creates the SUPER_ADMIN role holding every permission, and grants it permissions added
+// since the last migrate, so "roles grant" can make the first admin of a database created by migrate
+func seedSuperAdminRole(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		roleRepo := repository.NewRoleRepository(tx)
+		role, result := roleRepo.OneByName(superAdminRole)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			role, result = roleRepo.Create(model.Role{
+				Name:        superAdminRole,
+				Description: "every permission",
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			log.WithField("role", superAdminRole).Info("role created")
+		}
+
+		role, result = roleRepo.OneById(int(role.ID), "RolePermission")
+		if result.Error != nil {
+			return result.Error
+		}
+		granted := make(map[string]bool, len(role.RolePermission))
+		for _, v := range role.RolePermission {
+			granted[v.Permission] = true
+		}
+
+		rolePermissionRepo := repository.NewRolePermissionRepository(tx)
+		for _, v := range auth.Permissions {
+			if granted[v] {
+				continue
+			}
+			if _, result := rolePermissionRepo.Create(model.RolePermission{
+				RoleID:     role.ID,
+				Permission: v,
+			}); result.Error != nil {
+				return result.Error
+			}
+			log.WithFields(log.Fields{
+				"role":       superAdminRole,
+				"permission": v,
+			}).Info("permission granted")
+		}
+		return nil
+	})
 }
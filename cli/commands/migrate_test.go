@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/service/auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func CommandsNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_seedSuperAdminRole(t *testing.T) {
+	tests := []struct {
+		name string
+		mock func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name: "Create role with every permission",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `roles` WHERE name = ?")).
+					WithArgs("SUPER_ADMIN").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `roles`")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `roles` WHERE id = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "SUPER_ADMIN"))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `role_permissions` WHERE `role_permissions`.`role_id` = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				for _, v := range auth.Permissions {
+					mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `role_permissions`")).
+						WithArgs(1, v, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+						WillReturnResult(sqlmock.NewResult(1, 1))
+				}
+			},
+		},
+		{
+			// permissions added to the code since the last migrate are granted, the others kept
+			name: "Grant missing permissions",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `roles` WHERE name = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "SUPER_ADMIN"))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `roles` WHERE id = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "SUPER_ADMIN"))
+				rows := sqlmock.NewRows([]string{"id", "role_id", "permission"})
+				for i, v := range auth.Permissions[1:] {
+					rows.AddRow(i+1, 1, v)
+				}
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `role_permissions` WHERE `role_permissions`.`role_id` = ?")).
+					WillReturnRows(rows)
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `role_permissions`")).
+					WithArgs(1, auth.Permissions[0], sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := CommandsNewMockDB()
+			mock.ExpectBegin()
+			tt.mock(mock)
+			mock.ExpectCommit()
+
+			assert.NoError(t, seedSuperAdminRole(db))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/audit"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var (
+	rolesCmd = &cobra.Command{
+		Use:   "roles",
+		Short: "Manage account roles",
+	}
+
+	rolesGrantCmd = &cobra.Command{
+		Use:   "grant [email] [role]",
+		Short: "Grant a role to an account, e.g. SUPER_ADMIN to the first admin, it applies from the next login or token refresh",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return rolesGrantCommand(newMysqlDB("mysql"), args[0], args[1])
+		},
+	}
+)
+
+func rolesGrantCommand(db *gorm.DB, email string, roleName string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		accountRepo := repository.NewAccountRepository(tx)
+		account, result := accountRepo.OneByEmail(email)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("account not found")
+		}
+
+		roleRepo := repository.NewRoleRepository(tx)
+		role, result := roleRepo.OneByName(roleName)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("role not found, run migrate to create " + superAdminRole)
+		}
+
+		logCtx := log.WithFields(log.Fields{
+			"email": email,
+			"role":  roleName,
+		})
+
+		accountRoleRepo := repository.NewAccountRoleRepository(tx)
+		_, result = accountRoleRepo.OneByAccountIdAndRoleId(int(account.ID), int(role.ID))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			logCtx.Info("role already granted")
+			return nil
+		}
+		if _, result := accountRoleRepo.Create(model.AccountRole{
+			AccountID: account.ID,
+			RoleID:    role.ID,
+		}); result.Error != nil {
+			return result.Error
+		}
+		if result := accountRepo.UpdateType(int(account.ID), "ADMIN", audit.SystemActor); result.Error != nil {
+			return result.Error
+		}
+
+		logCtx.Info("role granted")
+		return nil
+	})
+}
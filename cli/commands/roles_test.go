@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_rolesGrantCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(mock sqlmock.Sqlmock)
+		wantErr bool
+	}{
+		{
+			name: "Grant role",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE email = ?")).
+					WithArgs("admin@mail.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "admin@mail.com"))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `roles` WHERE name = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "SUPER_ADMIN"))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_roles` WHERE (account_id = ? AND role_id = ?)")).
+					WithArgs(1, 2).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `account_roles`")).
+					WithArgs(1, 2, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `accounts` SET `type`=?,`updated_by`=?,`updated_at`=? WHERE id = ?")).
+					WithArgs("ADMIN", "SYSTEM", sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Granted already",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE email = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "admin@mail.com"))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `roles` WHERE name = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "SUPER_ADMIN"))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_roles` WHERE (account_id = ? AND role_id = ?)")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "role_id"}).AddRow(1, 1, 2))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Unknown account",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE email = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := CommandsNewMockDB()
+			mock.ExpectBegin()
+			tt.mock(mock)
+
+			err := rolesGrantCommand(db, "admin@mail.com", "SUPER_ADMIN")
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
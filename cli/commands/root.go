@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/avarian/online-shopping-cart/service/logging"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -58,9 +59,12 @@ func initConfig() error {
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_", ".", "_"))
 	viper.AutomaticEnv()
 
-	log.SetFormatter(&log.TextFormatter{
-		FullTimestamp: true,
-	})
+	viper.SetDefault("log_format", logging.FormatText)
+	formatter, err := logging.NewFormatter(viper.GetString("log_format"))
+	if err != nil {
+		return err
+	}
+	log.SetFormatter(formatter)
 	if viper.GetBool("verbose") {
 		log.SetLevel(log.DebugLevel)
 	}
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"math/rand"
+	nethttp "net/http"
 	"os"
 	"os/signal"
 	"sync"
@@ -10,10 +11,13 @@ import (
 
 	"github.com/avarian/online-shopping-cart/controllers"
 	"github.com/avarian/online-shopping-cart/delivery/http"
+	"github.com/avarian/online-shopping-cart/jobs"
+	"github.com/avarian/online-shopping-cart/service/auth"
 	"github.com/avarian/online-shopping-cart/util"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/taylorchu/work"
 )
 
 var (
@@ -37,30 +41,119 @@ func serveCommand() (err error) {
 	db := newMysqlDB("mysql")
 
 	// Redis client
-	// redis := newRedisClient(viper.GetString("redis.url"))
-	// defer redis.Close()
-	// jobs.SetRedisQueue(work.NewRedisQueue(redis))
+	redis := newRedisClient(viper.GetString("redis.url"))
+	defer redis.Close()
+	jobs.SetRedisQueue(work.NewRedisQueue(redis))
 
 	// validatorTranslate
 	validator := util.ValidatorTranslate()
 
+	// Access and refresh tokens, revoked sessions are kept in redis
+	tokens := auth.NewTokenService(db,
+		viper.GetString("jwt_secret"),
+		auth.NewKeyStore(db, time.Duration(viper.GetInt("token.key_refresh"))*time.Second),
+		time.Duration(viper.GetInt("token.access_ttl"))*time.Minute,
+		time.Duration(viper.GetInt("token.refresh_ttl"))*time.Hour,
+		util.NewRedisRevocationList(redis),
+	)
+
+	// Failed logins are counted in redis, or in memory while redis is unreachable
+	throttle := auth.NewLoginThrottle(
+		util.NewFallbackAttemptStore(util.NewRedisAttemptStore(redis), util.NewMemoryAttemptStore()),
+		auth.ThrottleConfig{
+			MaxAttempts:   viper.GetInt("login_throttle.max_attempts"),
+			IpMaxAttempts: viper.GetInt("login_throttle.ip_max_attempts"),
+			Window:        time.Duration(viper.GetInt("login_throttle.window")) * time.Minute,
+			Lockout:       time.Duration(viper.GetInt("login_throttle.lockout")) * time.Minute,
+			MaxLockout:    time.Duration(viper.GetInt("login_throttle.max_lockout")) * time.Minute,
+		},
+	)
+
+	verification := controllers.VerificationConfig{
+		EmailUrl:       viper.GetString("verification.email_url"),
+		EmailTTL:       time.Duration(viper.GetInt("verification.email_ttl")) * time.Hour,
+		CodeTTL:        time.Duration(viper.GetInt("verification.code_ttl")) * time.Minute,
+		MaxAttempts:    viper.GetInt("verification.max_attempts"),
+		ResendCooldown: time.Duration(viper.GetInt("verification.resend_cooldown")) * time.Second,
+		DailyLimit:     viper.GetInt("verification.daily_limit"),
+	}
+
+	passwordReset := controllers.PasswordResetConfig{
+		Url: viper.GetString("password_reset.url"),
+		TTL: time.Duration(viper.GetInt("password_reset.ttl")) * time.Minute,
+	}
+
+	twoFactor := controllers.TwoFactorConfig{
+		Issuer:           viper.GetString("two_factor.issuer"),
+		ChallengeTTL:     time.Duration(viper.GetInt("two_factor.challenge_ttl")) * time.Minute,
+		RequiredForAdmin: viper.GetBool("two_factor.required_for_admin"),
+	}
+
+	// OpenID Connect providers by name, the name is part of the login routes
+	oidc := controllers.OidcConfig{
+		StateTTL:  time.Duration(viper.GetInt("oidc.state_ttl")) * time.Minute,
+		Providers: map[string]*auth.OidcProvider{},
+	}
+	oidcClient := &nethttp.Client{Timeout: 10 * time.Second}
+	for name := range viper.GetStringMap("oidc.providers") {
+		key := "oidc.providers." + name
+		if viper.GetString(key+".client_id") == "" {
+			continue
+		}
+		oidc.Providers[name] = auth.NewOidcProvider(name, auth.OidcProviderConfig{
+			Issuer:       viper.GetString(key + ".issuer"),
+			ClientID:     viper.GetString(key + ".client_id"),
+			ClientSecret: viper.GetString(key + ".client_secret"),
+			RedirectUrl:  viper.GetString(key + ".redirect_url"),
+			Scopes:       viper.GetStringSlice(key + ".scopes"),
+		}, oidcClient)
+	}
+
 	//
 	// Initialize Controllers
 	//
 	home := controllers.NewHomeController()
-	account := controllers.NewAccountController(db, validator, viper.GetString("jwt_secret"))
+	account := controllers.NewAccountController(db, validator, viper.GetString("jwt_secret"), tokens, throttle, verification, twoFactor)
 	item := controllers.NewItemController(db, validator)
-	cart := controllers.NewCartController(db, validator)
+	cart := controllers.NewCartController(db, validator, viper.GetString("jwt_secret"), time.Duration(viper.GetInt("guest_token.ttl"))*time.Hour)
 	voucher := controllers.NewVoucherController(db, validator)
-	order := controllers.NewOrderController(db, validator)
+	order := controllers.NewOrderController(db, validator, viper.GetBool("verification.required_for_order"))
+	review := controllers.NewReviewController(db, validator)
+	wishlist := controllers.NewWishlistController(db, validator)
+	itemSubscription := controllers.NewItemSubscriptionController(db, validator)
+	password := controllers.NewPasswordController(db, validator, tokens, passwordReset)
+	verificationController := controllers.NewVerificationController(db, validator, verification)
+	role := controllers.NewRoleController(db, validator)
+	accountAddress := controllers.NewAccountAddressController(db, validator)
+	adminAccount := controllers.NewAdminAccountController(db, validator, tokens, throttle, passwordReset)
+	twoFactorController := controllers.NewTwoFactorController(db, validator, viper.GetString("jwt_secret"), tokens, throttle, twoFactor)
+	oidcController := controllers.NewOidcController(db, validator, viper.GetString("jwt_secret"), tokens, throttle, twoFactor, oidc)
+	apiKey := controllers.NewApiKeyController(db, validator)
+	accountData := controllers.NewAccountDataController(db, validator, tokens)
+	auditLog := controllers.NewAuditLogController(db)
 
 	server := http.NewServer(viper.GetString("listen_address"),
+		tokens,
+		viper.GetString("jwt_secret"),
 		home,
 		account,
 		item,
 		cart,
 		voucher,
 		order,
+		review,
+		wishlist,
+		itemSubscription,
+		password,
+		verificationController,
+		role,
+		accountAddress,
+		adminAccount,
+		twoFactorController,
+		oidcController,
+		apiKey,
+		accountData,
+		auditLog,
 	)
 
 	//
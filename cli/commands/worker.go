@@ -47,6 +47,16 @@ func newWorker(client *redis.Client) *work.Worker {
 		},
 	})
 
+	jobOptions := &work.JobOptions{
+		MaxExecutionTime: maxExecutionTime,
+		IdleWait:         idleWait,
+		NumGoroutines:    numGoroutines,
+		HandleMiddleware: []work.HandleMiddleware{
+			logrus.HandleFuncLogger,
+			discard.MaxRetry(maxRetry),
+		},
+	}
+
 	//
 	// Register job handlers
 	//
@@ -62,15 +72,77 @@ func newWorker(client *redis.Client) *work.Worker {
 		}
 
 		return nil
-	}, &work.JobOptions{
-		MaxExecutionTime: maxExecutionTime,
-		IdleWait:         idleWait,
-		NumGoroutines:    numGoroutines,
-		HandleMiddleware: []work.HandleMiddleware{
-			logrus.HandleFuncLogger,
-			discard.MaxRetry(maxRetry),
-		},
-	})
+	}, jobOptions)
+
+	if err != nil {
+		log.WithError(err).Fatal("fail to register queue job handler")
+	}
+
+	err = w.RegisterWithContext(jobs.SendEmailJobQueueId, func(ctx context.Context, j *work.Job, do *work.DequeueOptions) error {
+		var email jobs.SendEmailJob
+
+		if err := j.UnmarshalJSONPayload(&email); err != nil {
+			return err
+		}
+
+		return email.Handle(ctx)
+	}, jobOptions)
+
+	if err != nil {
+		log.WithError(err).Fatal("fail to register queue job handler")
+	}
+
+	err = w.RegisterWithContext(jobs.SendSMSJobQueueId, func(ctx context.Context, j *work.Job, do *work.DequeueOptions) error {
+		var sms jobs.SendSMSJob
+
+		if err := j.UnmarshalJSONPayload(&sms); err != nil {
+			return err
+		}
+
+		return sms.Handle(ctx)
+	}, jobOptions)
+
+	if err != nil {
+		log.WithError(err).Fatal("fail to register queue job handler")
+	}
+
+	err = w.RegisterWithContext(jobs.ItemSubscriptionJobQueueId, func(ctx context.Context, j *work.Job, do *work.DequeueOptions) error {
+		var itemSubscription jobs.ItemSubscriptionJob
+
+		if err := j.UnmarshalJSONPayload(&itemSubscription); err != nil {
+			return err
+		}
+
+		return itemSubscription.Handle(ctx)
+	}, jobOptions)
+
+	if err != nil {
+		log.WithError(err).Fatal("fail to register queue job handler")
+	}
+
+	err = w.RegisterWithContext(jobs.AbandonedCartJobQueueId, func(ctx context.Context, j *work.Job, do *work.DequeueOptions) error {
+		var abandonedCart jobs.AbandonedCartJob
+
+		if err := j.UnmarshalJSONPayload(&abandonedCart); err != nil {
+			return err
+		}
+
+		return abandonedCart.Handle(ctx)
+	}, jobOptions)
+
+	if err != nil {
+		log.WithError(err).Fatal("fail to register queue job handler")
+	}
+
+	err = w.RegisterWithContext(jobs.AccountErasureJobQueueId, func(ctx context.Context, j *work.Job, do *work.DequeueOptions) error {
+		var accountErasure jobs.AccountErasureJob
+
+		if err := j.UnmarshalJSONPayload(&accountErasure); err != nil {
+			return err
+		}
+
+		return accountErasure.Handle(ctx)
+	}, jobOptions)
 
 	if err != nil {
 		log.WithError(err).Fatal("fail to register queue job handler")
@@ -92,16 +164,54 @@ func workerCommand() error {
 	defer redis.Close()
 	log.WithField("url", viper.GetString("redis.url")).Info("redis client initialized")
 
+	// Database and queue used by the jobs
+	jobs.SetDB(newMysqlDB("mysql"))
+	jobs.SetRedisQueue(work.NewRedisQueue(redis))
+
+	// Notification channels used by the jobs
+	jobs.SetMailer(newMailer(), viper.GetString("mailer.from_name"), viper.GetString("mailer.from_email"))
+	jobs.SetMessenger(newMessenger())
+
 	w := newWorker(redis)
 	w.Start()
 
+	stop := make(chan struct{})
+	go scheduleAbandonedCartJob(stop)
+
 	done := make(chan os.Signal, 10)
 	signal.Notify(done, os.Interrupt, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
 	<-done
 
+	close(stop)
 	log.Info("stopping workers...")
 	w.Stop()
 	log.Info("all workers stopped")
 
 	return nil
 }
+
+// scheduleAbandonedCartJob dispatches AbandonedCartJob every abandoned_cart.interval minutes until stop is closed,
+// each worker ticks on its own, the job claims every reminder it sends so overlapping runs mail once
+func scheduleAbandonedCartJob(stop <-chan struct{}) {
+	interval := time.Duration(viper.GetInt("abandoned_cart.interval")) * time.Minute
+	if interval <= 0 {
+		log.Info("abandoned cart reminder disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			jobs.Dispatch(jobs.NewAbandonedCartJob(
+				viper.GetInt("abandoned_cart.idle_after"),
+				viper.GetFloat64("abandoned_cart.voucher_percentage"),
+				viper.GetFloat64("abandoned_cart.voucher_max"),
+				viper.GetInt("abandoned_cart.voucher_valid_for"),
+			))
+		}
+	}
+}
@@ -2,15 +2,19 @@ package controllers
 
 import (
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/audit"
+	"github.com/avarian/online-shopping-cart/service/auth"
+	"github.com/avarian/online-shopping-cart/service/logging"
 	"github.com/avarian/online-shopping-cart/service/repository"
 	"github.com/avarian/online-shopping-cart/util"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
-	"github.com/golang-jwt/jwt"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -29,31 +33,61 @@ type PostLoginRequest struct {
 	Password string `json:"password"  validate:"required"`
 }
 
-type JWTClaim struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Type     string `json:"type"`
-	jwt.StandardClaims
+type PostRefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"  validate:"required"`
 }
 
+type PutEditProfileRequest struct {
+	Name        string `json:"name"  validate:"omitempty,max=255"`
+	PhoneNumber string `json:"phone_number"  validate:"omitempty,max=255"`
+	Address     string `json:"address"  validate:"omitempty,max=255"`
+}
+
+type PutChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"  validate:"required"`
+	Password        string `json:"password"  validate:"required,nefield=CurrentPassword"`
+}
+
+var (
+	errWrongPassword         = apperror.New(apperror.Invalid, "wrong_password", "current password is wrong")
+	errPasswordNotSet        = apperror.New(apperror.PreconditionFailed, "password_not_set", "account has no password, set one with a password reset first")
+	errPasswordResetRequired = apperror.New(apperror.Forbidden, "password_reset_required", "password reset required, use the link sent by email")
+	errNoSession             = apperror.New(apperror.Invalid, "no_session", "request is not authenticated with a login session")
+	errEmailUsed             = apperror.New(apperror.Conflict, "email_used", "email already used")
+	errPhoneNumberUsed       = apperror.New(apperror.Conflict, "phone_number_used", "phone number already used")
+	errInvalidCredentials    = apperror.New(apperror.Unauthorized, "invalid_credentials", "email or password is wrong")
+)
+
+// dummyPasswordHash has the cost of the stored hashes, it is compared when a login has no hash to compare
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), 5)
+
 type AccountController struct {
-	db        *gorm.DB
-	validator *util.Validator
-	jwtSecret string
+	db           *gorm.DB
+	validator    *util.Validator
+	jwtSecret    string
+	tokens       *auth.TokenService
+	throttle     *auth.LoginThrottle
+	verification VerificationConfig
+	twoFactor    TwoFactorConfig
 }
 
-func NewAccountController(db *gorm.DB, validator *util.Validator, jwtSecret string) *AccountController {
+func NewAccountController(db *gorm.DB, validator *util.Validator, jwtSecret string, tokens *auth.TokenService, throttle *auth.LoginThrottle, verification VerificationConfig, twoFactor TwoFactorConfig) *AccountController {
 	return &AccountController{
-		db:        db,
-		validator: validator,
-		jwtSecret: jwtSecret,
+		db:           db,
+		validator:    validator,
+		jwtSecret:    jwtSecret,
+		tokens:       tokens,
+		throttle:     throttle,
+		verification: verification,
+		twoFactor:    twoFactor,
 	}
 }
 
 // RegisterAccount	goDocs
 // @Summary      register an account
-// @Description  register account with type CUSTOMER
+// @Description  register account with type CUSTOMER, its address as the default of the address book, and email a verification link, cart of X-Guest-Token is merged into the new account
 // @Tags         Account
+// @Param				 X-Guest-Token	header		string	false	"guest token from /cart/guest"
 // @Produce      application/json
 // @Param        tags body PostRegisterRequest true "Body Request"
 // @Router       /register [post]
@@ -61,28 +95,42 @@ func (s *AccountController) PostRegister(c *gin.Context) {
 	// bind data
 	var req PostRegisterRequest
 	if err := c.ShouldBind(&req); err != nil {
-		log.WithField("reason", err).Error("error Binding")
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
 		return
 	}
 	// validate
 	if err := s.validator.Validate.Struct(&req); err != nil {
-		log.WithField("reason", err).Error("invalid Request")
-		errs := err.(validator.ValidationErrors)
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": errs.Translate(s.validator.Trans)})
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
 		return
 	}
 
 	// log
-	logCtx := log.WithFields(log.Fields{
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
 		"email": req.Email,
 		"api":   "PostRegister",
 	})
 
+	// nobody is logged in yet, the new account creates itself
+	c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), req.Email))
+
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	if _, result := accountRepo.OneByEmail(req.Email); result.RowsAffected > 0 {
+		logCtx.WithField("reason", "email used").Error("error register")
+		apperror.Abort(c, errEmailUsed)
+		return
+	}
+	if _, result := accountRepo.OneByPhoneNumber(req.PhoneNumber); result.RowsAffected > 0 {
+		logCtx.WithField("reason", "phone number used").Error("error register")
+		apperror.Abort(c, errPhoneNumberUsed)
+		return
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), 5)
 	if err != nil {
 		logCtx.WithField("reason", err).Error("error hash password")
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apperror.Abort(c, apperror.ErrInternal)
 		return
 	}
 
@@ -90,18 +138,35 @@ func (s *AccountController) PostRegister(c *gin.Context) {
 		Address:     req.Address,
 		Email:       req.Email,
 		Name:        req.Name,
-		PhoneNumber: req.PhoneNumber,
+		PhoneNumber: &req.PhoneNumber,
 		Password:    string(hashedPassword),
 	}
 
-	accountRepo := repository.NewAccountRepository(s.db)
 	account, result := accountRepo.Create(account)
 	if result.Error != nil {
-		logCtx.WithField("reason", err).Error("error create account")
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": result.Error.Error()})
+		logCtx.WithField("reason", result.Error).Error("error create account")
+		apperror.Abort(c, apperror.ErrInternal)
 		return
 	}
 
+	accountAddressRepo := repository.NewAccountAddressRepository(s.db.WithContext(c))
+	if _, result := accountAddressRepo.Create(model.AccountAddress{
+		AccountID:     account.ID,
+		Label:         "home",
+		RecipientName: account.Name,
+		PhoneNumber:   req.PhoneNumber,
+		Address:       account.Address,
+		IsDefault:     true,
+	}); result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error create address")
+	}
+
+	if err := sendEmailVerification(s.db.WithContext(c), s.verification, account); err != nil {
+		logCtx.WithField("reason", err).Error("error send email verification")
+	}
+
+	mergeRequestGuestCart(c, logCtx, s.db.WithContext(c), s.jwtSecret, account)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Sucess!",
 		"data":    account,
@@ -110,8 +175,9 @@ func (s *AccountController) PostRegister(c *gin.Context) {
 
 // LoginAccount	goDocs
 // @Summary      login an account
-// @Description  login account with return JWT token
+// @Description  login account with return short lived JWT token and a refresh token, refused with 403 for suspended accounts and accounts that must reset their password, too many failed logins of the account or client ip lock logins with 429 and Retry-After, accounts with two factor authentication, or ADMIN accounts when it is mandatory, get 202 with a challenge token for /login/2fa or /login/2fa/enroll instead, cart of X-Guest-Token is merged into the account cart, qty is capped at item stock
 // @Tags         Account
+// @Param				 X-Guest-Token	header		string	false	"guest token from /cart/guest"
 // @Produce      application/json
 // @Param        tags body PostLoginRequest true "Body Request"
 // @Router       /login [post]
@@ -119,61 +185,447 @@ func (s *AccountController) PostLogin(c *gin.Context) {
 	// bind data
 	var req PostLoginRequest
 	if err := c.ShouldBind(&req); err != nil {
-		log.WithField("reason", err).Error("error Binding")
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
 		return
 	}
 	// validate
 	if err := s.validator.Validate.Struct(&req); err != nil {
-		log.WithField("reason", err).Error("invalid Request")
-		errs := err.(validator.ValidationErrors)
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": errs.Translate(s.validator.Trans)})
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
 		return
 	}
 
 	// log
-	logCtx := log.WithFields(log.Fields{
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
 		"email": req.Email,
 		"api":   "PostLogin",
 	})
 
-	accountRepo := repository.NewAccountRepository(s.db)
+	lockedFor, err := s.throttle.LockedFor(c.Request.Context(), req.Email, c.ClientIP())
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error check login lockout")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+	if lockedFor > 0 {
+		logCtx.WithField("reason", auth.ErrLoginLocked).Error("error login")
+		abortLoginLocked(c, lockedFor)
+		return
+	}
+
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
 	account, result := accountRepo.OneByEmail(req.Email)
-	if result.Error != nil || result.RowsAffected == 0 {
-		err := errors.New("not found")
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error find account")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+	if result.RowsAffected == 0 || account.Password == "" {
+		// compared anyway so an unknown email answers as slowly as a wrong password
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(req.Password))
+		logCtx.WithField("reason", "not found or no password").Error("error find account")
+		s.loginFailed(c, logCtx, req.Email)
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(account.Password), []byte(req.Password)); err != nil {
+		// If the two passwords don't match, return a 401 status
+		logCtx.WithField("reason", err).Error("error compare password")
+		s.loginFailed(c, logCtx, req.Email)
+		return
+	}
+
+	completeLogin(c, logCtx, s.db.WithContext(c), s.jwtSecret, s.tokens, s.throttle, s.twoFactor, account)
+}
+
+// RefreshToken	goDocs
+// @Summary      refresh access token
+// @Description  exchange a refresh token for a new access token and refresh token, a refresh token can be used once, using it again revokes the whole login session
+// @Tags         Account
+// @Produce      application/json
+// @Param        tags body PostRefreshTokenRequest true "Body Request"
+// @Router       /token/refresh [post]
+func (s *AccountController) PostRefreshToken(c *gin.Context) {
+	// bind data
+	var req PostRefreshTokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostRefreshToken",
+	})
+
+	tokenPair, err := s.tokens.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error refresh token")
+		apperror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenPair)
+}
+
+// JWKS	goDocs
+// @Summary      get token verification keys
+// @Description  public keys access tokens are signed with as JSON Web Key Set, tokens carry the key id in kid header
+// @Tags         Account
+// @Produce      application/json
+// @Router       /.well-known/jwks.json [get]
+func (s *AccountController) GetJWKS(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "GetJWKS",
+	})
+
+	jwks, err := s.tokens.JWKS()
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error load signing keys")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, jwks)
+}
+
+// Logout	goDocs
+// @Summary      logout
+// @Description  revoke the login session of the token, its refresh tokens and access tokens stop working, need credentials
+// @Tags         Account
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /logout [post]
+func (s *AccountController) PostLogout(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostLogout",
+	})
+
+	// api keys have no session, they are revoked through the admin endpoints
+	if c.GetString("session_id") == "" {
+		logCtx.WithField("reason", errNoSession).Error("error revoke session")
+		apperror.Abort(c, errNoSession)
+		return
+	}
+
+	username := c.GetString("username")
+	if err := s.tokens.RevokeSession(c.Request.Context(), c.GetString("session_id"), username); err != nil {
+		logCtx.WithField("reason", err).Error("error revoke session")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+	})
+}
+
+// GetProfile	goDocs
+// @Summary      get own profile
+// @Description  get own account with its address book, need credentials
+// @Tags         Account
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /account/me [get]
+func (s *AccountController) GetProfile(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "GetProfile",
+	})
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username, "AccountAddress")
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
 		if result.Error != nil {
 			err = result.Error
 		}
 		logCtx.WithField("reason", err).Error("error find account")
-		c.AbortWithStatusJSON(http.StatusUnauthorized, nil)
+		apperror.Abort(c, errAccountNotFound)
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(account.Password), []byte(req.Password)); err != nil {
-		// If the two passwords don't match, return a 401 status
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    account,
+	})
+}
+
+// EditProfile	goDocs
+// @Summary      edit own profile
+// @Description  edit own name, phone number and address, empty fields are kept, a new phone number has to be verified again, need credentials
+// @Tags         Account
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PutEditProfileRequest true "Body Request"
+// @Produce      application/json
+// @Router       /account/me [put]
+func (s *AccountController) PutEditProfile(c *gin.Context) {
+	// bind data
+	var req PutEditProfileRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PutEditProfile",
+	})
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	phoneChanged := req.PhoneNumber != "" && (account.PhoneNumber == nil || req.PhoneNumber != *account.PhoneNumber)
+	if phoneChanged {
+		if _, result := accountRepo.OneByPhoneNumber(req.PhoneNumber); result.RowsAffected > 0 {
+			logCtx.WithField("reason", "phone number used").Error("error edit profile")
+			apperror.Abort(c, errPhoneNumberUsed)
+			return
+		}
+	}
+
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		accountRepo := repository.NewAccountRepository(tx)
+		if _, result := accountRepo.Update(int(account.ID), model.Account{
+			Name:    req.Name,
+			Address: req.Address,
+		}); result.Error != nil {
+			return result.Error
+		}
+		if phoneChanged {
+			if result := accountRepo.UpdatePhoneNumber(int(account.ID), req.PhoneNumber, username); result.Error != nil {
+				return result.Error
+			}
+		}
+		return nil
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error edit profile")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	account, _ = accountRepo.OneByEmail(username, "AccountAddress")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    account,
+	})
+}
+
+// ChangePassword	goDocs
+// @Summary      change own password
+// @Description  change own password with the current one, 412 for accounts without password which set one with a password reset, every login session is revoked and a new token pair is returned, need credentials
+// @Tags         Account
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PutChangePasswordRequest true "Body Request"
+// @Produce      application/json
+// @Router       /account/me/password [put]
+func (s *AccountController) PutChangePassword(c *gin.Context) {
+	// bind data
+	var req PutChangePasswordRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PutChangePassword",
+	})
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	if err := checkCurrentPassword(account, req.CurrentPassword); err != nil {
 		logCtx.WithField("reason", err).Error("error compare password")
-		c.AbortWithStatusJSON(http.StatusUnauthorized, nil)
+		apperror.Abort(c, err)
 		return
 	}
 
-	expirationTime := time.Now().Add(24 * time.Hour)
-	claims := &JWTClaim{
-		Email:    account.Email,
-		Username: account.Email,
-		Type:     account.Type,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
-		},
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), 5)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error hash password")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(s.jwtSecret))
+
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		accountRepo := repository.NewAccountRepository(tx)
+		if _, result := accountRepo.Update(int(account.ID), model.Account{
+			Password: string(hashedPassword),
+		}); result.Error != nil {
+			return result.Error
+		}
+
+		// reset links sent before the change must not undo it
+		passwordResetRepo := repository.NewPasswordResetRepository(tx)
+		if result := passwordResetRepo.MarkUsedByAccountId(int(account.ID)); result.Error != nil {
+			return result.Error
+		}
+		return nil
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error change password")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	if err := s.tokens.RevokeAccountSessions(c.Request.Context(), int(account.ID), username); err != nil {
+		logCtx.WithField("reason", err).Error("error revoke sessions")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	tokenPair, err := s.tokens.Issue(account)
 	if err != nil {
 		logCtx.WithField("reason", err).Error("error generate jwt")
-		c.AbortWithStatusJSON(http.StatusUnauthorized, nil)
+		apperror.Abort(c, apperror.ErrInternal)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"token": tokenString,
-	})
+	c.JSON(http.StatusOK, tokenPair)
+}
+
+// checkCurrentPassword confirms the password of a logged in account before a sensitive change,
+// accounts created by a provider login have none until they set one with a password reset
+func checkCurrentPassword(account model.Account, password string) error {
+	if account.Password == "" {
+		return errPasswordNotSet
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(account.Password), []byte(password)); err != nil {
+		return errWrongPassword
+	}
+	return nil
+}
+
+// loginFailed counts the failed login towards the lockout of the account and client ip
+func (s *AccountController) loginFailed(c *gin.Context, logCtx *log.Entry, email string) {
+	lockout, err := s.throttle.Fail(c.Request.Context(), email, c.ClientIP())
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error count failed login")
+	}
+	if lockout > 0 {
+		abortLoginLocked(c, lockout)
+		return
+	}
+	apperror.Abort(c, errInvalidCredentials)
+}
+
+func abortLoginLocked(c *gin.Context, retryAfter time.Duration) {
+	abortRetryAfter(c, auth.ErrLoginLocked, retryAfter)
+}
+
+// abortRetryAfter answers err with when the request may be retried, in the Retry-After header and the details
+func abortRetryAfter(c *gin.Context, err *apperror.Error, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	apperror.Abort(c, err.WithDetail("retry_after", seconds))
+}
+
+// completeLogin issues the tokens once the credentials of a login passed, or the two factor challenge
+// when the account has one, password and OIDC logins both end here
+func completeLogin(c *gin.Context, logCtx *log.Entry, db *gorm.DB, jwtSecret string, tokens *auth.TokenService, throttle *auth.LoginThrottle, twoFactor TwoFactorConfig, account model.Account) {
+	if account.SuspendedAt != nil {
+		logCtx.WithField("reason", auth.ErrAccountSuspended).Error("error login")
+		apperror.Abort(c, auth.ErrAccountSuspended)
+		return
+	}
+
+	if account.PasswordResetRequired {
+		logCtx.WithField("reason", errPasswordResetRequired).Error("error login")
+		apperror.Abort(c, errPasswordResetRequired)
+		return
+	}
+
+	// failed logins are only reset once the second factor passed as well
+	challenge, err := twoFactorChallenge(tokens, twoFactor, account)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error issue two factor challenge")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+	if challenge != nil {
+		c.JSON(http.StatusAccepted, challenge)
+		return
+	}
+
+	if err := throttle.Succeed(c.Request.Context(), account.Email); err != nil {
+		logCtx.WithField("reason", err).Error("error reset failed logins")
+	}
+
+	// the login request has no token yet, what it changes from here on is done by the account
+	c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), account.Email))
+
+	tokenPair, err := tokens.Issue(account)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error generate jwt")
+		apperror.Abort(c, err)
+		return
+	}
+
+	mergeRequestGuestCart(c, logCtx, db, jwtSecret, account)
+
+	c.JSON(http.StatusOK, tokenPair)
+}
+
+// mergeRequestGuestCart merges the cart of the guest token sent along, a failed merge does not fail the request
+func mergeRequestGuestCart(c *gin.Context, logCtx *log.Entry, db *gorm.DB, jwtSecret string, account model.Account) {
+	guestToken := c.GetHeader(util.GuestTokenHeader)
+	if guestToken == "" {
+		return
+	}
+
+	guestId, err := util.ParseGuestToken(jwtSecret, guestToken)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error parse guest token")
+		return
+	}
+
+	if err := mergeGuestCart(db, account, guestId, account.Email); err != nil {
+		logCtx.WithField("reason", err).Error("error merge guest cart")
+	}
 }
@@ -0,0 +1,441 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/logging"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type PostCreateAccountAddressRequest struct {
+	Label         string `json:"label" validate:"required,max=64"`
+	RecipientName string `json:"recipient_name" validate:"omitempty,max=255"`
+	PhoneNumber   string `json:"phone_number" validate:"omitempty,max=255"`
+	Address       string `json:"address" validate:"required,max=255"`
+	IsDefault     bool   `json:"is_default"`
+}
+
+type PutEditAccountAddressRequest struct {
+	Label         string `json:"label" validate:"omitempty,max=64"`
+	RecipientName string `json:"recipient_name" validate:"omitempty,max=255"`
+	PhoneNumber   string `json:"phone_number" validate:"omitempty,max=255"`
+	Address       string `json:"address" validate:"omitempty,max=255"`
+}
+
+var errAddressNotFound = apperror.New(apperror.NotFound, "address_not_found", "address not found")
+
+type AccountAddressController struct {
+	db        *gorm.DB
+	validator *util.Validator
+}
+
+func NewAccountAddressController(db *gorm.DB, validator *util.Validator) *AccountAddressController {
+	return &AccountAddressController{
+		db:        db,
+		validator: validator,
+	}
+}
+
+// GetAllAccountAddress	goDocs
+// @Summary      get own address book
+// @Description  get all own addresses, the default one first, need credentials
+// @Tags         Account
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /account/me/address/all [get]
+func (s *AccountAddressController) GetAccountAddresses(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "GetAccountAddresses",
+	})
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	accountAddressRepo := repository.NewAccountAddressRepository(s.db.WithContext(c))
+	accountAddress, result := accountAddressRepo.AllByAccountId(int(account.ID))
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error find address")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    accountAddress,
+	})
+}
+
+// GetOneAccountAddressDetail	goDocs
+// @Summary      get one own address
+// @Description  get one own address from the address book, need credentials
+// @Tags         Account
+// @Param				 id path int true "get detail by id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /account/me/address/{id} [get]
+func (s *AccountAddressController) GetAccountAddressDetail(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "GetAccountAddressDetail",
+	})
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	idS := c.Param("id")
+	id, err := strconv.Atoi(idS)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error parse id")
+		apperror.Abort(c, errInvalidId)
+		return
+	}
+
+	accountAddressRepo := repository.NewAccountAddressRepository(s.db.WithContext(c))
+	accountAddress, result := accountAddressRepo.OneByIdAndAccountId(id, int(account.ID))
+	if result.RowsAffected == 0 || result.Error != nil {
+		var err error = errAddressNotFound
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find address")
+		apperror.Abort(c, errAddressNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    accountAddress,
+	})
+}
+
+// AddAccountAddress	goDocs
+// @Summary      add address to own address book
+// @Description  add an address with a label, recipient name and phone number default to the account ones, the first address becomes the default, need credentials
+// @Tags         Account
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PostCreateAccountAddressRequest true "Body Request"
+// @Produce      application/json
+// @Router       /account/me/address [post]
+func (s *AccountAddressController) PostCreateAccountAddress(c *gin.Context) {
+	// bind data
+	var req PostCreateAccountAddressRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostCreateAccountAddress",
+	})
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	if req.RecipientName == "" {
+		req.RecipientName = account.Name
+	}
+
+	if req.PhoneNumber == "" && account.PhoneNumber != nil {
+		req.PhoneNumber = *account.PhoneNumber
+	}
+
+	var accountAddress model.AccountAddress
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		accountAddressRepo := repository.NewAccountAddressRepository(tx)
+		if _, result := accountAddressRepo.OneDefaultByAccountId(int(account.ID)); result.Error != nil {
+			return result.Error
+		} else if result.RowsAffected == 0 {
+			req.IsDefault = true
+		}
+
+		var result *gorm.DB
+		accountAddress, result = accountAddressRepo.Create(model.AccountAddress{
+			AccountID:     account.ID,
+			Label:         req.Label,
+			RecipientName: req.RecipientName,
+			PhoneNumber:   req.PhoneNumber,
+			Address:       req.Address,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if req.IsDefault {
+			if result := accountAddressRepo.SetDefault(int(accountAddress.ID), int(account.ID), username); result.Error != nil {
+				return result.Error
+			}
+			accountAddress.IsDefault = true
+		}
+		return nil
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error create address")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    accountAddress,
+	})
+}
+
+// EditAccountAddress	goDocs
+// @Summary      edit own address
+// @Description  edit an address of own address book, empty fields are kept, need credentials
+// @Tags         Account
+// @Param				 id path int true "edit by id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PutEditAccountAddressRequest true "Body Request"
+// @Produce      application/json
+// @Router       /account/me/address/{id} [put]
+func (s *AccountAddressController) PutEditAccountAddress(c *gin.Context) {
+	// bind data
+	var req PutEditAccountAddressRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PutEditAccountAddress",
+	})
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	idS := c.Param("id")
+	id, err := strconv.Atoi(idS)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error parse id")
+		apperror.Abort(c, errInvalidId)
+		return
+	}
+
+	accountAddressRepo := repository.NewAccountAddressRepository(s.db.WithContext(c))
+	if _, result := accountAddressRepo.OneByIdAndAccountId(id, int(account.ID)); result.RowsAffected == 0 || result.Error != nil {
+		var err error = errAddressNotFound
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find address")
+		apperror.Abort(c, errAddressNotFound)
+		return
+	}
+
+	accountAddress, result := accountAddressRepo.Update(id, model.AccountAddress{
+		Label:         req.Label,
+		RecipientName: req.RecipientName,
+		PhoneNumber:   req.PhoneNumber,
+		Address:       req.Address,
+	})
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error edit address")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    accountAddress,
+	})
+}
+
+// DefaultAccountAddress	goDocs
+// @Summary      set own default address
+// @Description  make the address the default one used for orders, need credentials
+// @Tags         Account
+// @Param				 id path int true "address id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /account/me/address/{id}/default [put]
+func (s *AccountAddressController) PutDefaultAccountAddress(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PutDefaultAccountAddress",
+	})
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	idS := c.Param("id")
+	id, err := strconv.Atoi(idS)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error parse id")
+		apperror.Abort(c, errInvalidId)
+		return
+	}
+
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		accountAddressRepo := repository.NewAccountAddressRepository(tx)
+		if _, result := accountAddressRepo.OneByIdAndAccountId(id, int(account.ID)); result.Error != nil {
+			return result.Error
+		} else if result.RowsAffected == 0 {
+			return errAddressNotFound
+		}
+		if result := accountAddressRepo.SetDefault(id, int(account.ID), username); result.Error != nil {
+			return result.Error
+		}
+		return nil
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error set default address")
+		apperror.Abort(c, err)
+		return
+	}
+
+	accountAddressRepo := repository.NewAccountAddressRepository(s.db.WithContext(c))
+	accountAddress, _ := accountAddressRepo.OneById(id)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    accountAddress,
+	})
+}
+
+// deleteAccountAddress	goDocs
+// @Summary      delete own address
+// @Description  delete an address of own address book, when it was the default the latest added address becomes the default, need credentials
+// @Tags         Account
+// @Param				 id path int true "delete by id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /account/me/address/{id} [delete]
+func (s *AccountAddressController) DeleteAccountAddress(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "DeleteAccountAddress",
+	})
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	idS := c.Param("id")
+	id, err := strconv.Atoi(idS)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error parse id")
+		apperror.Abort(c, errInvalidId)
+		return
+	}
+
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		accountAddressRepo := repository.NewAccountAddressRepository(tx)
+		accountAddress, result := accountAddressRepo.OneByIdAndAccountId(id, int(account.ID))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errAddressNotFound
+		}
+
+		if result := accountAddressRepo.Delete(id, false); result.Error != nil {
+			return result.Error
+		}
+
+		if !accountAddress.IsDefault {
+			return nil
+		}
+		latest, result := accountAddressRepo.OneLatestByAccountId(int(account.ID))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		if result := accountAddressRepo.SetDefault(int(latest.ID), int(account.ID), username); result.Error != nil {
+			return result.Error
+		}
+		return nil
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error delete address")
+		apperror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+	})
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func AccountAddressNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_AccountAddressDefault(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	addressColumns := []string{"id", "account_id", "label", "is_default"}
+
+	expectAccount := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE email = ?")).
+			WithArgs("email@mail.com").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "name", "phone_number"}).AddRow(1, "email@mail.com", "Name", "+620000"))
+	}
+	expectSetDefault := func(mock sqlmock.Sqlmock, id int) {
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `account_addresses` SET `is_default`=?,`updated_by`=?,`updated_at`=? WHERE (account_id = ? AND id <> ? AND is_default = ?)")).
+			WithArgs(false, "email@mail.com", sqlmock.AnyArg(), 1, id, true).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `account_addresses` SET `is_default`=?,`updated_by`=?,`updated_at`=? WHERE (id = ? AND account_id = ?)")).
+			WithArgs(true, "email@mail.com", sqlmock.AnyArg(), id, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		mock       func(mock sqlmock.Sqlmock)
+		wantStatus int
+	}{
+		{
+			name:   "First address becomes the default",
+			method: http.MethodPost,
+			path:   "/address",
+			body:   `{"label":"Home","address":"Street 1"}`,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectAccount(mock)
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_addresses` WHERE (account_id = ? AND is_default = ?)")).
+					WithArgs(1, true).
+					WillReturnRows(sqlmock.NewRows(addressColumns))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `account_addresses`")).WillReturnResult(sqlmock.NewResult(2, 1))
+				expectSetDefault(mock, 2)
+				mock.ExpectCommit()
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "Switch the default",
+			method: http.MethodPut,
+			path:   "/address/5/default",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectAccount(mock)
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_addresses` WHERE (id = ? AND account_id = ?)")).
+					WithArgs(5, 1).
+					WillReturnRows(sqlmock.NewRows(addressColumns).AddRow(5, 1, "Office", false))
+				expectSetDefault(mock, 5)
+				mock.ExpectCommit()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_addresses` WHERE id = ?")).
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows(addressColumns).AddRow(5, 1, "Office", true))
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			// the address of another account is never touched
+			name:   "Default of another account",
+			method: http.MethodPut,
+			path:   "/address/5/default",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectAccount(mock)
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_addresses` WHERE (id = ? AND account_id = ?)")).
+					WithArgs(5, 1).
+					WillReturnRows(sqlmock.NewRows(addressColumns))
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:   "Deleting the default promotes the latest address",
+			method: http.MethodDelete,
+			path:   "/address/2",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectAccount(mock)
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_addresses` WHERE (id = ? AND account_id = ?)")).
+					WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows(addressColumns).AddRow(2, 1, "Home", true))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `account_addresses` SET `deleted_at`=?")).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_addresses` WHERE account_id = ? AND `account_addresses`.`deleted_at` IS NULL ORDER BY id desc LIMIT 1")).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows(addressColumns).AddRow(5, 1, "Office", false))
+				expectSetDefault(mock, 5)
+				mock.ExpectCommit()
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := AccountAddressNewMockDB()
+			tt.mock(mock)
+
+			s := NewAccountAddressController(db, util.ValidatorTranslate())
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "email@mail.com")
+			})
+			router.POST("/address", s.PostCreateAccountAddress)
+			router.PUT("/address/:id/default", s.PutDefaultAccountAddress)
+			router.DELETE("/address/:id", s.DeleteAccountAddress)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
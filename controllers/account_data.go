@@ -0,0 +1,317 @@
+package controllers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/jobs"
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/auth"
+	"github.com/avarian/online-shopping-cart/service/logging"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type PostRequestErasureRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// AccountExport is everything kept about an account, returned by /account/me/export
+type AccountExport struct {
+	ExportedAt   time.Time            `json:"exported_at"`
+	Account      model.Account        `json:"account"`
+	Carts        []model.Cart         `json:"carts"`
+	Orders       []AccountExportOrder `json:"orders"`
+	VouchersUsed []model.Voucher      `json:"vouchers_used"`
+}
+
+type AccountExportOrder struct {
+	Order         model.Order          `json:"order"`
+	OrderItems    []model.OrderItem    `json:"order_items"`
+	OrderVouchers []model.OrderVoucher `json:"order_vouchers"`
+}
+
+var (
+	errErasurePending      = apperror.New(apperror.Conflict, "erasure_pending", "account erasure already requested")
+	errUnknownExportFormat = apperror.New(apperror.Invalid, "unknown_format", "format must be json or zip")
+)
+
+type AccountDataController struct {
+	db        *gorm.DB
+	validator *util.Validator
+	tokens    *auth.TokenService
+}
+
+func NewAccountDataController(db *gorm.DB, validator *util.Validator, tokens *auth.TokenService) *AccountDataController {
+	return &AccountDataController{
+		db:        db,
+		validator: validator,
+		tokens:    tokens,
+	}
+}
+
+// ExportAccountData	goDocs
+// @Summary      export personal data
+// @Description  export the account, its addresses, carts, orders with their items and vouchers, and the vouchers used, as json or as a zip with a json file each
+// @Tags         Account
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param				 format	query		string	false	"export format" Enums(json, zip)
+// @Produce      application/json
+// @Produce      application/zip
+// @Router       /account/me/export [get]
+func (s *AccountDataController) GetExportAccountData(c *gin.Context) {
+	username := c.GetString("username")
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api":      "GetExportAccountData",
+		"username": username,
+	})
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		logCtx.WithField("reason", "unknown format").Error("error export account")
+		apperror.Abort(c, errUnknownExportFormat)
+		return
+	}
+
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	export, err := exportAccount(s.db.WithContext(c), account)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error export account")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "Sucess!",
+			"data":    export,
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=account-%d-export.zip", account.ID))
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+	if err := writeAccountExportZip(c.Writer, export); err != nil {
+		// the response is already started, nothing to answer anymore
+		logCtx.WithField("reason", err).Error("error write export")
+	}
+}
+
+// RequestAccountErasure	goDocs
+// @Summary      request account erasure
+// @Description  suspend the account and log it out everywhere, its personal data is then erased in the background. Orders are kept with address and phone number replaced
+// @Tags         Account
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PostRequestErasureRequest true "Body Request"
+// @Produce      application/json
+// @Router       /account/me/erasure [post]
+func (s *AccountDataController) PostRequestErasure(c *gin.Context) {
+	// bind data
+	var req PostRequestErasureRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	username := c.GetString("username")
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api":      "PostRequestErasure",
+		"username": username,
+	})
+
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	if err := checkCurrentPassword(account, req.Password); err != nil {
+		logCtx.WithField("reason", err).Error("error compare password")
+		apperror.Abort(c, err)
+		return
+	}
+
+	requestAccountErasure(c, logCtx, s.db.WithContext(c), s.tokens, account, username)
+}
+
+// requestAccountErasure suspends the account, revokes its sessions and dispatches the erasure,
+// the erasure record is the audit trail of who asked and whether it completed
+func requestAccountErasure(c *gin.Context, logCtx *log.Entry, db *gorm.DB, tokens *auth.TokenService, account model.Account, username string) {
+	var erasure model.AccountErasure
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		accountErasureRepo := repository.NewAccountErasureRepository(tx)
+		_, result := accountErasureRepo.OnePendingByAccountId(int(account.ID))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			return errErasurePending
+		}
+
+		accountRepo := repository.NewAccountRepository(tx)
+		if result := accountRepo.Suspend(int(account.ID), username); result.Error != nil {
+			return result.Error
+		}
+
+		erasure, result = accountErasureRepo.Create(model.AccountErasure{
+			AccountID: account.ID,
+			Status:    "PENDING",
+		})
+		return result.Error
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error request erasure")
+		apperror.Abort(c, err)
+		return
+	}
+
+	if err := tokens.RevokeAccountSessions(c.Request.Context(), int(account.ID), username); err != nil {
+		logCtx.WithField("reason", err).Error("error revoke sessions")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	accountErasureRepo := repository.NewAccountErasureRepository(db)
+	if err := jobs.Dispatch(jobs.NewAccountErasureJob(int(erasure.ID))); err != nil {
+		logCtx.WithField("reason", err).Error("error dispatch erasure")
+		accountErasureRepo.MarkFailed(int(erasure.ID), err.Error())
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	logCtx.WithFields(log.Fields{
+		"account_id": account.ID,
+		"erasure_id": erasure.ID,
+	}).Info("account erasure requested")
+
+	erasure, _ = accountErasureRepo.OneById(int(erasure.ID))
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Sucess!",
+		"data":    erasure,
+	})
+}
+
+func exportAccount(db *gorm.DB, account model.Account) (AccountExport, error) {
+	export := AccountExport{
+		ExportedAt:   time.Now(),
+		Orders:       []AccountExportOrder{},
+		VouchersUsed: []model.Voucher{},
+	}
+
+	accountAddressRepo := repository.NewAccountAddressRepository(db)
+	addresses, result := accountAddressRepo.AllByAccountId(int(account.ID))
+	if result.Error != nil {
+		return export, result.Error
+	}
+	account.AccountAddress = addresses
+	export.Account = account
+
+	cartRepo := repository.NewCartRepository(db)
+	carts, result := cartRepo.AllByOwner(int(account.ID), "", "Item")
+	if result.Error != nil {
+		return export, result.Error
+	}
+	export.Carts = carts
+
+	orderRepo := repository.NewOrderRepository(db)
+	orders, result := orderRepo.AllByAccountId(int(account.ID))
+	if result.Error != nil {
+		return export, result.Error
+	}
+
+	orderItemRepo := repository.NewOrderItemRepository(db)
+	orderVoucherRepo := repository.NewOrderVoucherRepository(db)
+	seen := map[uint]bool{}
+	for _, order := range orders {
+		orderItems, result := orderItemRepo.AllByOrderId(int(order.ID))
+		if result.Error != nil {
+			return export, result.Error
+		}
+		orderVouchers, result := orderVoucherRepo.AllByOrderId(int(order.ID), "Voucher")
+		if result.Error != nil {
+			return export, result.Error
+		}
+
+		for i, v := range orderVouchers {
+			if v.Voucher != nil && !seen[v.VoucherID] {
+				seen[v.VoucherID] = true
+				export.VouchersUsed = append(export.VouchersUsed, *v.Voucher)
+			}
+			orderVouchers[i].Voucher = nil
+		}
+
+		export.Orders = append(export.Orders, AccountExportOrder{
+			Order:         order,
+			OrderItems:    orderItems,
+			OrderVouchers: orderVouchers,
+		})
+	}
+
+	return export, nil
+}
+
+func writeAccountExportZip(w http.ResponseWriter, export AccountExport) error {
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"account.json", export.Account},
+		{"carts.json", export.Carts},
+		{"orders.json", export.Orders},
+		{"vouchers_used.json", export.VouchersUsed},
+	}
+	for _, v := range files {
+		f, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     v.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(v.data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
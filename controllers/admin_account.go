@@ -0,0 +1,543 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/auth"
+	"github.com/avarian/online-shopping-cart/service/logging"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type PutAdminAccountActionRequest struct {
+	Reason string `json:"reason" validate:"required,max=1024"`
+}
+
+// Only demoting is left to the type, an account becomes ADMIN by being assigned a role
+type PutEditAccountTypeRequest struct {
+	Type   string `json:"type" validate:"required,oneof=CUSTOMER"`
+	Reason string `json:"reason" validate:"required,max=1024"`
+}
+
+var (
+	errOwnAccount          = apperror.New(apperror.Invalid, "own_account", "cannot change own account")
+	errAccountSuspended    = apperror.New(apperror.Conflict, "account_suspended", "account already suspended")
+	errAccountNotSuspended = apperror.New(apperror.Conflict, "account_not_suspended", "account not suspended")
+)
+
+type AdminAccountController struct {
+	db        *gorm.DB
+	validator *util.Validator
+	tokens    *auth.TokenService
+	throttle  *auth.LoginThrottle
+	reset     PasswordResetConfig
+}
+
+func NewAdminAccountController(db *gorm.DB, validator *util.Validator, tokens *auth.TokenService, throttle *auth.LoginThrottle, reset PasswordResetConfig) *AdminAccountController {
+	return &AdminAccountController{
+		db:        db,
+		validator: validator,
+		tokens:    tokens,
+		throttle:  throttle,
+		reset:     reset,
+	}
+}
+
+// GetAllAccount	goDocs
+// @Summary      get all accounts
+// @Description  get all accounts with pagination, need permission account:manage
+// @Tags         Admin
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param				 email	query		string	false	"email account"
+// @Param				 name	query		string	false	"name account"
+// @Param				 type	query		string	false	"type account" Enums(CUSTOMER, ADMIN)
+// @Param				 status	query		string	false	"status account" Enums(ACTIVE, SUSPENDED)
+// @Produce      application/json
+// @Router       /admin/account/all [get]
+func (s *AdminAccountController) GetAccounts(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api":    "GetAccounts",
+		"params": c.Request.URL.RawQuery,
+	})
+
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.Index(c.Request)
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error find account")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	meta := accountRepo.MetaPaginate(c.Request)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    account,
+		"meta":    meta,
+	})
+}
+
+// GetOneAccountDetail	goDocs
+// @Summary      get one account detail
+// @Description  get one account with its address book, need permission account:manage
+// @Tags         Admin
+// @Param				 id path int true "get detail by id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /admin/account/{id} [get]
+func (s *AdminAccountController) GetAccountDetail(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "GetAccountDetail",
+	})
+
+	account, ok := s.findAccount(c, logCtx, "AccountAddress")
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    account,
+	})
+}
+
+// SuspendAccount	goDocs
+// @Summary      suspend account
+// @Description  suspend an account, it cannot login anymore and its sessions are revoked, the reason is kept in the admin actions of the account, need permission account:manage
+// @Tags         Admin
+// @Param				 id path int true "account id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PutAdminAccountActionRequest true "Body Request"
+// @Produce      application/json
+// @Router       /admin/account/{id}/suspend [put]
+func (s *AdminAccountController) PutSuspendAccount(c *gin.Context) {
+	req, ok := s.bindAction(c)
+	if !ok {
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PutSuspendAccount",
+	})
+
+	account, ok := s.findAccount(c, logCtx)
+	if !ok {
+		return
+	}
+
+	username := c.GetString("username")
+	if account.Email == username {
+		logCtx.WithField("reason", errOwnAccount).Error("error suspend account")
+		apperror.Abort(c, errOwnAccount)
+		return
+	}
+
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		accountRepo := repository.NewAccountRepository(tx)
+		result := accountRepo.Suspend(int(account.ID), username)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errAccountSuspended
+		}
+		return recordAdminAction(tx, account, "SUSPEND", req.Reason, "")
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error suspend account")
+		apperror.Abort(c, err)
+		return
+	}
+
+	if err := s.tokens.RevokeAccountSessions(c.Request.Context(), int(account.ID), username); err != nil {
+		logCtx.WithField("reason", err).Error("error revoke sessions")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, _ = accountRepo.OneById(int(account.ID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    account,
+	})
+}
+
+// ReactivateAccount	goDocs
+// @Summary      reactivate account
+// @Description  lift the suspension of an account, the reason is kept in the admin actions of the account, need permission account:manage
+// @Tags         Admin
+// @Param				 id path int true "account id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PutAdminAccountActionRequest true "Body Request"
+// @Produce      application/json
+// @Router       /admin/account/{id}/reactivate [put]
+func (s *AdminAccountController) PutReactivateAccount(c *gin.Context) {
+	req, ok := s.bindAction(c)
+	if !ok {
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PutReactivateAccount",
+	})
+
+	account, ok := s.findAccount(c, logCtx)
+	if !ok {
+		return
+	}
+
+	username := c.GetString("username")
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		accountRepo := repository.NewAccountRepository(tx)
+		result := accountRepo.Reactivate(int(account.ID), username)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errAccountNotSuspended
+		}
+		return recordAdminAction(tx, account, "REACTIVATE", req.Reason, "")
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error reactivate account")
+		apperror.Abort(c, err)
+		return
+	}
+
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, _ = accountRepo.OneById(int(account.ID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    account,
+	})
+}
+
+// ForcePasswordReset	goDocs
+// @Summary      force password reset
+// @Description  revoke the sessions of an account and email it a reset link, it cannot login until the password is reset, the reason is kept in the admin actions of the account, need permission account:manage
+// @Tags         Admin
+// @Param				 id path int true "account id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PutAdminAccountActionRequest true "Body Request"
+// @Produce      application/json
+// @Router       /admin/account/{id}/password-reset [post]
+func (s *AdminAccountController) PostForcePasswordReset(c *gin.Context) {
+	req, ok := s.bindAction(c)
+	if !ok {
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostForcePasswordReset",
+	})
+
+	account, ok := s.findAccount(c, logCtx)
+	if !ok {
+		return
+	}
+
+	username := c.GetString("username")
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		accountRepo := repository.NewAccountRepository(tx)
+		if result := accountRepo.UpdatePasswordResetRequired(int(account.ID), true, username); result.Error != nil {
+			return result.Error
+		}
+		return recordAdminAction(tx, account, "FORCE_PASSWORD_RESET", req.Reason, "")
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error force password reset")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	if err := s.tokens.RevokeAccountSessions(c.Request.Context(), int(account.ID), username); err != nil {
+		logCtx.WithField("reason", err).Error("error revoke sessions")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	if err := sendPasswordResetLink(s.db.WithContext(c), s.reset, account, username); err != nil {
+		logCtx.WithField("reason", err).Error("error send reset link")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+	})
+}
+
+// UnlockAccount	goDocs
+// @Summary      unlock account login
+// @Description  lift the lockout after too many failed logins of an account and reset its back-off, the reason is kept in the admin actions of the account, need permission account:manage
+// @Tags         Admin
+// @Param				 id path int true "account id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PutAdminAccountActionRequest true "Body Request"
+// @Produce      application/json
+// @Router       /admin/account/{id}/unlock [put]
+func (s *AdminAccountController) PutUnlockAccount(c *gin.Context) {
+	req, ok := s.bindAction(c)
+	if !ok {
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PutUnlockAccount",
+	})
+
+	account, ok := s.findAccount(c, logCtx)
+	if !ok {
+		return
+	}
+
+	if err := s.throttle.Unlock(c.Request.Context(), account.Email); err != nil {
+		logCtx.WithField("reason", err).Error("error unlock account")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	// the lockout lives in redis, only the record of lifting it is kept in the database
+	if err := recordAdminAction(s.db.WithContext(c), account, "UNLOCK", req.Reason, ""); err != nil {
+		logCtx.WithField("reason", err).Error("error record admin action")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+	})
+}
+
+// EditAccountType	goDocs
+// @Summary      change account type
+// @Description  demote an account to CUSTOMER, its roles are removed and its sessions revoked, accounts become ADMIN by being assigned a role, the reason is kept in the admin actions of the account, need permission account:manage
+// @Tags         Admin
+// @Param				 id path int true "account id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PutEditAccountTypeRequest true "Body Request"
+// @Produce      application/json
+// @Router       /admin/account/{id}/type [put]
+func (s *AdminAccountController) PutEditAccountType(c *gin.Context) {
+	// bind data
+	var req PutEditAccountTypeRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PutEditAccountType",
+	})
+
+	account, ok := s.findAccount(c, logCtx)
+	if !ok {
+		return
+	}
+
+	username := c.GetString("username")
+	if account.Email == username {
+		logCtx.WithField("reason", errOwnAccount).Error("error edit account type")
+		apperror.Abort(c, errOwnAccount)
+		return
+	}
+
+	// the type alone grants nothing, the roles of the account are what it may do
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		accountRoleRepo := repository.NewAccountRoleRepository(tx)
+		if result := accountRoleRepo.DeleteByAccountId(int(account.ID)); result.Error != nil {
+			return result.Error
+		}
+		accountRepo := repository.NewAccountRepository(tx)
+		if result := accountRepo.UpdateType(int(account.ID), req.Type, username); result.Error != nil {
+			return result.Error
+		}
+		return recordAdminAction(tx, account, "CHANGE_TYPE", req.Reason, account.Type+" to "+req.Type)
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error edit account type")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	// tokens carry the permissions of the roles, they must not outlive them
+	if err := s.tokens.RevokeAccountSessions(c.Request.Context(), int(account.ID), username); err != nil {
+		logCtx.WithField("reason", err).Error("error revoke sessions")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, _ = accountRepo.OneById(int(account.ID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    account,
+	})
+}
+
+// GetAllAdminAction	goDocs
+// @Summary      get all admin actions
+// @Description  get the actions admins took on accounts with who took them and why, with pagination, need permission account:manage
+// @Tags         Admin
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param				 account_id	query		int	false	"account id"
+// @Param				 action	query		string	false	"action" Enums(SUSPEND, REACTIVATE, FORCE_PASSWORD_RESET, UNLOCK, CHANGE_TYPE)
+// @Produce      application/json
+// @Router       /admin/account/action/all [get]
+func (s *AdminAccountController) GetAdminActions(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api":    "GetAdminActions",
+		"params": c.Request.URL.RawQuery,
+	})
+
+	adminActionRepo := repository.NewAdminActionRepository(s.db.WithContext(c))
+	actions, result := adminActionRepo.Index(c.Request)
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error find admin action")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	meta := adminActionRepo.MetaPaginate(c.Request)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    actions,
+		"meta":    meta,
+	})
+}
+
+// GetAllAccountErasure	goDocs
+// @Summary      get all account erasures
+// @Description  get all account erasure requests with pagination, need permission account:manage
+// @Tags         Admin
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param				 account_id	query		int	false	"account id"
+// @Param				 status	query		string	false	"status erasure" Enums(PENDING, COMPLETED, FAILED)
+// @Produce      application/json
+// @Router       /admin/account/erasure/all [get]
+func (s *AdminAccountController) GetAccountErasures(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api":    "GetAccountErasures",
+		"params": c.Request.URL.RawQuery,
+	})
+
+	accountErasureRepo := repository.NewAccountErasureRepository(s.db.WithContext(c))
+	erasures, result := accountErasureRepo.Index(c.Request)
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error find account erasure")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	meta := accountErasureRepo.MetaPaginate(c.Request)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    erasures,
+		"meta":    meta,
+	})
+}
+
+// EraseAccount	goDocs
+// @Summary      erase account
+// @Description  suspend an account and log it out everywhere, its personal data is then erased in the background, need permission account:manage
+// @Tags         Admin
+// @Param				 id path int true "account id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /admin/account/{id}/erasure [post]
+func (s *AdminAccountController) PostEraseAccount(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostEraseAccount",
+	})
+
+	account, ok := s.findAccount(c, logCtx)
+	if !ok {
+		return
+	}
+
+	username := c.GetString("username")
+	if account.Email == username {
+		logCtx.WithField("reason", errOwnAccount).Error("error erase account")
+		apperror.Abort(c, errOwnAccount)
+		return
+	}
+
+	requestAccountErasure(c, logCtx, s.db.WithContext(c), s.tokens, account, username)
+}
+
+func (s *AdminAccountController) findAccount(c *gin.Context, logCtx *log.Entry, preload ...string) (model.Account, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error parse id")
+		apperror.Abort(c, errInvalidId)
+		return model.Account{}, false
+	}
+
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneById(id, preload...)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return model.Account{}, false
+	}
+
+	return account, true
+}
+
+// bindAction binds the reason every admin action on an account has to give
+func (s *AdminAccountController) bindAction(c *gin.Context) (PutAdminAccountActionRequest, bool) {
+	var req PutAdminAccountActionRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return req, false
+	}
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return req, false
+	}
+	return req, true
+}
+
+// recordAdminAction keeps what was done to the account and why, the admin is the actor of db
+func recordAdminAction(db *gorm.DB, account model.Account, action string, reason string, detail string) error {
+	adminActionRepo := repository.NewAdminActionRepository(db)
+	_, result := adminActionRepo.Create(model.AdminAction{
+		AccountID: account.ID,
+		Action:    action,
+		Reason:    reason,
+		Detail:    detail,
+	})
+	return result.Error
+}
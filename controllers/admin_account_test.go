@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/auth"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func AdminAccountNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_AdminAccountActions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	expectAccount := func(mock sqlmock.Sqlmock, accountType string) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE id = ?")).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "type"}).AddRow(2, "customer@mail.com", accountType))
+	}
+	expectAction := func(mock sqlmock.Sqlmock, action string, detail string) {
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `admin_actions`")).
+			WithArgs(2, action, "chargeback fraud", detail, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	expectRevoke := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT `family_id` FROM `refresh_tokens`")).
+			WillReturnRows(sqlmock.NewRows([]string{"family_id"}))
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		mock       func(mock sqlmock.Sqlmock)
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:   "Suspend records the reason",
+			method: http.MethodPut,
+			path:   "/admin/account/2/suspend",
+			body:   `{"reason":"chargeback fraud"}`,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectAccount(mock, "CUSTOMER")
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `accounts` SET `suspended_at`=?,`updated_by`=?")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAction(mock, "SUSPEND", "")
+				mock.ExpectCommit()
+				expectRevoke(mock)
+				expectAccount(mock, "CUSTOMER")
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Suspend without reason",
+			method:     http.MethodPut,
+			path:       "/admin/account/2/suspend",
+			body:       `{}`,
+			mock:       func(mock sqlmock.Sqlmock) {},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			// nothing changed, so nothing is recorded
+			name:   "Suspend suspended account",
+			method: http.MethodPut,
+			path:   "/admin/account/2/suspend",
+			body:   `{"reason":"chargeback fraud"}`,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectAccount(mock, "CUSTOMER")
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `accounts` SET `suspended_at`=?,`updated_by`=?")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusConflict,
+			wantCode:   "account_suspended",
+		},
+		{
+			// an action without its record is rolled back
+			name:   "Force password reset fails to record",
+			method: http.MethodPost,
+			path:   "/admin/account/2/password-reset",
+			body:   `{"reason":"chargeback fraud"}`,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectAccount(mock, "CUSTOMER")
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `accounts` SET `password_reset_required`=?,`updated_by`=?")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `admin_actions`")).
+					WillReturnError(errors.New("connection lost"))
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   apperror.ErrInternal.Code,
+		},
+		{
+			// the roles are what an admin may do, demoting has to take them away
+			name:   "Demote to customer removes the roles",
+			method: http.MethodPut,
+			path:   "/admin/account/2/type",
+			body:   `{"type":"CUSTOMER","reason":"chargeback fraud"}`,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectAccount(mock, "ADMIN")
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `account_roles` WHERE account_id = ?")).
+					WithArgs(2).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `accounts` SET `type`=?,`updated_by`=?")).
+					WithArgs("CUSTOMER", "admin@mail.com", sqlmock.AnyArg(), 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAction(mock, "CHANGE_TYPE", "ADMIN to CUSTOMER")
+				mock.ExpectCommit()
+				expectRevoke(mock)
+				expectAccount(mock, "CUSTOMER")
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			// the type alone grants nothing, accounts become ADMIN by being assigned a role
+			name:       "Refuse ADMIN type",
+			method:     http.MethodPut,
+			path:       "/admin/account/2/type",
+			body:       `{"type":"ADMIN","reason":"new staff"}`,
+			mock:       func(mock sqlmock.Sqlmock) {},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := AdminAccountNewMockDB()
+			tt.mock(mock)
+
+			tokens := auth.NewTokenService(db, "secret", nil, 0, 0, nil)
+			s := NewAdminAccountController(db, util.ValidatorTranslate(), tokens, nil, PasswordResetConfig{})
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "admin@mail.com")
+			})
+			router.PUT("/admin/account/:id/suspend", s.PutSuspendAccount)
+			router.POST("/admin/account/:id/password-reset", s.PostForcePasswordReset)
+			router.PUT("/admin/account/:id/type", s.PutEditAccountType)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				var body apperror.Response
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tt.wantCode, body.Error.Code)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
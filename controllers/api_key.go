@@ -0,0 +1,295 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/auth"
+	"github.com/avarian/online-shopping-cart/service/logging"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type PostCreateApiKeyRequest struct {
+	AccountID   int        `json:"account_id" validate:"required"`
+	Name        string     `json:"name" validate:"required,max=255"`
+	Permissions []string   `json:"permissions" validate:"required,min=1,dive,required"`
+	ExpiredAt   *time.Time `json:"expired_at"`
+}
+
+type ApiKeyCreatedResponse struct {
+	Key    string       `json:"key"`
+	ApiKey model.ApiKey `json:"api_key"`
+}
+
+var (
+	errApiKeyNotFound        = apperror.New(apperror.NotFound, "api_key_not_found", "api key not found")
+	errPermissionNotGranted  = apperror.New(apperror.Invalid, "permission_not_granted", "permission not granted to the account")
+	errApiKeyExpiredAtInPast = apperror.New(apperror.Invalid, "expired_at_in_past", "expired_at must be in the future")
+	errApiKeyRevoked         = apperror.New(apperror.Conflict, "api_key_revoked", "api key already revoked")
+)
+
+type ApiKeyController struct {
+	db        *gorm.DB
+	validator *util.Validator
+}
+
+func NewApiKeyController(db *gorm.DB, validator *util.Validator) *ApiKeyController {
+	return &ApiKeyController{
+		db:        db,
+		validator: validator,
+	}
+}
+
+// GetAllApiKey	goDocs
+// @Summary      get all api keys
+// @Description  get all api keys with pagination and their permissions, need permission api_key:manage
+// @Tags         Admin
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param				 account_id	query		int	false	"owner account id"
+// @Param				 status	query		string	false	"status api key" Enums(ACTIVE, EXPIRED, REVOKED)
+// @Produce      application/json
+// @Router       /admin/api-key/all [get]
+func (s *ApiKeyController) GetApiKeys(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api":    "GetApiKeys",
+		"params": c.Request.URL.RawQuery,
+	})
+
+	apiKeyRepo := repository.NewApiKeyRepository(s.db.WithContext(c))
+	apiKeys, result := apiKeyRepo.Index(c.Request, "ApiKeyPermission")
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error find api key")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	meta := apiKeyRepo.MetaPaginate(c.Request)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    apiKeys,
+		"meta":    meta,
+	})
+}
+
+// GetOneApiKeyDetail	goDocs
+// @Summary      get one api key detail
+// @Description  get one api key with its owner and permissions, the key itself is only shown when created, need permission api_key:manage
+// @Tags         Admin
+// @Param				 id path int true "get detail by id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /admin/api-key/{id} [get]
+func (s *ApiKeyController) GetApiKeyDetail(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "GetApiKeyDetail",
+	})
+
+	apiKey, ok := s.findApiKey(c, logCtx, "Account", "ApiKeyPermission")
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    apiKey,
+	})
+}
+
+// CreateApiKey	goDocs
+// @Summary      create api key
+// @Description  create an api key owned by an account for "Authorization: ApiKey {key}", permissions must be granted to the account by its roles, the key is only accepted on routes needing one of its permissions and only shown in this response, need permission api_key:manage
+// @Tags         Admin
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PostCreateApiKeyRequest true "Body Request"
+// @Produce      application/json
+// @Router       /admin/api-key [post]
+func (s *ApiKeyController) PostCreateApiKey(c *gin.Context) {
+	// bind data
+	var req PostCreateApiKeyRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api":        "PostCreateApiKey",
+		"account_id": req.AccountID,
+	})
+
+	if req.ExpiredAt != nil && !req.ExpiredAt.After(time.Now()) {
+		logCtx.WithField("reason", errApiKeyExpiredAtInPast).Error("error create api key")
+		apperror.Abort(c, errApiKeyExpiredAtInPast)
+		return
+	}
+
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneById(req.AccountID)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+	if account.SuspendedAt != nil {
+		logCtx.WithField("reason", auth.ErrAccountSuspended).Error("error create api key")
+		apperror.Abort(c, auth.ErrAccountSuspended)
+		return
+	}
+
+	key, prefix, err := auth.GenerateApiKey()
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error generate api key")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	var apiKey model.ApiKey
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		accountRoleRepo := repository.NewAccountRoleRepository(tx)
+		granted, result := accountRoleRepo.AllPermissionsByAccountId(req.AccountID)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		apiKeyRepo := repository.NewApiKeyRepository(tx)
+		apiKey, result = apiKeyRepo.Create(model.ApiKey{
+			AccountID: account.ID,
+			Name:      req.Name,
+			Prefix:    prefix,
+			KeyHash:   util.HashToken(key),
+			ExpiredAt: req.ExpiredAt,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return setApiKeyPermissions(tx, int(apiKey.ID), req.Permissions, granted)
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error create api key")
+		apperror.Abort(c, err)
+		return
+	}
+
+	apiKeyRepo := repository.NewApiKeyRepository(s.db.WithContext(c))
+	apiKey, _ = apiKeyRepo.OneById(int(apiKey.ID), "ApiKeyPermission")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data": ApiKeyCreatedResponse{
+			Key:    key,
+			ApiKey: apiKey,
+		},
+	})
+}
+
+// RevokeApiKey	goDocs
+// @Summary      revoke api key
+// @Description  revoke an api key, requests with it are refused right away, need permission api_key:manage
+// @Tags         Admin
+// @Param				 id path int true "api key id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /admin/api-key/{id}/revoke [put]
+func (s *ApiKeyController) PutRevokeApiKey(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PutRevokeApiKey",
+	})
+
+	apiKey, ok := s.findApiKey(c, logCtx)
+	if !ok {
+		return
+	}
+
+	apiKeyRepo := repository.NewApiKeyRepository(s.db.WithContext(c))
+	result := apiKeyRepo.Revoke(int(apiKey.ID), c.GetString("username"))
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error revoke api key")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+	if result.RowsAffected == 0 {
+		logCtx.WithField("reason", "already revoked").Error("error revoke api key")
+		apperror.Abort(c, errApiKeyRevoked)
+		return
+	}
+
+	apiKey, _ = apiKeyRepo.OneById(int(apiKey.ID), "ApiKeyPermission")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    apiKey,
+	})
+}
+
+func (s *ApiKeyController) findApiKey(c *gin.Context, logCtx *log.Entry, preload ...string) (model.ApiKey, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error parse id")
+		apperror.Abort(c, errInvalidId)
+		return model.ApiKey{}, false
+	}
+
+	apiKeyRepo := repository.NewApiKeyRepository(s.db.WithContext(c))
+	apiKey, result := apiKeyRepo.OneById(id, preload...)
+	if result.RowsAffected == 0 || result.Error != nil {
+		var err error = errApiKeyNotFound
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find api key")
+		apperror.Abort(c, errApiKeyNotFound)
+		return model.ApiKey{}, false
+	}
+
+	return apiKey, true
+}
+
+// setApiKeyPermissions only stores permissions the owner has, a role removed later still narrows the key on use
+func setApiKeyPermissions(db *gorm.DB, apiKeyId int, permissions []string, granted []string) error {
+	apiKeyPermissionRepo := repository.NewApiKeyPermissionRepository(db)
+	seen := map[string]bool{}
+	for _, v := range permissions {
+		if !auth.IsPermission(v) {
+			return fmt.Errorf("%s: %w", v, errUnknownPermission)
+		}
+		if !auth.HasPermissions(granted, v) {
+			return fmt.Errorf("%s: %w", v, errPermissionNotGranted)
+		}
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+
+		if _, result := apiKeyPermissionRepo.Create(model.ApiKeyPermission{
+			ApiKeyID:   uint(apiKeyId),
+			Permission: v,
+		}); result.Error != nil {
+			return result.Error
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/logging"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// auditLogExportLimit is the most logs one csv export holds, narrow the filters for more
+const auditLogExportLimit = 10000
+
+type AuditLogController struct {
+	db *gorm.DB
+}
+
+func NewAuditLogController(db *gorm.DB) *AuditLogController {
+	return &AuditLogController{
+		db: db,
+	}
+}
+
+// GetAllAuditLog	goDocs
+// @Summary      get all audit logs
+// @Description  get all audit logs of creates, updates and deletes with pagination, before and after hold the changed columns as json, need permission audit:read
+// @Tags         Admin
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param				 actor	query		string	false	"email of the actor, SYSTEM for jobs, GUEST for guest carts"
+// @Param				 action	query		string	false	"action" Enums(CREATE, UPDATE, DELETE)
+// @Param				 entity	query		string	false	"table name, e.g. orders"
+// @Param				 entity_id	query		string	false	"id of the row"
+// @Param				 request_id	query		string	false	"request id"
+// @Param				 client_ip	query		string	false	"client ip"
+// @Param				 from	query		string	false	"created at or after, RFC3339"
+// @Param				 to	query		string	false	"created before, RFC3339"
+// @Produce      application/json
+// @Router       /admin/audit-log/all [get]
+func (s *AuditLogController) GetAuditLogs(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api":    "GetAuditLogs",
+		"params": c.Request.URL.RawQuery,
+	})
+
+	auditLogRepo := repository.NewAuditLogRepository(s.db.WithContext(c))
+	auditLogs, result := auditLogRepo.Index(c.Request)
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error find audit log")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	meta := auditLogRepo.MetaPaginate(c.Request)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    auditLogs,
+		"meta":    meta,
+	})
+}
+
+// ExportAuditLog	goDocs
+// @Summary      export audit logs
+// @Description  export the newest 10000 audit logs matching the filters of /admin/audit-log/all as csv, need permission audit:read
+// @Tags         Admin
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param				 actor	query		string	false	"email of the actor, SYSTEM for jobs, GUEST for guest carts"
+// @Param				 action	query		string	false	"action" Enums(CREATE, UPDATE, DELETE)
+// @Param				 entity	query		string	false	"table name, e.g. orders"
+// @Param				 entity_id	query		string	false	"id of the row"
+// @Param				 request_id	query		string	false	"request id"
+// @Param				 client_ip	query		string	false	"client ip"
+// @Param				 from	query		string	false	"created at or after, RFC3339"
+// @Param				 to	query		string	false	"created before, RFC3339"
+// @Produce      text/csv
+// @Router       /admin/audit-log/export [get]
+func (s *AuditLogController) GetExportAuditLogs(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api":    "GetExportAuditLogs",
+		"params": c.Request.URL.RawQuery,
+	})
+
+	auditLogRepo := repository.NewAuditLogRepository(s.db.WithContext(c))
+	auditLogs, result := auditLogRepo.Latest(c.Request, auditLogExportLimit)
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error find audit log")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=audit-log-%s.csv", time.Now().Format("20060102150405")))
+	c.Header("Content-Type", "text/csv")
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"id", "created_at", "actor", "action", "entity", "entity_id", "before", "after", "request_id", "client_ip"})
+	for _, v := range auditLogs {
+		createdAt := ""
+		if v.CreatedAt != nil {
+			createdAt = v.CreatedAt.Format(time.RFC3339)
+		}
+		w.Write([]string{
+			strconv.Itoa(int(v.ID)),
+			createdAt,
+			v.Actor,
+			v.Action,
+			v.Entity,
+			v.EntityID,
+			v.Before,
+			v.After,
+			v.RequestID,
+			v.ClientIP,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		// the response is already started, nothing to answer anymore
+		logCtx.WithField("reason", err).Error("error write export")
+	}
+}
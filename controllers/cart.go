@@ -2,14 +2,17 @@ package controllers
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/logging"
 	"github.com/avarian/online-shopping-cart/service/repository"
 	"github.com/avarian/online-shopping-cart/util"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
@@ -23,82 +26,129 @@ type PutEditCartRequest struct {
 	Qty *int `json:"qty"  validate:"required"`
 }
 
+type CartWarning struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+type CartResponse struct {
+	model.Cart
+	Warnings []CartWarning `json:"warnings"`
+}
+
+type CartSectionsResponse struct {
+	Active []CartResponse `json:"active"`
+	Saved  []CartResponse `json:"saved"`
+}
+
+type PutEditCartsRequest struct {
+	Carts []PutEditCartsLine `json:"carts" validate:"required,min=1,dive"`
+}
+
+type PutEditCartsLine struct {
+	ID  int  `json:"id" validate:"required"`
+	Qty *int `json:"qty" validate:"required,min=0"`
+}
+
+var (
+	errItemNotFound    = apperror.New(apperror.NotFound, "item_not_found", "item not found")
+	errQtyExceedsStock = apperror.New(apperror.PreconditionFailed, "qty_exceeds_stock", "qty > item qty")
+	errCartNotFound    = apperror.New(apperror.NotFound, "cart_not_found", "cart not found")
+	errCartItemDeleted = apperror.New(apperror.PreconditionFailed, "item_deleted", "item deleted, set qty 0 to remove the line")
+)
+
 type CartController struct {
-	db        *gorm.DB
-	validator *util.Validator
+	db          *gorm.DB
+	validator   *util.Validator
+	guestSecret string
+	guestTTL    time.Duration
 }
 
-func NewCartController(db *gorm.DB, validator *util.Validator) *CartController {
+func NewCartController(db *gorm.DB, validator *util.Validator, guestSecret string, guestTTL time.Duration) *CartController {
 	return &CartController{
-		db:        db,
-		validator: validator,
+		db:          db,
+		validator:   validator,
+		guestSecret: guestSecret,
+		guestTTL:    guestTTL,
 	}
 }
 
+// CreateGuestCart	goDocs
+// @Summary      create guest cart token
+// @Description  create a signed guest token valid for guest_token.ttl hours, send it as X-Guest-Token header instead of Authorization to use cart as a guest, the guest cart is merged into the account cart on login or register with the same header
+// @Tags         Cart
+// @Produce      application/json
+// @Router       /cart/guest [post]
+func (s *CartController) PostCreateGuestCart(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostCreateGuestCart",
+	})
+
+	_, token, expiredAt, err := util.NewGuestToken(s.guestSecret, s.guestTTL)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error generate guest token")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Sucess!",
+		"guest_token": token,
+		"expired_at":  expiredAt,
+	})
+}
+
 // GetAllCarts	goDocs
 // @Summary      get all own carts
-// @Description  get all own carts. need credentials.
+// @Description  get all own carts split into active and saved for later sections, with warnings of lines whose price changed since added, stock is lower than qty or item is deleted. need credentials.
 // @Tags         Cart
-// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param				 Authorization	header		string	false	"Bearer {token}" default(Bearer {token})
+// @Param				 X-Guest-Token	header		string	false	"guest token from /cart/guest when not logged in"
 // @Produce      application/json
 // @Router       /cart/all [get]
 func (s *CartController) GetCarts(c *gin.Context) {
 	// log
-	logCtx := log.WithFields(log.Fields{
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
 		"api": "GetCarts",
 	})
 
-	username := c.GetString("username")
-	accountRepo := repository.NewAccountRepository(s.db)
-	account, result := accountRepo.OneByEmail(username)
-	if result.RowsAffected == 0 || result.Error != nil {
-		err := errors.New("error find account")
-		if result.Error != nil {
-			err = result.Error
-		}
-		logCtx.WithField("reason", err).Error("error find account")
-		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "account not found"})
+	owner, ok := s.cartOwner(c, logCtx)
+	if !ok {
 		return
 	}
 
-	cartRepo := repository.NewCartRepository(s.db)
-	cart, result := cartRepo.AllByAccountId(int(account.ID), "Item")
+	cartRepo := repository.NewCartRepository(s.db.WithContext(c))
+	cart, result := cartRepo.AllByOwner(owner.accountId, owner.guestId, "Item")
 	if result.Error != nil {
 		logCtx.WithField("reason", result.Error).Error("error find cart")
-		c.AbortWithStatusJSON(http.StatusNotFound, nil)
+		apperror.Abort(c, apperror.ErrInternal)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Sucess!",
-		"data":    cart,
+		"data":    newCartSectionsResponse(cart),
 	})
 }
 
 // GetOneCartDetail	goDocs
 // @Summary      get one own carts detail
-// @Description  get one own carts detail, need credentials
+// @Description  get one own carts detail with its warnings, need credentials
 // @Tags         Cart
 // @Param				 id path int true "get detail by id"
-// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param				 Authorization	header		string	false	"Bearer {token}" default(Bearer {token})
+// @Param				 X-Guest-Token	header		string	false	"guest token from /cart/guest when not logged in"
 // @Produce      application/json
 // @Router       /cart/{id} [get]
 func (s *CartController) GetCartDetail(c *gin.Context) {
 	// log
-	logCtx := log.WithFields(log.Fields{
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
 		"api": "GetCart",
 	})
 
-	username := c.GetString("username")
-	accountRepo := repository.NewAccountRepository(s.db)
-	account, result := accountRepo.OneByEmail(username)
-	if result.RowsAffected == 0 || result.Error != nil {
-		err := errors.New("error find account")
-		if result.Error != nil {
-			err = result.Error
-		}
-		logCtx.WithField("reason", err).Error("error find account")
-		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "account not found"})
+	owner, ok := s.cartOwner(c, logCtx)
+	if !ok {
 		return
 	}
 
@@ -106,33 +156,34 @@ func (s *CartController) GetCartDetail(c *gin.Context) {
 	id, err := strconv.Atoi(idS)
 	if err != nil {
 		logCtx.WithField("reason", err).Error("error parse id")
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid id"})
+		apperror.Abort(c, errInvalidId)
 		return
 	}
 
-	cartRepo := repository.NewCartRepository(s.db)
-	cart, result := cartRepo.OneByIdAndAccountId(id, int(account.ID), "Item")
+	cartRepo := repository.NewCartRepository(s.db.WithContext(c))
+	cart, result := cartRepo.OneByIdAndOwner(id, owner.accountId, owner.guestId, "Item")
 	if result.RowsAffected == 0 || result.Error != nil {
 		err := errors.New("error find cart")
 		if result.Error != nil {
 			err = result.Error
 		}
 		logCtx.WithField("reason", err).Error("error find cart")
-		c.AbortWithStatusJSON(http.StatusNotFound, nil)
+		apperror.Abort(c, errCartNotFound)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data":    cart,
+		"data":    newCartResponse(cart),
 		"message": "Sucess!",
 	})
 }
 
 // AddToCart	goDocs
 // @Summary      add to own cart from item
-// @Description  add to own cart from item, need credential
+// @Description  add to own cart from item, qty is added to the existing line when item already in cart and the line keeps the price it was added at, need credential
 // @Tags         Cart
-// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param				 Authorization	header		string	false	"Bearer {token}" default(Bearer {token})
+// @Param				 X-Guest-Token	header		string	false	"guest token from /cart/guest when not logged in"
 // @Param        tags body PostCreateCartFromItemRequest true "Body Request"
 // @Produce      application/json
 // @Router       /cart [post]
@@ -140,64 +191,36 @@ func (s *CartController) PostCreateCartFromItem(c *gin.Context) {
 	// bind data
 	var req PostCreateCartFromItemRequest
 	if err := c.ShouldBind(&req); err != nil {
-		log.WithField("reason", err).Error("error Binding")
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
 		return
 	}
 	// validate
 	if err := s.validator.Validate.Struct(&req); err != nil {
-		log.WithField("reason", err).Error("invalid Request")
-		errs := err.(validator.ValidationErrors)
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": errs.Translate(s.validator.Trans)})
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
 		return
 	}
 
 	// log
-	logCtx := log.WithFields(log.Fields{
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
 		"api": "PostCreateCart",
 	})
 
 	username := c.GetString("username")
-	accountRepo := repository.NewAccountRepository(s.db)
-	account, result := accountRepo.OneByEmail(username)
-	if result.RowsAffected == 0 || result.Error != nil {
-		err := errors.New("error find account")
-		if result.Error != nil {
-			err = result.Error
-		}
-		logCtx.WithField("reason", err).Error("error find account")
-		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "account not found"})
-		return
-	}
-
-	itemRepo := repository.NewItemRepository(s.db)
-	item, result := itemRepo.OneById(req.ItemId)
-	if result.RowsAffected == 0 || result.Error != nil {
-		err := errors.New("error find item")
-		if result.Error != nil {
-			err = result.Error
-		}
-		logCtx.WithField("reason", err).Error("error find item")
-		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "item not found"})
-		return
-	}
-
-	if req.Qty > *item.Qty {
-		logCtx.WithField("reason", "request qty > item qty").Error("error add qty")
-		c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"error": "qty > item qty"})
+	owner, ok := s.cartOwner(c, logCtx)
+	if !ok {
 		return
 	}
 
-	cartRepo := repository.NewCartRepository(s.db)
-	cart, result := cartRepo.Create(model.Cart{
-		AccountID: account.ID,
-		ItemID:    item.ID,
-		Qty:       req.Qty,
-		CreatedBy: username,
-	})
-	if result.Error != nil {
-		logCtx.WithField("reason", result.Error).Error("error create cart")
-		c.AbortWithStatusJSON(http.StatusInternalServerError, nil)
+	var cart model.Cart
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var err error
+		cart, err = addItemToCart(tx, owner, req.ItemId, req.Qty, username)
+		return err
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error create cart")
+		apperror.Abort(c, err)
 		return
 	}
 
@@ -209,10 +232,11 @@ func (s *CartController) PostCreateCartFromItem(c *gin.Context) {
 
 // EditQtyCart	goDocs
 // @Summary      update qty of item in cart
-// @Description  update qty of item in cart, delete it if qty 0, failed when qty item < qty, need credential
+// @Description  update qty of item in cart, delete it if qty 0, failed when qty item < qty or the item is deleted, need credential
 // @Tags         Cart
 // @Param				 id path int true "edit by id"
-// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param				 Authorization	header		string	false	"Bearer {token}" default(Bearer {token})
+// @Param				 X-Guest-Token	header		string	false	"guest token from /cart/guest when not logged in"
 // @Param        tags body PutEditCartRequest true "Body Request"
 // @Produce      application/json
 // @Router       /cart/{id} [put]
@@ -220,33 +244,24 @@ func (s *CartController) PutEditCart(c *gin.Context) {
 	// bind data
 	var req PutEditCartRequest
 	if err := c.ShouldBind(&req); err != nil {
-		log.WithField("reason", err).Error("error Binding")
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
 		return
 	}
 	// validate
 	if err := s.validator.Validate.Struct(&req); err != nil {
-		log.WithField("reason", err).Error("invalid Request")
-		errs := err.(validator.ValidationErrors)
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": errs.Translate(s.validator.Trans)})
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
 		return
 	}
 
 	// log
-	logCtx := log.WithFields(log.Fields{
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
 		"api": "PutEditCart",
 	})
 
-	username := c.GetString("username")
-	accountRepo := repository.NewAccountRepository(s.db)
-	account, result := accountRepo.OneByEmail(username)
-	if result.RowsAffected == 0 || result.Error != nil {
-		err := errors.New("error find account")
-		if result.Error != nil {
-			err = result.Error
-		}
-		logCtx.WithField("reason", err).Error("error find account")
-		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "account not found"})
+	owner, ok := s.cartOwner(c, logCtx)
+	if !ok {
 		return
 	}
 
@@ -254,25 +269,19 @@ func (s *CartController) PutEditCart(c *gin.Context) {
 	id, err := strconv.Atoi(idS)
 	if err != nil {
 		logCtx.WithField("reason", err).Error("error parse id")
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid id"})
+		apperror.Abort(c, errInvalidId)
 		return
 	}
 
-	cartRepo := repository.NewCartRepository(s.db)
-	cart, result := cartRepo.OneByIdAndAccountId(id, int(account.ID), "Item")
+	cartRepo := repository.NewCartRepository(s.db.WithContext(c))
+	cart, result := cartRepo.OneByIdAndOwner(id, owner.accountId, owner.guestId, "Item")
 	if result.RowsAffected == 0 || result.Error != nil {
 		err := errors.New("error find cart")
 		if result.Error != nil {
 			err = result.Error
 		}
 		logCtx.WithField("reason", err).Error("error find cart")
-		c.AbortWithStatusJSON(http.StatusNotFound, nil)
-		return
-	}
-
-	if *req.Qty > *cart.Item.Qty {
-		logCtx.WithField("reason", "request qty > item qty").Error("error add qty")
-		c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"error": "qty > item qty"})
+		apperror.Abort(c, errCartNotFound)
 		return
 	}
 
@@ -282,13 +291,24 @@ func (s *CartController) PutEditCart(c *gin.Context) {
 		return
 	}
 
+	stock, err := cartLineStock(cart)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error add qty")
+		apperror.Abort(c, err)
+		return
+	}
+	if *req.Qty > stock {
+		logCtx.WithField("reason", "request qty > item qty").Error("error add qty")
+		apperror.Abort(c, errQtyExceedsStock)
+		return
+	}
+
 	cart, result = cartRepo.Update(id, model.Cart{
-		Qty:       *req.Qty,
-		UpdatedBy: username,
+		Qty: *req.Qty,
 	})
 	if result.Error != nil {
 		logCtx.WithField("reason", result.Error).Error("error update cart")
-		c.AbortWithStatusJSON(http.StatusInternalServerError, nil)
+		apperror.Abort(c, apperror.ErrInternal)
 		return
 	}
 
@@ -298,17 +318,230 @@ func (s *CartController) PutEditCart(c *gin.Context) {
 	})
 }
 
+// EditQtyCarts	goDocs
+// @Summary      update qty of many items in cart
+// @Description  update qty of many items in own cart at once, delete line if qty 0, a line of a deleted item can only be deleted, nothing is changed when any line fails, need credential
+// @Tags         Cart
+// @Param				 Authorization	header		string	false	"Bearer {token}" default(Bearer {token})
+// @Param				 X-Guest-Token	header		string	false	"guest token from /cart/guest when not logged in"
+// @Param        tags body PutEditCartsRequest true "Body Request"
+// @Produce      application/json
+// @Router       /cart [put]
+func (s *CartController) PutEditCarts(c *gin.Context) {
+	// bind data
+	var req PutEditCartsRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PutEditCarts",
+	})
+
+	owner, ok := s.cartOwner(c, logCtx)
+	if !ok {
+		return
+	}
+
+	var carts []model.Cart
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		cartRepo := repository.NewCartRepository(tx)
+		for _, v := range req.Carts {
+			cart, result := cartRepo.OneByIdAndOwner(v.ID, owner.accountId, owner.guestId, "Item")
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("cart id %d: %w", v.ID, errCartNotFound)
+			}
+
+			if *v.Qty == 0 {
+				if result := cartRepo.Delete(int(cart.ID), true); result.Error != nil {
+					return result.Error
+				}
+				continue
+			}
+
+			stock, err := cartLineStock(cart)
+			if err != nil {
+				return fmt.Errorf("cart id %d: %w", v.ID, err)
+			}
+			if *v.Qty > stock {
+				return fmt.Errorf("cart id %d: %w", v.ID, errQtyExceedsStock)
+			}
+
+			if _, result := cartRepo.Update(int(cart.ID), model.Cart{
+				Qty: *v.Qty,
+			}); result.Error != nil {
+				return result.Error
+			}
+		}
+
+		var result *gorm.DB
+		carts, result = cartRepo.AllByOwner(owner.accountId, owner.guestId, "Item")
+		return result.Error
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error update cart")
+		apperror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    newCartSectionsResponse(carts),
+	})
+}
+
+// AcknowledgeCarts	goDocs
+// @Summary      acknowledge current price of own cart
+// @Description  accept the current item price of every own cart line, needed before checkout when a price went up, need credential
+// @Tags         Cart
+// @Param				 Authorization	header		string	false	"Bearer {token}" default(Bearer {token})
+// @Param				 X-Guest-Token	header		string	false	"guest token from /cart/guest when not logged in"
+// @Produce      application/json
+// @Router       /cart/acknowledge [post]
+func (s *CartController) PostAcknowledgeCarts(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostAcknowledgeCarts",
+	})
+
+	owner, ok := s.cartOwner(c, logCtx)
+	if !ok {
+		return
+	}
+
+	var carts []model.Cart
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		cartRepo := repository.NewCartRepository(tx)
+		cart, result := cartRepo.AllByOwner(owner.accountId, owner.guestId, "Item")
+		if result.Error != nil {
+			return result.Error
+		}
+
+		for _, v := range cart {
+			if v.Item == nil || v.Item.Price == v.Price {
+				continue
+			}
+			if _, result := cartRepo.Update(int(v.ID), model.Cart{
+				Price: v.Item.Price,
+			}); result.Error != nil {
+				return result.Error
+			}
+		}
+
+		carts, result = cartRepo.AllByOwner(owner.accountId, owner.guestId, "Item")
+		return result.Error
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error acknowledge cart")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    newCartSectionsResponse(carts),
+	})
+}
+
+// SaveCartForLater	goDocs
+// @Summary      move cart line to saved for later
+// @Description  park own cart line in the saved for later section, it is left out of checkout, need credential
+// @Tags         Cart
+// @Param				 id path int true "save by id"
+// @Param				 Authorization	header		string	false	"Bearer {token}" default(Bearer {token})
+// @Param				 X-Guest-Token	header		string	false	"guest token from /cart/guest when not logged in"
+// @Produce      application/json
+// @Router       /cart/{id}/saved [put]
+func (s *CartController) PutSaveCartForLater(c *gin.Context) {
+	s.moveCart(c, "PutSaveCartForLater", true)
+}
+
+// MoveCartToActive	goDocs
+// @Summary      move saved for later line back to cart
+// @Description  move own saved for later line back to the active cart section, need credential
+// @Tags         Cart
+// @Param				 id path int true "move by id"
+// @Param				 Authorization	header		string	false	"Bearer {token}" default(Bearer {token})
+// @Param				 X-Guest-Token	header		string	false	"guest token from /cart/guest when not logged in"
+// @Produce      application/json
+// @Router       /cart/{id}/active [put]
+func (s *CartController) PutMoveCartToActive(c *gin.Context) {
+	s.moveCart(c, "PutMoveCartToActive", false)
+}
+
+// moveCart moves an own cart line between the active and the saved for later section
+func (s *CartController) moveCart(c *gin.Context, api string, saved bool) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": api,
+	})
+
+	idS := c.Param("id")
+	id, err := strconv.Atoi(idS)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error parse id")
+		apperror.Abort(c, errInvalidId)
+		return
+	}
+
+	username := c.GetString("username")
+	owner, ok := s.cartOwner(c, logCtx)
+	if !ok {
+		return
+	}
+
+	cartRepo := repository.NewCartRepository(s.db.WithContext(c))
+	if _, result := cartRepo.OneByIdAndOwner(id, owner.accountId, owner.guestId); result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find cart")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find cart")
+		apperror.Abort(c, errCartNotFound)
+		return
+	}
+
+	if result := cartRepo.UpdateSavedForLater(id, saved, username); result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error update cart")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	cart, result := cartRepo.OneById(id, "Item")
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error find cart")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    newCartResponse(cart),
+	})
+}
+
 // deleteCart	goDocs
 // @Summary      delete item by own cart id
 // @Description  delete item by own cart cart, need credential
 // @Tags         Cart
 // @Param				 id path int true "delete by id"
-// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param				 Authorization	header		string	false	"Bearer {token}" default(Bearer {token})
+// @Param				 X-Guest-Token	header		string	false	"guest token from /cart/guest when not logged in"
 // @Produce      application/json
 // @Router       /cart/{id} [delete]
 func (s *CartController) DeleteCart(c *gin.Context) {
 	// log
-	logCtx := log.WithFields(log.Fields{
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
 		"api": "PutEditCart",
 	})
 
@@ -316,31 +549,285 @@ func (s *CartController) DeleteCart(c *gin.Context) {
 	id, err := strconv.Atoi(idS)
 	if err != nil {
 		logCtx.WithField("reason", err).Error("error parse id")
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid id"})
+		apperror.Abort(c, errInvalidId)
 		return
 	}
 
-	username := c.GetString("username")
+	owner, ok := s.cartOwner(c, logCtx)
+	if !ok {
+		return
+	}
 
-	if err := s.db.Transaction(func(tx *gorm.DB) error {
-		cartRepo := repository.NewCartRepository(tx)
-		_, result := cartRepo.Update(id, model.Cart{
-			DeletedBy: &username,
+	cartRepo := repository.NewCartRepository(s.db.WithContext(c))
+	if _, result := cartRepo.OneByIdAndOwner(id, owner.accountId, owner.guestId); result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find cart")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find cart")
+		apperror.Abort(c, errCartNotFound)
+		return
+	}
+
+	if result := cartRepo.Delete(id, true); result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error delete cart")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+	})
+}
+
+// addItemToCart validates the item and requested qty against stock before putting it in the owner cart,
+// an existing line gets the qty added and is moved back from saved for later keeping its price snapshot,
+// only acknowledging the price change snapshots it again. A soft deleted line is restored with the
+// requested qty and the current item price.
+// db must be a transaction, the line stays locked until it ends so concurrent adds of the item sum up
+func addItemToCart(db *gorm.DB, owner cartOwner, itemId int, qty int, username string) (model.Cart, error) {
+	itemRepo := repository.NewItemRepository(db)
+	item, result := itemRepo.OneById(itemId)
+	if result.Error != nil {
+		return model.Cart{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return model.Cart{}, errItemNotFound
+	}
+
+	cartRepo := repository.NewCartRepository(db)
+	cart, result := lockCartLine(cartRepo, owner, int(item.ID))
+	if result.Error != nil {
+		return model.Cart{}, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		if qty > *item.Qty {
+			return model.Cart{}, errQtyExceedsStock
+		}
+
+		created := model.Cart{
+			ItemID: item.ID,
+			Qty:    qty,
+			Price:  item.Price,
+		}
+		owner.assign(&created)
+		created, result = cartRepo.Create(created)
+		if result.Error == nil {
+			return created, nil
+		}
+		if !repository.IsDuplicateKey(result.Error) {
+			return model.Cart{}, result.Error
+		}
+
+		// a concurrent add of the item inserted the line first, add to it instead
+		cart, result = cartRepo.OneUnscopedByOwnerAndItemIdForUpdate(owner.accountId, owner.guestId, int(item.ID))
+		if result.Error != nil {
+			return model.Cart{}, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return model.Cart{}, errCartNotFound
+		}
+	}
+
+	deleted := cart.DeletedAt != nil && cart.DeletedAt.Valid
+	if !deleted {
+		qty += cart.Qty
+	}
+	if qty > *item.Qty {
+		return model.Cart{}, errQtyExceedsStock
+	}
+
+	if deleted || cart.SavedForLater {
+		// a removed line is added anew, a saved one keeps the price it was put in the cart at
+		price := cart.Price
+		if deleted {
+			price = item.Price
+		}
+		if result := cartRepo.Restore(int(cart.ID), qty, price, username); result.Error != nil {
+			return model.Cart{}, result.Error
+		}
+		cart, result = cartRepo.OneById(int(cart.ID))
+	} else {
+		cart, result = cartRepo.Update(int(cart.ID), model.Cart{
+			Qty: qty,
 		})
+	}
+	if result.Error != nil {
+		return model.Cart{}, result.Error
+	}
+
+	return cart, nil
+}
+
+// lockCartLine returns the owner line of the item locked until the transaction ends, missing lines are
+// not locked, inserting them fails on the unique index when a concurrent request inserted them first
+func lockCartLine(cartRepo *repository.CartRepository, owner cartOwner, itemId int) (model.Cart, *gorm.DB) {
+	cart, result := cartRepo.OneUnscopedByOwnerAndItemId(owner.accountId, owner.guestId, itemId)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return cart, result
+	}
+	return cartRepo.OneUnscopedByOwnerAndItemIdForUpdate(owner.accountId, owner.guestId, itemId)
+}
+
+// mergeGuestCart moves the guest cart lines into the account cart, when both have the item
+// the qty is summed and capped at the item stock, lines left with no stock are dropped
+func mergeGuestCart(db *gorm.DB, account model.Account, guestId string, username string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		cartRepo := repository.NewCartRepository(tx)
+		guestCarts, result := cartRepo.AllByOwner(0, guestId, "Item")
 		if result.Error != nil {
 			return result.Error
 		}
-		if result := cartRepo.Delete(id, true); result.Error != nil {
-			return result.Error
+
+		for _, v := range guestCarts {
+			stock := 0
+			if v.Item != nil && v.Item.Qty != nil {
+				stock = *v.Item.Qty
+			}
+
+			cart, result := lockCartLine(cartRepo, cartOwner{accountId: int(account.ID)}, int(v.ItemID))
+			if result.Error != nil {
+				return result.Error
+			}
+			deleted := cart.DeletedAt != nil && cart.DeletedAt.Valid
+
+			qty := v.Qty
+			if result.RowsAffected > 0 && !deleted {
+				qty += cart.Qty
+			}
+			if qty > stock {
+				qty = stock
+			}
+
+			switch {
+			case qty <= 0:
+				// nothing left to buy, keep the account line as it is
+			case result.RowsAffected == 0:
+				if result := cartRepo.MoveToAccount(int(v.ID), int(account.ID), qty, username); result.Error != nil {
+					return result.Error
+				}
+				continue
+			case deleted:
+				if result := cartRepo.Restore(int(cart.ID), qty, v.Price, username); result.Error != nil {
+					return result.Error
+				}
+			default:
+				if _, result := cartRepo.Update(int(cart.ID), model.Cart{
+					Qty: qty,
+				}); result.Error != nil {
+					return result.Error
+				}
+			}
+
+			if result := cartRepo.Delete(int(v.ID), true); result.Error != nil {
+				return result.Error
+			}
 		}
 		return nil
-	}); err != nil {
-		logCtx.WithField("reason", err).Error("error delete cart")
-		c.AbortWithStatusJSON(http.StatusInternalServerError, nil)
+	})
+}
+
+// cartOwner identifies the account or the guest a cart belongs to
+type cartOwner struct {
+	accountId int
+	guestId   string
+}
+
+func (o cartOwner) assign(cart *model.Cart) {
+	if o.accountId != 0 {
+		accountId := uint(o.accountId)
+		cart.AccountID = &accountId
 		return
 	}
+	cart.GuestID = &o.guestId
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Sucess!",
-	})
+// cartOwner resolves the logged in account or the guest set by the AuthOrGuest middleware
+func (s *CartController) cartOwner(c *gin.Context, logCtx *log.Entry) (cartOwner, bool) {
+	username := c.GetString("username")
+	if username == "" {
+		return cartOwner{guestId: c.GetString("guest_id")}, true
+	}
+
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return cartOwner{}, false
+	}
+	return cartOwner{accountId: int(account.ID)}, true
+}
+
+// cartLineStock returns the stock of the item of a cart line, preloading skips deleted items so a line whose
+// item was deleted has none and can only be removed
+func cartLineStock(cart model.Cart) (int, error) {
+	if cart.Item == nil {
+		return 0, errCartItemDeleted
+	}
+	if cart.Item.Qty == nil {
+		return 0, nil
+	}
+	return *cart.Item.Qty, nil
+}
+
+// newCartResponse attaches the warnings of a cart line, the item must be preloaded
+// and is nil when it has been deleted since the line was added
+func newCartResponse(cart model.Cart) CartResponse {
+	warnings := []CartWarning{}
+	switch {
+	case cart.Item == nil:
+		warnings = append(warnings, CartWarning{
+			Type:    "ITEM_DELETED",
+			Message: "item deleted",
+		})
+	default:
+		if cart.Price > 0 && cart.Item.Price != cart.Price {
+			warnings = append(warnings, CartWarning{
+				Type:    "PRICE_CHANGED",
+				Message: fmt.Sprintf("price changed from %.2f to %.2f", cart.Price, cart.Item.Price),
+			})
+		}
+		stock := 0
+		if cart.Item.Qty != nil {
+			stock = *cart.Item.Qty
+		}
+		if stock <= 0 {
+			warnings = append(warnings, CartWarning{
+				Type:    "OUT_OF_STOCK",
+				Message: "out of stock",
+			})
+		} else if stock < cart.Qty {
+			warnings = append(warnings, CartWarning{
+				Type:    "LOW_STOCK",
+				Message: fmt.Sprintf("only %d left", stock),
+			})
+		}
+	}
+
+	return CartResponse{
+		Cart:     cart,
+		Warnings: warnings,
+	}
+}
+
+// newCartSectionsResponse splits the cart lines into the active and the saved for later section
+func newCartSectionsResponse(carts []model.Cart) CartSectionsResponse {
+	res := CartSectionsResponse{
+		Active: []CartResponse{},
+		Saved:  []CartResponse{},
+	}
+	for _, v := range carts {
+		if v.SavedForLater {
+			res.Saved = append(res.Saved, newCartResponse(v))
+			continue
+		}
+		res.Active = append(res.Active, newCartResponse(v))
+	}
+	return res
 }
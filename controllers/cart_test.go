@@ -0,0 +1,633 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	gormMysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func CartNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(gormMysql.New(gormMysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func expectItem(mock sqlmock.Sqlmock, id int, stock int, price float64) {
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE id = ?")).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "qty", "price"}).AddRow(id, stock, price))
+}
+
+func Test_addItemToCart(t *testing.T) {
+	owner := cartOwner{accountId: 1}
+	lineColumns := []string{"id", "account_id", "item_id", "qty", "price", "saved_for_later", "deleted_at"}
+
+	tests := []struct {
+		name      string
+		qty       int
+		mock      func(mock sqlmock.Sqlmock)
+		wantQty   int
+		wantPrice float64
+		wantErr   error
+	}{
+		{
+			name: "New line",
+			qty:  2,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectItem(mock, 2, 10, 5)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE item_id = ? AND account_id = ?")).
+					WillReturnRows(sqlmock.NewRows(lineColumns))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `carts`")).WillReturnResult(sqlmock.NewResult(7, 1))
+			},
+			wantQty:   2,
+			wantPrice: 5,
+		},
+		{
+			// adding again must not acknowledge a price increase of the item
+			name: "Add to existing line keeps price snapshot",
+			qty:  2,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectItem(mock, 2, 10, 8)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE item_id = ? AND account_id = ?")).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(7, 1, 2, 3, 5, false, nil))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE item_id = ? AND account_id = ? FOR UPDATE")).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(7, 1, 2, 3, 5, false, nil))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ?")).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(7, 1, 2, 3, 5, false, nil))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `carts` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantQty:   5,
+			wantPrice: 5,
+		},
+		{
+			name: "Move saved line back keeps price snapshot",
+			qty:  2,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectItem(mock, 2, 10, 8)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE item_id = ? AND account_id = ?")).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(7, 1, 2, 3, 5, true, nil))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE item_id = ? AND account_id = ? FOR UPDATE")).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(7, 1, 2, 3, 5, true, nil))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `carts` SET `deleted_at`=?,`deleted_by`=?,`price`=?,`qty`=?,`saved_for_later`=?")).
+					WithArgs(nil, nil, 5.0, 5, false, "email@mail.com", sqlmock.AnyArg(), 7).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ?")).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(7, 1, 2, 5, 5, false, nil))
+			},
+			wantQty:   5,
+			wantPrice: 5,
+		},
+		{
+			// both requests missed the line, the second insert hits the unique index
+			name: "Add to line inserted concurrently",
+			qty:  2,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectItem(mock, 2, 10, 5)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE item_id = ? AND account_id = ?")).
+					WillReturnRows(sqlmock.NewRows(lineColumns))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `carts`")).
+					WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1-2' for key 'idx_account_item_key'"})
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE item_id = ? AND account_id = ? FOR UPDATE")).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(7, 1, 2, 1, 5, false, nil))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ?")).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(7, 1, 2, 1, 5, false, nil))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `carts` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			wantQty:   3,
+			wantPrice: 5,
+		},
+		{
+			name: "Restore deleted line with requested qty",
+			qty:  2,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectItem(mock, 2, 10, 6)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE item_id = ? AND account_id = ?")).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(7, 1, 2, 4, 5, false, time.Now()))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE item_id = ? AND account_id = ? FOR UPDATE")).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(7, 1, 2, 4, 5, false, time.Now()))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `carts` SET `deleted_at`=?,`deleted_by`=?,`price`=?,`qty`=?,`saved_for_later`=?")).
+					WithArgs(nil, nil, 6.0, 2, false, "email@mail.com", sqlmock.AnyArg(), 7).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ?")).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(7, 1, 2, 2, 6, false, nil))
+			},
+			wantQty:   2,
+			wantPrice: 6,
+		},
+		{
+			name: "Qty exceeds stock with existing line",
+			qty:  8,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectItem(mock, 2, 10, 5)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE item_id = ? AND account_id = ?")).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(7, 1, 2, 3, 5, false, nil))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE item_id = ? AND account_id = ? FOR UPDATE")).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(7, 1, 2, 3, 5, false, nil))
+			},
+			wantErr: errQtyExceedsStock,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := CartNewMockDB()
+			mock.ExpectBegin()
+			tt.mock(mock)
+			if tt.wantErr != nil {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectCommit()
+			}
+
+			var cart model.Cart
+			err := db.Transaction(func(tx *gorm.DB) error {
+				var err error
+				cart, err = addItemToCart(tx, owner, 2, tt.qty, "email@mail.com")
+				return err
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantQty, cart.Qty)
+				assert.Equal(t, tt.wantPrice, cart.Price)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_mergeGuestCart(t *testing.T) {
+	account := model.Account{ID: 1, Email: "email@mail.com"}
+	lineColumns := []string{"id", "account_id", "guest_id", "item_id", "qty", "price", "deleted_at"}
+
+	db, mock := CartNewMockDB()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE guest_id = ?")).
+		WithArgs("guest").
+		WillReturnRows(sqlmock.NewRows(lineColumns).
+			AddRow(10, nil, "guest", 2, 3, 5, nil).
+			AddRow(11, nil, "guest", 3, 1, 8, nil))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE `items`.`id` IN (?,?)")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}).AddRow(2, 5).AddRow(3, 4))
+
+	// item 2 is in both carts, the summed qty is capped at the stock and the guest line dropped
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE item_id = ? AND account_id = ?")).
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(20, 1, nil, 2, 4, 5, nil))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE item_id = ? AND account_id = ? FOR UPDATE")).
+		WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(20, 1, nil, 2, 4, 5, nil))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ?")).
+		WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(20, 1, nil, 2, 4, 5, nil))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `carts` SET")).
+		WithArgs(1, nil, 2, 5, 5.0, false, "", "", nil, nil, sqlmock.AnyArg(), nil, 20).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `carts` WHERE `carts`.`id` = ?")).
+		WithArgs(10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// item 3 is only in the guest cart, the guest line moves to the account
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE item_id = ? AND account_id = ?")).
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows(lineColumns))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `carts` SET `account_id`=?,`guest_id`=?,`qty`=?")).
+		WithArgs(1, nil, 1, "email@mail.com", sqlmock.AnyArg(), 11).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	assert.NoError(t, mergeGuestCart(db, account, "guest", "email@mail.com"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_CartPutEditCarts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	lineColumns := []string{"id", "guest_id", "item_id", "qty", "price"}
+
+	tests := []struct {
+		name       string
+		body       string
+		mock       func(mock sqlmock.Sqlmock)
+		wantStatus int
+	}{
+		{
+			name: "Update and delete lines",
+			body: `{"carts":[{"id":1,"qty":4},{"id":2,"qty":0}]}`,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ? AND guest_id = ?")).
+					WithArgs(1, "guest").
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(1, "guest", 2, 1, 5))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE `items`.`id` = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}).AddRow(2, 10))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ?")).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(1, "guest", 2, 1, 5))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `carts` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ? AND guest_id = ?")).
+					WithArgs(2, "guest").
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(2, "guest", 3, 1, 8))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE `items`.`id` = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}).AddRow(3, 10))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `carts` WHERE `carts`.`id` = ?")).
+					WithArgs(2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE guest_id = ?")).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(1, "guest", 2, 4, 5))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE `items`.`id` = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "qty", "price"}).AddRow(2, 10, 5))
+				mock.ExpectCommit()
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			// preloading skips the deleted item, the line can be removed but not changed
+			name: "Item deleted",
+			body: `{"carts":[{"id":2,"qty":3}]}`,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ? AND guest_id = ?")).
+					WithArgs(2, "guest").
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(2, "guest", 3, 1, 8))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE `items`.`id` = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}))
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name: "Remove line of deleted item",
+			body: `{"carts":[{"id":2,"qty":0}]}`,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ? AND guest_id = ?")).
+					WithArgs(2, "guest").
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(2, "guest", 3, 1, 8))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE `items`.`id` = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `carts` WHERE `carts`.`id` = ?")).
+					WithArgs(2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE guest_id = ?")).
+					WillReturnRows(sqlmock.NewRows(lineColumns))
+				mock.ExpectCommit()
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			// a line over the stock rolls back the lines updated before it
+			name: "Qty exceeds stock",
+			body: `{"carts":[{"id":1,"qty":4},{"id":2,"qty":20}]}`,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ? AND guest_id = ?")).
+					WithArgs(1, "guest").
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(1, "guest", 2, 1, 5))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE `items`.`id` = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}).AddRow(2, 10))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ?")).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(1, "guest", 2, 1, 5))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `carts` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ? AND guest_id = ?")).
+					WithArgs(2, "guest").
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(2, "guest", 3, 1, 8))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE `items`.`id` = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}).AddRow(3, 10))
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := CartNewMockDB()
+			tt.mock(mock)
+
+			s := NewCartController(db, util.ValidatorTranslate(), "secret", time.Hour)
+			router := gin.New()
+			router.PUT("/cart", func(c *gin.Context) {
+				c.Set("guest_id", "guest")
+				s.PutEditCarts(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/cart", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_CartPutEditCart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	lineColumns := []string{"id", "guest_id", "item_id", "qty", "price"}
+
+	tests := []struct {
+		name       string
+		body       string
+		mock       func(mock sqlmock.Sqlmock)
+		wantStatus int
+	}{
+		{
+			name: "Item deleted",
+			body: `{"qty":3}`,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ? AND guest_id = ?")).
+					WithArgs(2, "guest").
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(2, "guest", 3, 1, 8))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE `items`.`id` = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}))
+			},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name: "Remove line of deleted item",
+			body: `{"qty":0}`,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ? AND guest_id = ?")).
+					WithArgs(2, "guest").
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(2, "guest", 3, 1, 8))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE `items`.`id` = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "qty"}))
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `carts` WHERE `carts`.`id` = ?")).
+					WithArgs(2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := CartNewMockDB()
+			tt.mock(mock)
+
+			s := NewCartController(db, util.ValidatorTranslate(), "secret", time.Hour)
+			router := gin.New()
+			router.PUT("/cart/:id", func(c *gin.Context) {
+				c.Set("guest_id", "guest")
+				s.PutEditCart(c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, "/cart/2", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_newCartResponse(t *testing.T) {
+	qty := func(v int) *int { return &v }
+
+	tests := []struct {
+		name         string
+		cart         model.Cart
+		wantWarnings []string
+	}{
+		{
+			name: "Unchanged line",
+			cart: model.Cart{Qty: 2, Price: 10, Item: &model.Item{Price: 10, Qty: qty(5)}},
+		},
+		{
+			name:         "Price changed since added",
+			cart:         model.Cart{Qty: 2, Price: 10, Item: &model.Item{Price: 8, Qty: qty(5)}},
+			wantWarnings: []string{"PRICE_CHANGED"},
+		},
+		{
+			// lines added before prices were snapshotted have no price to compare
+			name: "Line without price snapshot",
+			cart: model.Cart{Qty: 2, Item: &model.Item{Price: 8, Qty: qty(5)}},
+		},
+		{
+			name:         "Less stock than qty",
+			cart:         model.Cart{Qty: 4, Price: 10, Item: &model.Item{Price: 10, Qty: qty(3)}},
+			wantWarnings: []string{"LOW_STOCK"},
+		},
+		{
+			name:         "Out of stock and dearer",
+			cart:         model.Cart{Qty: 1, Price: 10, Item: &model.Item{Price: 12, Qty: qty(0)}},
+			wantWarnings: []string{"PRICE_CHANGED", "OUT_OF_STOCK"},
+		},
+		{
+			name:         "Item deleted",
+			cart:         model.Cart{Qty: 1, Price: 10},
+			wantWarnings: []string{"ITEM_DELETED"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			for _, v := range newCartResponse(tt.cart).Warnings {
+				got = append(got, v.Type)
+			}
+			assert.Equal(t, tt.wantWarnings, got)
+		})
+	}
+}
+
+func Test_CartPostAcknowledgeCarts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	lineColumns := []string{"id", "guest_id", "item_id", "qty", "price"}
+	db, mock := CartNewMockDB()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE guest_id = ?")).
+		WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(1, "guest", 2, 1, 10).AddRow(2, "guest", 3, 1, 5))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE `items`.`id` IN (?,?)")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "qty", "price"}).AddRow(2, 10, 12).AddRow(3, 10, 5))
+	// only the line whose price changed takes the current price
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ?")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(1, "guest", 2, 1, 10))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `carts` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE guest_id = ?")).
+		WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(1, "guest", 2, 1, 12).AddRow(2, "guest", 3, 1, 5))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE `items`.`id` IN (?,?)")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "qty", "price"}).AddRow(2, 10, 12).AddRow(3, 10, 5))
+	mock.ExpectCommit()
+
+	s := NewCartController(db, util.ValidatorTranslate(), "secret", time.Hour)
+	router := gin.New()
+	router.POST("/cart/acknowledge", func(c *gin.Context) {
+		c.Set("guest_id", "guest")
+		s.PostAcknowledgeCarts(c)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/cart/acknowledge", nil)
+	router.ServeHTTP(w, req)
+
+	var body struct {
+		Data CartSectionsResponse `json:"data"`
+	}
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Len(t, body.Data.Active, 2)
+	for _, v := range body.Data.Active {
+		assert.Empty(t, v.Warnings)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_newCartSectionsResponse(t *testing.T) {
+	t.Parallel()
+
+	got := newCartSectionsResponse([]model.Cart{
+		{ID: 1, Qty: 1},
+		{ID: 2, Qty: 1, SavedForLater: true},
+		{ID: 3, Qty: 1},
+	})
+
+	var active, saved []uint
+	for _, v := range got.Active {
+		active = append(active, v.ID)
+	}
+	for _, v := range got.Saved {
+		saved = append(saved, v.ID)
+	}
+	assert.Equal(t, []uint{1, 3}, active)
+	assert.Equal(t, []uint{2}, saved)
+}
+
+func Test_CartMoveCart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	lineColumns := []string{"id", "guest_id", "item_id", "qty", "price", "saved_for_later"}
+
+	tests := []struct {
+		name       string
+		path       string
+		mock       func(mock sqlmock.Sqlmock)
+		wantStatus int
+		wantSaved  bool
+	}{
+		{
+			name: "Save own line for later",
+			path: "/cart/3/saved",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ? AND guest_id = ?")).
+					WithArgs(3, "guest").
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(3, "guest", 2, 1, 10, false))
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `carts` SET `saved_for_later`=?")).
+					WithArgs(true, "", sqlmock.AnyArg(), 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ?")).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(3, "guest", 2, 1, 10, true))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE `items`.`id` = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "qty", "price"}).AddRow(2, 10, 10))
+			},
+			wantStatus: http.StatusOK,
+			wantSaved:  true,
+		},
+		{
+			name: "Move line back to cart",
+			path: "/cart/3/active",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ? AND guest_id = ?")).
+					WithArgs(3, "guest").
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(3, "guest", 2, 1, 10, true))
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `carts` SET `saved_for_later`=?")).
+					WithArgs(false, "", sqlmock.AnyArg(), 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ?")).
+					WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(3, "guest", 2, 1, 10, false))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE `items`.`id` = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "qty", "price"}).AddRow(2, 10, 10))
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "Line of another cart",
+			path: "/cart/3/saved",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ? AND guest_id = ?")).
+					WithArgs(3, "guest").
+					WillReturnRows(sqlmock.NewRows(lineColumns))
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := CartNewMockDB()
+			tt.mock(mock)
+
+			s := NewCartController(db, util.ValidatorTranslate(), "secret", time.Hour)
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("guest_id", "guest")
+			})
+			router.PUT("/cart/:id/saved", s.PutSaveCartForLater)
+			router.PUT("/cart/:id/active", s.PutMoveCartToActive)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, tt.path, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantStatus == http.StatusOK {
+				var body struct {
+					Data CartResponse `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tt.wantSaved, body.Data.SavedForLater)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
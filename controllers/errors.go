@@ -0,0 +1,9 @@
+package controllers
+
+import "github.com/avarian/online-shopping-cart/service/apperror"
+
+// errors answered by most controllers, the ones of a single controller live next to it
+var (
+	errInvalidId       = apperror.New(apperror.Invalid, "invalid_id", "invalid id")
+	errAccountNotFound = apperror.New(apperror.NotFound, "account_not_found", "account not found")
+)
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/logging"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type PostCreateItemSubscriptionRequest struct {
+	Type        string   `json:"type" validate:"required,oneof=BACK_IN_STOCK PRICE_DROP"`
+	TargetPrice *float64 `json:"target_price" validate:"omitempty,gt=0"`
+}
+
+var (
+	errItemInStock          = apperror.New(apperror.PreconditionFailed, "item_in_stock", "item is in stock")
+	errTargetPriceTooHigh   = apperror.New(apperror.PreconditionFailed, "target_price_too_high", "target price must be below item price")
+	errAlreadySubscribed    = apperror.New(apperror.Conflict, "already_subscribed", "already subscribed")
+	errSubscriptionNotFound = apperror.New(apperror.NotFound, "subscription_not_found", "subscription not found")
+)
+
+type ItemSubscriptionController struct {
+	db        *gorm.DB
+	validator *util.Validator
+}
+
+func NewItemSubscriptionController(db *gorm.DB, validator *util.Validator) *ItemSubscriptionController {
+	return &ItemSubscriptionController{
+		db:        db,
+		validator: validator,
+	}
+}
+
+// AddItemSubscription	goDocs
+// @Summary      subscribe to an item alert
+// @Description  subscribe to back in stock (BACK_IN_STOCK) or price drop (PRICE_DROP) alert of an item, target_price only notify when price goes at or below it, need credentials
+// @Tags         Item Subscription
+// @Param				 id path int true "item id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PostCreateItemSubscriptionRequest true "Body Request"
+// @Produce      application/json
+// @Router       /item/{id}/subscription [post]
+func (s *ItemSubscriptionController) PostCreateItemSubscription(c *gin.Context) {
+	// bind data
+	var req PostCreateItemSubscriptionRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostCreateItemSubscription",
+	})
+
+	idS := c.Param("id")
+	id, err := strconv.Atoi(idS)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error parse id")
+		apperror.Abort(c, errInvalidId)
+		return
+	}
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	itemRepo := repository.NewItemRepository(s.db.WithContext(c))
+	item, result := itemRepo.OneById(id)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find item")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find item")
+		apperror.Abort(c, errItemNotFound)
+		return
+	}
+
+	switch req.Type {
+	case "BACK_IN_STOCK":
+		if item.Qty != nil && *item.Qty > 0 {
+			logCtx.WithField("reason", "item in stock").Error("error create item subscription")
+			apperror.Abort(c, errItemInStock)
+			return
+		}
+		req.TargetPrice = nil
+	case "PRICE_DROP":
+		if req.TargetPrice != nil && *req.TargetPrice >= item.Price {
+			logCtx.WithField("reason", "target price >= item price").Error("error create item subscription")
+			apperror.Abort(c, errTargetPriceTooHigh)
+			return
+		}
+	}
+
+	itemSubscriptionRepo := repository.NewItemSubscriptionRepository(s.db.WithContext(c))
+	if _, result := itemSubscriptionRepo.OneActiveByAccountIdAndItemIdAndType(int(account.ID), int(item.ID), req.Type); result.RowsAffected > 0 {
+		logCtx.WithField("reason", "subscription exists").Error("error create item subscription")
+		apperror.Abort(c, errAlreadySubscribed)
+		return
+	}
+
+	itemSubscription, result := itemSubscriptionRepo.Create(model.ItemSubscription{
+		AccountID:   account.ID,
+		ItemID:      item.ID,
+		Type:        req.Type,
+		TargetPrice: req.TargetPrice,
+	})
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error create item subscription")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    itemSubscription,
+		"message": "Sucess!",
+	})
+}
+
+// GetAllItemSubscription	goDocs
+// @Summary      get all own active item subscriptions
+// @Description  get all own item subscriptions that are not notified yet, need credentials
+// @Tags         Item Subscription
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /subscription/all [get]
+func (s *ItemSubscriptionController) GetItemSubscriptions(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "GetItemSubscriptions",
+	})
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	itemSubscriptionRepo := repository.NewItemSubscriptionRepository(s.db.WithContext(c))
+	itemSubscription, result := itemSubscriptionRepo.AllByAccountId(int(account.ID), "Item")
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error find item subscription")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    itemSubscription,
+	})
+}
+
+// DeleteItemSubscription	goDocs
+// @Summary      unsubscribe own item subscription
+// @Description  unsubscribe own item subscription, need credentials
+// @Tags         Item Subscription
+// @Param				 id path int true "delete by id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /subscription/{id} [delete]
+func (s *ItemSubscriptionController) DeleteItemSubscription(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "DeleteItemSubscription",
+	})
+
+	idS := c.Param("id")
+	id, err := strconv.Atoi(idS)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error parse id")
+		apperror.Abort(c, errInvalidId)
+		return
+	}
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	itemSubscriptionRepo := repository.NewItemSubscriptionRepository(s.db.WithContext(c))
+	itemSubscription, result := itemSubscriptionRepo.OneByIdAndAccountId(id, int(account.ID))
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find item subscription")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find item subscription")
+		apperror.Abort(c, errSubscriptionNotFound)
+		return
+	}
+
+	if result := itemSubscriptionRepo.Delete(int(itemSubscription.ID), false); result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error delete item subscription")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+	})
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/avarian/online-shopping-cart/jobs"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/taylorchu/work"
+)
+
+// itemSubscriptionQueue keeps the types of the item subscription jobs dispatched to it
+type itemSubscriptionQueue struct {
+	work.RedisQueue
+	types []string
+}
+
+func (q *itemSubscriptionQueue) Enqueue(job *work.Job, opts *work.EnqueueOptions) error {
+	var payload jobs.ItemSubscriptionJob
+	if err := job.UnmarshalJSONPayload(&payload); err != nil {
+		return err
+	}
+	q.types = append(q.types, payload.Type)
+	return nil
+}
+
+// not parallel, the queue of jobs.Dispatch is shared by the package
+func Test_notifySubscribers(t *testing.T) {
+	qty := func(v int) *int { return &v }
+
+	tests := []struct {
+		name      string
+		before    model.Item
+		after     model.Item
+		wantTypes []string
+	}{
+		{
+			name:      "Restocked",
+			before:    model.Item{ID: 2, Price: 10, Qty: qty(0)},
+			after:     model.Item{ID: 2, Price: 10, Qty: qty(5)},
+			wantTypes: []string{"BACK_IN_STOCK"},
+		},
+		{
+			// only the transition from out of stock alerts, more stock on top does not
+			name:   "Stock added while in stock",
+			before: model.Item{ID: 2, Price: 10, Qty: qty(3)},
+			after:  model.Item{ID: 2, Price: 10, Qty: qty(8)},
+		},
+		{
+			name:      "Price dropped",
+			before:    model.Item{ID: 2, Price: 10, Qty: qty(3)},
+			after:     model.Item{ID: 2, Price: 7, Qty: qty(3)},
+			wantTypes: []string{"PRICE_DROP"},
+		},
+		{
+			name:   "Price raised",
+			before: model.Item{ID: 2, Price: 10, Qty: qty(3)},
+			after:  model.Item{ID: 2, Price: 12, Qty: qty(3)},
+		},
+		{
+			name:      "Restocked cheaper",
+			before:    model.Item{ID: 2, Price: 10, Qty: qty(0)},
+			after:     model.Item{ID: 2, Price: 7, Qty: qty(5)},
+			wantTypes: []string{"BACK_IN_STOCK", "PRICE_DROP"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := &itemSubscriptionQueue{}
+			jobs.SetRedisQueue(queue)
+			defer jobs.SetRedisQueue(nil)
+
+			notifySubscribers(log.WithField("test", t.Name()), tt.before, tt.after)
+			assert.Equal(t, tt.wantTypes, queue.types)
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/auth"
+	"github.com/avarian/online-shopping-cart/service/logging"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type PostOidcCallbackRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+type OidcAuthorizationResponse struct {
+	AuthorizationUrl string `json:"authorization_url"`
+	ExpiresIn        int64  `json:"expires_in"`
+}
+
+// OidcConfig is the configured providers by name and how long a started login may take
+type OidcConfig struct {
+	StateTTL  time.Duration
+	Providers map[string]*auth.OidcProvider
+}
+
+var (
+	errUnknownProvider     = apperror.New(apperror.NotFound, "unknown_provider", "unknown login provider")
+	errInvalidOidcState    = apperror.New(apperror.Unauthorized, "invalid_oidc_state", "invalid or expired login state")
+	errProviderUnavailable = apperror.New(apperror.BadGateway, "provider_unavailable", "provider unavailable")
+)
+
+type OidcController struct {
+	db        *gorm.DB
+	validator *util.Validator
+	jwtSecret string
+	tokens    *auth.TokenService
+	throttle  *auth.LoginThrottle
+	twoFactor TwoFactorConfig
+	config    OidcConfig
+}
+
+func NewOidcController(db *gorm.DB, validator *util.Validator, jwtSecret string, tokens *auth.TokenService, throttle *auth.LoginThrottle, twoFactor TwoFactorConfig, config OidcConfig) *OidcController {
+	return &OidcController{
+		db:        db,
+		validator: validator,
+		jwtSecret: jwtSecret,
+		tokens:    tokens,
+		throttle:  throttle,
+		twoFactor: twoFactor,
+		config:    config,
+	}
+}
+
+// OidcAuthorize	goDocs
+// @Summary      start login with a provider
+// @Description  returns the login page url of an OpenID Connect provider, the provider redirects back with code and state for /login/oidc/{provider}/callback
+// @Tags         Account
+// @Param				 provider path string true "provider name"
+// @Produce      application/json
+// @Router       /login/oidc/{provider} [get]
+func (s *OidcController) GetOidcAuthorize(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api":      "GetOidcAuthorize",
+		"provider": c.Param("provider"),
+	})
+
+	provider, ok := s.config.Providers[c.Param("provider")]
+	if !ok {
+		logCtx.WithField("reason", errUnknownProvider).Error("error find provider")
+		apperror.Abort(c, errUnknownProvider)
+		return
+	}
+
+	state, err := util.RandomToken(32)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error generate state")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+	nonce, err := util.RandomToken(16)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error generate nonce")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+	codeVerifier, err := auth.NewPkceVerifier()
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error generate code verifier")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	authorizationUrl, err := provider.AuthCodeUrl(c.Request.Context(), state, nonce, codeVerifier)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error build authorization url")
+		apperror.Abort(c, errProviderUnavailable)
+		return
+	}
+
+	oidcStateRepo := repository.NewOidcStateRepository(s.db.WithContext(c))
+	if _, result := oidcStateRepo.Create(model.OidcState{
+		Provider:     provider.Name(),
+		StateHash:    util.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiredAt:    time.Now().Add(s.config.StateTTL),
+	}); result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error create state")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data": OidcAuthorizationResponse{
+			AuthorizationUrl: authorizationUrl,
+			ExpiresIn:        int64(s.config.StateTTL.Seconds()),
+		},
+	})
+}
+
+// OidcCallback	goDocs
+// @Summary      finish login with a provider
+// @Description  exchange code and state the provider redirected back with for a JWT token and a refresh token like /login, the provider account is linked to the account with its email on first use when both the provider and the account verified it (409 while the email of the account is not verified), without an account with the email a CUSTOMER account without password is created, 202 with a challenge token when the account has two factor authentication
+// @Tags         Account
+// @Param				 provider path string true "provider name"
+// @Param				 X-Guest-Token	header		string	false	"guest token from /cart/guest"
+// @Produce      application/json
+// @Param        tags body PostOidcCallbackRequest true "Body Request"
+// @Router       /login/oidc/{provider}/callback [post]
+func (s *OidcController) PostOidcCallback(c *gin.Context) {
+	// bind data
+	var req PostOidcCallbackRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api":      "PostOidcCallback",
+		"provider": c.Param("provider"),
+	})
+
+	provider, ok := s.config.Providers[c.Param("provider")]
+	if !ok {
+		logCtx.WithField("reason", errUnknownProvider).Error("error find provider")
+		apperror.Abort(c, errUnknownProvider)
+		return
+	}
+
+	oidcStateRepo := repository.NewOidcStateRepository(s.db.WithContext(c))
+	oidcState, result := oidcStateRepo.OneByStateHash(util.HashToken(req.State))
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error find state")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+	if result.RowsAffected == 0 || oidcState.Provider != provider.Name() || oidcState.UsedAt != nil || time.Now().After(oidcState.ExpiredAt) {
+		logCtx.WithField("reason", errInvalidOidcState).Error("error find state")
+		apperror.Abort(c, errInvalidOidcState)
+		return
+	}
+	result = oidcStateRepo.MarkUsed(int(oidcState.ID))
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error use state")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+	if result.RowsAffected == 0 {
+		logCtx.WithField("reason", errInvalidOidcState).Error("error use state")
+		apperror.Abort(c, errInvalidOidcState)
+		return
+	}
+
+	identity, err := provider.Exchange(c.Request.Context(), req.Code, oidcState.CodeVerifier, oidcState.Nonce)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error exchange code")
+		apperror.Abort(c, apperror.As(err, errProviderUnavailable))
+		return
+	}
+
+	account, err := auth.LinkOidcAccount(s.db.WithContext(c), provider.Name(), identity)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error link account")
+		apperror.Abort(c, err)
+		return
+	}
+
+	completeLogin(c, logCtx.WithField("email", account.Email), s.db.WithContext(c), s.jwtSecret, s.tokens, s.throttle, s.twoFactor, account)
+}
@@ -2,33 +2,62 @@ package controllers
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/logging"
 	"github.com/avarian/online-shopping-cart/service/repository"
 	"github.com/avarian/online-shopping-cart/util"
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 type PostCreateOrderRequest struct {
 	VoucherCode string `json:"voucher_code"`
+	AddressID   int    `json:"address_id"`
 	Address     string `json:"address"`
 	PhoneNumber string `json:"phone_number"`
 }
 
+type ReorderLine struct {
+	ItemID   uint   `json:"item_id"`
+	Name     string `json:"name"`
+	Qty      int    `json:"qty"`
+	AddedQty int    `json:"added_qty"`
+	Reason   string `json:"reason"`
+}
+
+type ReorderResponse struct {
+	Carts   []CartResponse `json:"carts"`
+	Skipped []ReorderLine  `json:"skipped"`
+	Reduced []ReorderLine  `json:"reduced"`
+}
+
+var (
+	errPriceIncreased     = apperror.New(apperror.Conflict, "price_increased", "price increased, acknowledge cart first")
+	errVoucherNotFound    = apperror.New(apperror.NotFound, "voucher_not_found", "voucher not found")
+	errVoucherUnavailable = apperror.New(apperror.PreconditionFailed, "voucher_unavailable", "voucher expired or already used")
+	errOrderNotFound      = apperror.New(apperror.NotFound, "order_not_found", "order not found")
+	errCartEmpty          = apperror.New(apperror.PreconditionFailed, "cart_empty", "cart is empty")
+	errAccountNotVerified = apperror.New(apperror.PreconditionFailed, "account_not_verified", "verify email and phone number first")
+)
+
 type OrderController struct {
-	db        *gorm.DB
-	validator *util.Validator
+	db              *gorm.DB
+	validator       *util.Validator
+	requireVerified bool
 }
 
-func NewOrderController(db *gorm.DB, validator *util.Validator) *OrderController {
+func NewOrderController(db *gorm.DB, validator *util.Validator, requireVerified bool) *OrderController {
 	return &OrderController{
-		db:        db,
-		validator: validator,
+		db:              db,
+		validator:       validator,
+		requireVerified: requireVerified,
 	}
 }
 
@@ -41,12 +70,12 @@ func NewOrderController(db *gorm.DB, validator *util.Validator) *OrderController
 // @Router       /order/all [get]
 func (s *OrderController) GetOrders(c *gin.Context) {
 	// log
-	logCtx := log.WithFields(log.Fields{
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
 		"api": "GetOrders",
 	})
 
 	username := c.GetString("username")
-	accountRepo := repository.NewAccountRepository(s.db)
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
 	account, result := accountRepo.OneByEmail(username)
 	if result.RowsAffected == 0 || result.Error != nil {
 		err := errors.New("error find account")
@@ -54,15 +83,15 @@ func (s *OrderController) GetOrders(c *gin.Context) {
 			err = result.Error
 		}
 		logCtx.WithField("reason", err).Error("error find account")
-		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "account not found"})
+		apperror.Abort(c, errAccountNotFound)
 		return
 	}
 
-	orderRepo := repository.NewOrderRepository(s.db)
+	orderRepo := repository.NewOrderRepository(s.db.WithContext(c))
 	order, result := orderRepo.AllByAccountId(int(account.ID), "OrderItem", "OrderVoucher")
 	if result.Error != nil {
 		logCtx.WithField("reason", result.Error).Error("error find order")
-		c.AbortWithStatusJSON(http.StatusNotFound, nil)
+		apperror.Abort(c, apperror.ErrInternal)
 		return
 	}
 
@@ -82,12 +111,12 @@ func (s *OrderController) GetOrders(c *gin.Context) {
 // @Router       /order/{id} [get]
 func (s *OrderController) GetOrderDetail(c *gin.Context) {
 	// log
-	logCtx := log.WithFields(log.Fields{
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
 		"api": "GetOrder",
 	})
 
 	username := c.GetString("username")
-	accountRepo := repository.NewAccountRepository(s.db)
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
 	account, result := accountRepo.OneByEmail(username)
 	if result.RowsAffected == 0 || result.Error != nil {
 		err := errors.New("error find account")
@@ -95,7 +124,7 @@ func (s *OrderController) GetOrderDetail(c *gin.Context) {
 			err = result.Error
 		}
 		logCtx.WithField("reason", err).Error("error find account")
-		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "account not found"})
+		apperror.Abort(c, errAccountNotFound)
 		return
 	}
 
@@ -103,11 +132,11 @@ func (s *OrderController) GetOrderDetail(c *gin.Context) {
 	id, err := strconv.Atoi(idS)
 	if err != nil {
 		logCtx.WithField("reason", err).Error("error parse id")
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid id"})
+		apperror.Abort(c, errInvalidId)
 		return
 	}
 
-	orderRepo := repository.NewOrderRepository(s.db)
+	orderRepo := repository.NewOrderRepository(s.db.WithContext(c))
 	order, result := orderRepo.OneByIdAndAccountId(id, int(account.ID), "OrderItem", "OrderVoucher")
 	if result.RowsAffected == 0 || result.Error != nil {
 		err := errors.New("error find order")
@@ -115,7 +144,7 @@ func (s *OrderController) GetOrderDetail(c *gin.Context) {
 			err = result.Error
 		}
 		logCtx.WithField("reason", err).Error("error find order")
-		c.AbortWithStatusJSON(http.StatusNotFound, nil)
+		apperror.Abort(c, errOrderNotFound)
 		return
 	}
 
@@ -127,7 +156,7 @@ func (s *OrderController) GetOrderDetail(c *gin.Context) {
 
 // CheckoutOrder	goDocs
 // @Summary      create order from cart
-// @Description  create order from cart, need credential
+// @Description  create order from cart shipped to address_id of the address book, to the address sent or else to the default address, refused with 409 when a cart item price went up until the cart is acknowledged, and with 412 when verified email and phone number are required but missing, need credential
 // @Tags         Order
 // @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
 // @Param        tags body PostCreateOrderRequest true "Body Request"
@@ -137,25 +166,24 @@ func (s *OrderController) PostCreateOrder(c *gin.Context) {
 	// bind data
 	var req PostCreateOrderRequest
 	if err := c.ShouldBind(&req); err != nil {
-		log.WithField("reason", err).Error("error Binding")
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
 		return
 	}
 	// validate
 	if err := s.validator.Validate.Struct(&req); err != nil {
-		log.WithField("reason", err).Error("invalid Request")
-		errs := err.(validator.ValidationErrors)
-		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": errs.Translate(s.validator.Trans)})
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
 		return
 	}
 
 	// log
-	logCtx := log.WithFields(log.Fields{
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
 		"api": "PostCreateOrder",
 	})
 
 	username := c.GetString("username")
-	accountRepo := repository.NewAccountRepository(s.db)
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
 	account, result := accountRepo.OneByEmail(username)
 	if result.RowsAffected == 0 || result.Error != nil {
 		err := errors.New("error find account")
@@ -163,32 +191,60 @@ func (s *OrderController) PostCreateOrder(c *gin.Context) {
 			err = result.Error
 		}
 		logCtx.WithField("reason", err).Error("error find account")
-		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "account not found"})
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	if s.requireVerified && (account.EmailVerifiedAt == nil || account.PhoneVerifiedAt == nil) {
+		logCtx.WithField("reason", "account not verified").Error("error create order")
+		apperror.Abort(c, errAccountNotVerified)
 		return
 	}
 
+	accountAddressRepo := repository.NewAccountAddressRepository(s.db.WithContext(c))
+	if req.AddressID != 0 {
+		accountAddress, result := accountAddressRepo.OneByIdAndAccountId(req.AddressID, int(account.ID))
+		if result.RowsAffected == 0 || result.Error != nil {
+			var err error = errAddressNotFound
+			if result.Error != nil {
+				err = result.Error
+			}
+			logCtx.WithField("reason", err).Error("error find address")
+			apperror.Abort(c, errAddressNotFound)
+			return
+		}
+		req.Address = accountAddress.Address
+		req.PhoneNumber = accountAddress.PhoneNumber
+	}
+
 	if req.Address == "" {
 		req.Address = account.Address
+		if accountAddress, result := accountAddressRepo.OneDefaultByAccountId(int(account.ID)); result.Error == nil && result.RowsAffected > 0 {
+			req.Address = accountAddress.Address
+			if req.PhoneNumber == "" {
+				req.PhoneNumber = accountAddress.PhoneNumber
+			}
+		}
 	}
 
-	if req.PhoneNumber == "" {
-		req.PhoneNumber = account.PhoneNumber
+	if req.PhoneNumber == "" && account.PhoneNumber != nil {
+		req.PhoneNumber = *account.PhoneNumber
 	}
 
 	if req.VoucherCode != "" {
-		voucherRepo := repository.NewVoucherRepository(s.db)
+		voucherRepo := repository.NewVoucherRepository(s.db.WithContext(c))
 		if _, result := voucherRepo.OneByCode(req.VoucherCode); result.Error != nil || result.RowsAffected == 0 {
 			err := errors.New("voucher not found")
 			if result.Error != nil {
 				err = result.Error
 			}
 			logCtx.WithField("reason", err).Error("error find account")
-			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "voucher not found"})
+			apperror.Abort(c, errVoucherNotFound)
 			return
 		}
 	}
 
-	if err := s.db.Transaction(func(tx *gorm.DB) error {
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
 		var total float64
 
 		cartRepo := repository.NewCartRepository(tx)
@@ -197,7 +253,20 @@ func (s *OrderController) PostCreateOrder(c *gin.Context) {
 			return result.Error
 		}
 		if result.RowsAffected == 0 {
-			return errors.New("cart empty")
+			return errCartEmpty
+		}
+
+		var increased []uint
+		for _, v := range cart {
+			if v.Item == nil {
+				return fmt.Errorf("cart id %d: %w", v.ID, errItemNotFound)
+			}
+			if v.Price > 0 && v.Item.Price > v.Price {
+				increased = append(increased, v.ID)
+			}
+		}
+		if len(increased) > 0 {
+			return fmt.Errorf("cart id %v: %w", increased, errPriceIncreased)
 		}
 
 		orderRepo := repository.NewOrderRepository(tx)
@@ -205,7 +274,6 @@ func (s *OrderController) PostCreateOrder(c *gin.Context) {
 			AccountID:   account.ID,
 			Address:     req.Address,
 			PhoneNumber: req.PhoneNumber,
-			CreatedBy:   username,
 		})
 		if result.Error != nil {
 			return result.Error
@@ -216,7 +284,7 @@ func (s *OrderController) PostCreateOrder(c *gin.Context) {
 		for _, v := range cart {
 			remaining := *v.Item.Qty - v.Qty
 			if remaining < 0 {
-				return errors.New("qty > item qty")
+				return fmt.Errorf("cart id %d: %w", v.ID, errQtyExceedsStock)
 			}
 
 			orderItem, result := orderItemRepo.Create(model.OrderItem{
@@ -227,7 +295,6 @@ func (s *OrderController) PostCreateOrder(c *gin.Context) {
 				Price:       v.Item.Price,
 				Qty:         v.Qty,
 				Total:       v.Item.Price * float64(v.Qty),
-				CreatedBy:   username,
 			})
 			if result.Error != nil {
 				return result.Error
@@ -253,7 +320,10 @@ func (s *OrderController) PostCreateOrder(c *gin.Context) {
 				return result.Error
 			}
 			if result.RowsAffected == 0 {
-				return errors.New("voucher not found")
+				return errVoucherNotFound
+			}
+			if err := validateVoucher(tx, voucher, account); err != nil {
+				return err
 			}
 
 			appliedVoucher = total * voucher.Percentage / 100
@@ -271,7 +341,6 @@ func (s *OrderController) PostCreateOrder(c *gin.Context) {
 				Max:         voucher.Max,
 				Total:       total,
 				Applied:     appliedVoucher,
-				CreatedBy:   username,
 			}); result.Error != nil {
 				return result.Error
 			}
@@ -281,7 +350,7 @@ func (s *OrderController) PostCreateOrder(c *gin.Context) {
 		if totalPrice < 0 {
 			totalPrice = 0
 		}
-		order, result = orderRepo.Update(int(order.ID), model.Order{Total: totalPrice, UpdatedBy: username})
+		order, result = orderRepo.Update(int(order.ID), model.Order{Total: totalPrice})
 		if result.Error != nil {
 			return result.Error
 		}
@@ -289,7 +358,7 @@ func (s *OrderController) PostCreateOrder(c *gin.Context) {
 		return nil
 	}); err != nil {
 		logCtx.WithField("reason", err).Error("error create order")
-		c.AbortWithStatusJSON(http.StatusInternalServerError, nil)
+		apperror.Abort(c, err)
 		return
 	}
 
@@ -297,3 +366,158 @@ func (s *OrderController) PostCreateOrder(c *gin.Context) {
 		"message": "Sucess!",
 	})
 }
+
+// Reorder	goDocs
+// @Summary      add items of a previous order to cart
+// @Description  add the items of own order to cart at current price, lines of deleted items (ITEM_DELETED) or without stock (OUT_OF_STOCK) are skipped and lines with not enough stock left (LOW_STOCK) are reduced, need credential
+// @Tags         Order
+// @Param				 id path int true "reorder by id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /order/{id}/reorder [post]
+func (s *OrderController) PostReorder(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostReorder",
+	})
+
+	idS := c.Param("id")
+	id, err := strconv.Atoi(idS)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error parse id")
+		apperror.Abort(c, errInvalidId)
+		return
+	}
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	orderRepo := repository.NewOrderRepository(s.db.WithContext(c))
+	order, result := orderRepo.OneByIdAndAccountId(id, int(account.ID))
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find order")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find order")
+		apperror.Abort(c, errOrderNotFound)
+		return
+	}
+
+	res := ReorderResponse{
+		Carts:   []CartResponse{},
+		Skipped: []ReorderLine{},
+		Reduced: []ReorderLine{},
+	}
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		orderItemRepo := repository.NewOrderItemRepository(tx)
+		orderItems, result := orderItemRepo.AllByOrderId(int(order.ID))
+		if result.Error != nil {
+			return result.Error
+		}
+
+		owner := cartOwner{accountId: int(account.ID)}
+		itemRepo := repository.NewItemRepository(tx)
+		cartRepo := repository.NewCartRepository(tx)
+		for _, v := range orderItems {
+			line := ReorderLine{
+				ItemID: v.ItemID,
+				Name:   v.Name,
+				Qty:    v.Qty,
+			}
+
+			item, result := itemRepo.OneById(int(v.ItemID))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				line.Reason = "ITEM_DELETED"
+				res.Skipped = append(res.Skipped, line)
+				continue
+			}
+
+			// qty already in cart counts against the stock as addItemToCart adds onto it
+			inCart := 0
+			cart, result := cartRepo.OneUnscopedByOwnerAndItemId(owner.accountId, owner.guestId, int(item.ID))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 && (cart.DeletedAt == nil || !cart.DeletedAt.Valid) {
+				inCart = cart.Qty
+			}
+			stock := 0
+			if item.Qty != nil {
+				stock = *item.Qty
+			}
+
+			line.AddedQty = v.Qty
+			if left := stock - inCart; left < v.Qty {
+				line.AddedQty = left
+			}
+			if line.AddedQty <= 0 {
+				line.AddedQty = 0
+				line.Reason = "OUT_OF_STOCK"
+				if stock > 0 {
+					line.Reason = "LOW_STOCK"
+				}
+				res.Skipped = append(res.Skipped, line)
+				continue
+			}
+
+			cart, err := addItemToCart(tx, owner, int(item.ID), line.AddedQty, username)
+			if err != nil {
+				return fmt.Errorf("item id %d: %w", item.ID, err)
+			}
+			cart.Item = &item
+			res.Carts = append(res.Carts, newCartResponse(cart))
+
+			if line.AddedQty < v.Qty {
+				line.Reason = "LOW_STOCK"
+				res.Reduced = append(res.Reduced, line)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error reorder")
+		apperror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    res,
+	})
+}
+
+// validateVoucher checks a voucher bound to an account is used by that account,
+// is not expired, and a single use voucher has not been applied to an order yet
+func validateVoucher(db *gorm.DB, voucher model.Voucher, account model.Account) error {
+	if voucher.AccountID != nil && *voucher.AccountID != account.ID {
+		return errVoucherNotFound
+	}
+	if voucher.ExpiredAt != nil && voucher.ExpiredAt.Before(time.Now()) {
+		return errVoucherUnavailable
+	}
+	if voucher.SingleUse {
+		orderVoucherRepo := repository.NewOrderVoucherRepository(db)
+		used, result := orderVoucherRepo.CountByVoucherId(int(voucher.ID))
+		if result.Error != nil {
+			return result.Error
+		}
+		if used > 0 {
+			return errVoucherUnavailable
+		}
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func OrderNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_OrderPostCreateOrder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// the cart lines are read with their item, a deleted item is not preloaded
+	expectCart := func(mock sqlmock.Sqlmock, lines *sqlmock.Rows, items *sqlmock.Rows) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE email = ?")).
+			WithArgs("email@mail.com").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "address"}).AddRow(1, "email@mail.com", "Street 1"))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_addresses`")).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE (account_id = ? AND saved_for_later = ?)")).
+			WithArgs(1, false).
+			WillReturnRows(lines)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE `items`.`id` = ?")).
+			WillReturnRows(items)
+		mock.ExpectRollback()
+	}
+	lineColumns := []string{"id", "account_id", "item_id", "qty", "price"}
+	itemColumns := []string{"id", "name", "qty", "price"}
+
+	tests := []struct {
+		name       string
+		mock       func(mock sqlmock.Sqlmock)
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			// the buyer has to see the new price before paying it
+			name: "Price went up since added",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectCart(mock,
+					sqlmock.NewRows(lineColumns).AddRow(3, 1, 2, 1, 10),
+					sqlmock.NewRows(itemColumns).AddRow(2, "Book", 5, 12))
+			},
+			wantStatus: http.StatusConflict,
+			wantCode:   "price_increased",
+		},
+		{
+			name: "Item deleted since added",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectCart(mock,
+					sqlmock.NewRows(lineColumns).AddRow(3, 1, 2, 1, 10),
+					sqlmock.NewRows(itemColumns))
+			},
+			wantStatus: http.StatusNotFound,
+			wantCode:   "item_not_found",
+		},
+		{
+			// saved for later lines are not read, a cart of only saved lines has nothing to order
+			name: "Only saved lines",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE email = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "address"}).AddRow(1, "email@mail.com", "Street 1"))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_addresses`")).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE (account_id = ? AND saved_for_later = ?)")).
+					WithArgs(1, false).
+					WillReturnRows(sqlmock.NewRows(lineColumns))
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusPreconditionFailed,
+			wantCode:   "cart_empty",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := OrderNewMockDB()
+			tt.mock(mock)
+
+			s := NewOrderController(db, util.ValidatorTranslate(), false)
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "email@mail.com")
+			})
+			router.POST("/order", s.PostCreateOrder)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/order", strings.NewReader(`{}`))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			var body apperror.Response
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantCode, body.Error.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_validateVoucher(t *testing.T) {
+	owner := uint(1)
+	other := uint(2)
+	expired := time.Now().Add(-time.Hour)
+	unused, usedOnce := 0, 1
+
+	tests := []struct {
+		name    string
+		voucher model.Voucher
+		// single use vouchers count their orders, nil when not counted
+		used    *int
+		wantErr error
+	}{
+		{
+			name:    "Public voucher",
+			voucher: model.Voucher{ID: 7},
+		},
+		{
+			name:    "Reminder voucher of the account",
+			voucher: model.Voucher{ID: 7, AccountID: &owner, SingleUse: true},
+			used:    &unused,
+		},
+		{
+			// a voucher bound to another account is not disclosed
+			name:    "Reminder voucher of another account",
+			voucher: model.Voucher{ID: 7, AccountID: &other, SingleUse: true},
+			wantErr: errVoucherNotFound,
+		},
+		{
+			name:    "Expired voucher",
+			voucher: model.Voucher{ID: 7, AccountID: &owner, ExpiredAt: &expired},
+			wantErr: errVoucherUnavailable,
+		},
+		{
+			name:    "Single use voucher used",
+			voucher: model.Voucher{ID: 7, AccountID: &owner, SingleUse: true},
+			used:    &usedOnce,
+			wantErr: errVoucherUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := OrderNewMockDB()
+			if tt.used != nil {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `order_vouchers` WHERE voucher_id = ?")).
+					WithArgs(7).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(*tt.used))
+			}
+
+			err := validateVoucher(db, tt.voucher, model.Account{ID: owner})
+			assert.Equal(t, tt.wantErr, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_OrderPostReorder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	lineColumns := []string{"id", "account_id", "item_id", "qty", "price", "saved_for_later", "deleted_at"}
+	expectLine := func(mock sqlmock.Sqlmock, itemId int, rows *sqlmock.Rows) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE item_id = ? AND account_id = ?")).
+			WithArgs(itemId, 1).
+			WillReturnRows(rows)
+	}
+
+	db, mock := OrderNewMockDB()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE email = ?")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "email@mail.com"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `orders` WHERE (id = ? AND account_id = ?)")).
+		WithArgs(9, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id"}).AddRow(9, 1))
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `order_items` WHERE order_id = ?")).
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "item_id", "name", "qty"}).
+			AddRow(1, 9, 2, "Deleted", 1).
+			AddRow(2, 9, 3, "Sold out", 1).
+			AddRow(3, 9, 4, "Scarce", 2).
+			AddRow(4, 9, 5, "Plenty", 2))
+	// the item was deleted
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE id = ?")).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	// no stock left
+	expectItem(mock, 3, 0, 5)
+	expectLine(mock, 3, sqlmock.NewRows(lineColumns))
+	// 4 of 5 already in the cart, only 1 more fits
+	expectItem(mock, 4, 5, 5)
+	expectLine(mock, 4, sqlmock.NewRows(lineColumns).AddRow(7, 1, 4, 4, 5, false, nil))
+	expectItem(mock, 4, 5, 5)
+	expectLine(mock, 4, sqlmock.NewRows(lineColumns).AddRow(7, 1, 4, 4, 5, false, nil))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE item_id = ? AND account_id = ? FOR UPDATE")).
+		WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(7, 1, 4, 4, 5, false, nil))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ?")).
+		WillReturnRows(sqlmock.NewRows(lineColumns).AddRow(7, 1, 4, 4, 5, false, nil))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `carts` SET")).WillReturnResult(sqlmock.NewResult(0, 1))
+	// added in full
+	expectItem(mock, 5, 10, 5)
+	expectLine(mock, 5, sqlmock.NewRows(lineColumns))
+	expectItem(mock, 5, 10, 5)
+	expectLine(mock, 5, sqlmock.NewRows(lineColumns))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `carts`")).WillReturnResult(sqlmock.NewResult(8, 1))
+	mock.ExpectCommit()
+
+	s := NewOrderController(db, util.ValidatorTranslate(), false)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("username", "email@mail.com")
+	})
+	router.POST("/order/:id/reorder", s.PostReorder)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/order/9/reorder", nil)
+	router.ServeHTTP(w, req)
+
+	var body struct {
+		Data ReorderResponse `json:"data"`
+	}
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, []ReorderLine{
+		{ItemID: 2, Name: "Deleted", Qty: 1, Reason: "ITEM_DELETED"},
+		{ItemID: 3, Name: "Sold out", Qty: 1, Reason: "OUT_OF_STOCK"},
+	}, body.Data.Skipped)
+	assert.Equal(t, []ReorderLine{
+		{ItemID: 4, Name: "Scarce", Qty: 2, AddedQty: 1, Reason: "LOW_STOCK"},
+	}, body.Data.Reduced)
+	assert.Len(t, body.Data.Carts, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/jobs"
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/audit"
+	"github.com/avarian/online-shopping-cart/service/auth"
+	"github.com/avarian/online-shopping-cart/service/logging"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type PostForgotPasswordRequest struct {
+	Email string `json:"email"  validate:"required,email"`
+}
+
+type PostResetPasswordRequest struct {
+	Token    string `json:"token"  validate:"required"`
+	Password string `json:"password"  validate:"required"`
+}
+
+var errInvalidResetToken = apperror.New(apperror.Invalid, "invalid_reset_token", "invalid or expired token")
+
+// PasswordResetConfig is where the emailed reset link points to and how long it works
+type PasswordResetConfig struct {
+	Url string
+	TTL time.Duration
+}
+
+type PasswordController struct {
+	db        *gorm.DB
+	validator *util.Validator
+	tokens    *auth.TokenService
+	reset     PasswordResetConfig
+}
+
+func NewPasswordController(db *gorm.DB, validator *util.Validator, tokens *auth.TokenService, reset PasswordResetConfig) *PasswordController {
+	return &PasswordController{
+		db:        db,
+		validator: validator,
+		tokens:    tokens,
+		reset:     reset,
+	}
+}
+
+// ForgotPassword	goDocs
+// @Summary      request a password reset link
+// @Description  email a one time password reset link to the account, the response is the same whether the email is registered or not
+// @Tags         Account
+// @Produce      application/json
+// @Param        tags body PostForgotPasswordRequest true "Body Request"
+// @Router       /password/forgot [post]
+func (s *PasswordController) PostForgotPassword(c *gin.Context) {
+	// bind data
+	var req PostForgotPasswordRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"email": req.Email,
+		"api":   "PostForgotPassword",
+	})
+
+	s.sendResetLink(c, logCtx, req.Email)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+	})
+}
+
+// sendResetLink only logs failures, so the response never tells whether the account exists
+func (s *PasswordController) sendResetLink(c *gin.Context, logCtx *log.Entry, email string) {
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(email)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		return
+	}
+
+	if err := sendPasswordResetLink(s.db.WithContext(c), s.reset, account, account.Email); err != nil {
+		logCtx.WithField("reason", err).Error("error send reset link")
+	}
+}
+
+// sendPasswordResetLink stores a one time reset token of the account and emails the link to it
+func sendPasswordResetLink(db *gorm.DB, config PasswordResetConfig, account model.Account, createdBy string) error {
+	token, err := util.RandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	passwordResetRepo := repository.NewPasswordResetRepository(db)
+	if _, result := passwordResetRepo.Create(model.PasswordReset{
+		AccountID: account.ID,
+		TokenHash: util.HashToken(token),
+		ExpiredAt: time.Now().Add(config.TTL),
+		CreatedBy: createdBy,
+	}); result.Error != nil {
+		return result.Error
+	}
+
+	link := config.Url + "?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("<p>Hi %s,</p><p>Use <a href=\"%s\">this link</a> to reset your password, it expires in %d minutes.</p><p>Ignore this email if you did not ask for it.</p>",
+		html.EscapeString(account.Name), html.EscapeString(link), int(config.TTL.Minutes()))
+	return jobs.Dispatch(jobs.NewSendEmailJob(account.Email, "Reset your password", body))
+}
+
+// ResetPassword	goDocs
+// @Summary      reset password
+// @Description  set a new password with the token from the reset link, the token works once and every login session of the account is revoked
+// @Tags         Account
+// @Produce      application/json
+// @Param        tags body PostResetPasswordRequest true "Body Request"
+// @Router       /password/reset [post]
+func (s *PasswordController) PostResetPassword(c *gin.Context) {
+	// bind data
+	var req PostResetPasswordRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostResetPassword",
+	})
+
+	passwordResetRepo := repository.NewPasswordResetRepository(s.db.WithContext(c))
+	passwordReset, result := passwordResetRepo.OneByTokenHash(util.HashToken(req.Token), "Account")
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error find password reset")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+	if result.RowsAffected == 0 || passwordReset.UsedAt != nil || time.Now().After(passwordReset.ExpiredAt) || passwordReset.Account == nil {
+		logCtx.WithField("reason", errInvalidResetToken).Error("error reset password")
+		apperror.Abort(c, errInvalidResetToken)
+		return
+	}
+	account := *passwordReset.Account
+	c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), account.Email))
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), 5)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error hash password")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		passwordResetRepo := repository.NewPasswordResetRepository(tx)
+		result := passwordResetRepo.MarkUsed(int(passwordReset.ID))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvalidResetToken
+		}
+		if result := passwordResetRepo.MarkUsedByAccountId(int(account.ID)); result.Error != nil {
+			return result.Error
+		}
+
+		accountRepo := repository.NewAccountRepository(tx)
+		if _, result := accountRepo.Update(int(account.ID), model.Account{
+			Password: string(hashedPassword),
+		}); result.Error != nil {
+			return result.Error
+		}
+		if result := accountRepo.UpdatePasswordResetRequired(int(account.ID), false, account.Email); result.Error != nil {
+			return result.Error
+		}
+		return nil
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error reset password")
+		apperror.Abort(c, err)
+		return
+	}
+
+	if err := s.tokens.RevokeAccountSessions(c.Request.Context(), int(account.ID), account.Email); err != nil {
+		logCtx.WithField("reason", err).Error("error revoke sessions")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+	})
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/auth"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func PasswordNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_PasswordPostResetPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resetColumns := []string{"id", "account_id", "token_hash", "expired_at", "used_at"}
+
+	expectReset := func(mock sqlmock.Sqlmock, rows *sqlmock.Rows) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `password_resets` WHERE token_hash = ?")).
+			WithArgs(util.HashToken("token")).
+			WillReturnRows(rows)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE `accounts`.`id` = ?")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "email@mail.com"))
+	}
+	expectMarkUsed := func(mock sqlmock.Sqlmock, affected int64) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `password_resets` SET `used_at`=?,`updated_at`=? WHERE (id = ? AND used_at IS NULL)")).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 3).
+			WillReturnResult(sqlmock.NewResult(0, affected))
+	}
+
+	tests := []struct {
+		name       string
+		mock       func(mock sqlmock.Sqlmock)
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			// the token is used up and every session of the account is logged out
+			name: "Reset with valid token",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectReset(mock, sqlmock.NewRows(resetColumns).AddRow(3, 1, util.HashToken("token"), time.Now().Add(time.Hour), nil))
+				expectMarkUsed(mock, 1)
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `password_resets` SET `used_at`=?,`updated_at`=? WHERE (account_id = ? AND used_at IS NULL)")).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE id = ?")).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "email@mail.com"))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `accounts` SET")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `accounts` SET `password_reset_required`=?,`updated_by`=?")).
+					WithArgs(false, "email@mail.com", sqlmock.AnyArg(), 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT `family_id` FROM `refresh_tokens`")).
+					WillReturnRows(sqlmock.NewRows([]string{"family_id"}))
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "Unknown token",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `password_resets` WHERE token_hash = ?")).
+					WillReturnRows(sqlmock.NewRows(resetColumns))
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   "invalid_reset_token",
+		},
+		{
+			name: "Used token",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectReset(mock, sqlmock.NewRows(resetColumns).AddRow(3, 1, util.HashToken("token"), time.Now().Add(time.Hour), time.Now()))
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   "invalid_reset_token",
+		},
+		{
+			name: "Expired token",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectReset(mock, sqlmock.NewRows(resetColumns).AddRow(3, 1, util.HashToken("token"), time.Now().Add(-time.Minute), nil))
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   "invalid_reset_token",
+		},
+		{
+			// both requests read the token unused, only the first one to mark it used resets the password
+			name: "Token used concurrently",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectReset(mock, sqlmock.NewRows(resetColumns).AddRow(3, 1, util.HashToken("token"), time.Now().Add(time.Hour), nil))
+				expectMarkUsed(mock, 0)
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   "invalid_reset_token",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := PasswordNewMockDB()
+			tt.mock(mock)
+
+			tokens := auth.NewTokenService(db, "secret", nil, 0, 0, nil)
+			s := NewPasswordController(db, util.ValidatorTranslate(), tokens, PasswordResetConfig{})
+			router := gin.New()
+			router.POST("/password/reset", s.PostResetPassword)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/password/reset", strings.NewReader(`{"token":"token","password":"new password"}`))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			if tt.wantCode != "" {
+				var body apperror.Response
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tt.wantCode, body.Error.Code)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
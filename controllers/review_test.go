@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func ReviewNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_CreateReview(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	expectBuyer := func(mock sqlmock.Sqlmock, purchased int) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE email = ?")).
+			WithArgs("email@mail.com").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "email@mail.com"))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE id = ?")).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "Book"))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `order_items` JOIN orders")).
+			WithArgs(1, "CANCELLED", 2).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(purchased))
+	}
+	expectReviewed := func(mock sqlmock.Sqlmock, rows *sqlmock.Rows) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `reviews` WHERE (item_id = ? AND account_id = ?)")).
+			WithArgs(2, 1).
+			WillReturnRows(rows)
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		mock       func(mock sqlmock.Sqlmock)
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name: "Verified buyer reviews",
+			body: `{"rating":5,"title":"Great","images":["https://img.example.com/1.png"]}`,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectBuyer(mock, 1)
+				expectReviewed(mock, sqlmock.NewRows([]string{"id"}))
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `reviews`")).
+					WillReturnResult(sqlmock.NewResult(3, 1))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `review_images`")).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			// cancelled orders are not counted, so the buyer never received the item
+			name: "Item not bought",
+			body: `{"rating":5,"title":"Great"}`,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectBuyer(mock, 0)
+			},
+			wantStatus: http.StatusForbidden,
+			wantCode:   "item_not_purchased",
+		},
+		{
+			name: "Item already reviewed",
+			body: `{"rating":5,"title":"Great"}`,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectBuyer(mock, 1)
+				expectReviewed(mock, sqlmock.NewRows([]string{"id", "item_id", "account_id"}).AddRow(3, 2, 1))
+			},
+			wantStatus: http.StatusConflict,
+			wantCode:   "item_reviewed",
+		},
+		{
+			name:       "Rating out of range",
+			body:       `{"rating":6,"title":"Great"}`,
+			mock:       func(mock sqlmock.Sqlmock) {},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := ReviewNewMockDB()
+			tt.mock(mock)
+
+			s := NewReviewController(db, util.ValidatorTranslate())
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "email@mail.com")
+			})
+			router.POST("/item/:id/review", s.PostCreateReview)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/item/2/review", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				var body apperror.Response
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tt.wantCode, body.Error.Code)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// customers only see approved reviews, whatever status they ask for
+func Test_GetItemReviews(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Parallel()
+
+	db, mock := ReviewNewMockDB()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `reviews` WHERE item_id = ? AND status = ?")).
+		WithArgs("2", "APPROVED").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "item_id", "status"}).AddRow(3, 2, "APPROVED"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `review_images` WHERE `review_images`.`review_id` = ?")).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "review_id", "url"}))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `reviews` WHERE item_id = ? AND status = ?")).
+		WithArgs("2", "APPROVED").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	s := NewReviewController(db, util.ValidatorTranslate())
+	router := gin.New()
+	router.GET("/item/:id/review", s.GetItemReviews)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/item/2/review?status=HIDDEN&item_id=9", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_ModerateReview(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	expectReview := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `reviews` WHERE id = ?")).
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "item_id", "rating", "status"}).AddRow(3, 1, 2, 4, "PENDING"))
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		mock       func(mock sqlmock.Sqlmock)
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			// the approved review is counted in the rating of its item
+			name: "Approve refreshes item rating",
+			path: "/review/3/approve",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expectReview(mock)
+				expectReview(mock)
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `reviews` SET")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count FROM `reviews` WHERE (item_id = ? AND status = ?)")).
+					WithArgs(2, "APPROVED").
+					WillReturnRows(sqlmock.NewRows([]string{"average", "count"}).AddRow(4.5, 2))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `items` SET `rating_average`=?,`rating_count`=?,`updated_at`=? WHERE id = ?")).
+					WithArgs(4.5, 2, sqlmock.AnyArg(), 2).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "Hide missing review",
+			path: "/review/3/hide",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `reviews` WHERE id = ?")).
+					WithArgs(3).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusNotFound,
+			wantCode:   "review_not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := ReviewNewMockDB()
+			tt.mock(mock)
+
+			s := NewReviewController(db, util.ValidatorTranslate())
+			router := gin.New()
+			router.PUT("/review/:id/approve", s.PutApproveReview)
+			router.PUT("/review/:id/hide", s.PutHideReview)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, tt.path, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				var body apperror.Response
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tt.wantCode, body.Error.Code)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
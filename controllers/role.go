@@ -0,0 +1,439 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/auth"
+	"github.com/avarian/online-shopping-cart/service/logging"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type PostCreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,max=64"`
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions" validate:"required,dive,required"`
+}
+
+type PutEditRoleRequest struct {
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions" validate:"omitempty,dive,required"`
+}
+
+var (
+	errUnknownPermission = apperror.New(apperror.Invalid, "unknown_permission", "unknown permission")
+	errRoleNotFound      = apperror.New(apperror.NotFound, "role_not_found", "role not found")
+	errRoleExists        = apperror.New(apperror.Conflict, "role_exists", "role already exists")
+	errRoleAssigned      = apperror.New(apperror.Conflict, "role_assigned", "role already assigned")
+	errRoleNotAssigned   = apperror.New(apperror.NotFound, "role_not_assigned", "role not assigned")
+)
+
+type RoleController struct {
+	db        *gorm.DB
+	validator *util.Validator
+}
+
+func NewRoleController(db *gorm.DB, validator *util.Validator) *RoleController {
+	return &RoleController{
+		db:        db,
+		validator: validator,
+	}
+}
+
+// GetAllRole	goDocs
+// @Summary      get all roles
+// @Description  get all roles with their permissions, need permission role:write
+// @Tags         Role
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /role/all [get]
+func (s *RoleController) GetRoles(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api":    "GetRoles",
+		"params": c.Request.URL.RawQuery,
+	})
+
+	roleRepo := repository.NewRoleRepository(s.db.WithContext(c))
+	role, result := roleRepo.All(c.Request, "RolePermission")
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error find role")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    role,
+	})
+}
+
+// GetAllPermission	goDocs
+// @Summary      get all permissions
+// @Description  get all permissions that can be granted to a role, need permission role:write
+// @Tags         Role
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /role/permission [get]
+func (s *RoleController) GetPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    auth.Permissions,
+	})
+}
+
+// AddRole	goDocs
+// @Summary      add role
+// @Description  add role granting permissions, need permission role:write
+// @Tags         Role
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PostCreateRoleRequest true "Body Request"
+// @Produce      application/json
+// @Router       /role [post]
+func (s *RoleController) PostCreateRole(c *gin.Context) {
+	// bind data
+	var req PostCreateRoleRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostCreateRole",
+	})
+
+	roleRepo := repository.NewRoleRepository(s.db.WithContext(c))
+	if _, result := roleRepo.OneByName(req.Name); result.RowsAffected > 0 {
+		logCtx.WithField("reason", "role exists").Error("error create role")
+		apperror.Abort(c, errRoleExists)
+		return
+	}
+
+	var role model.Role
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		roleRepo := repository.NewRoleRepository(tx)
+		var result *gorm.DB
+		role, result = roleRepo.Create(model.Role{
+			Name:        req.Name,
+			Description: req.Description,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return setRolePermissions(tx, int(role.ID), req.Permissions)
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error create role")
+		apperror.Abort(c, err)
+		return
+	}
+
+	role, _ = roleRepo.OneById(int(role.ID), "RolePermission")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    role,
+	})
+}
+
+// EditRole	goDocs
+// @Summary      edit role
+// @Description  edit role description, permissions when sent replace the current ones and apply to tokens issued afterwards, need permission role:write
+// @Tags         Role
+// @Param				 id path int true "edit by id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PutEditRoleRequest true "Body Request"
+// @Produce      application/json
+// @Router       /role/{id} [put]
+func (s *RoleController) PutEditRole(c *gin.Context) {
+	// bind data
+	var req PutEditRoleRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PutEditRole",
+	})
+
+	idS := c.Param("id")
+	id, err := strconv.Atoi(idS)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error parse id")
+		apperror.Abort(c, errInvalidId)
+		return
+	}
+
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		roleRepo := repository.NewRoleRepository(tx)
+		_, result := roleRepo.Update(id, model.Role{
+			Description: req.Description,
+		})
+		if result.RowsAffected == 0 {
+			return errRoleNotFound
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if req.Permissions == nil {
+			return nil
+		}
+		rolePermissionRepo := repository.NewRolePermissionRepository(tx)
+		if result := rolePermissionRepo.DeleteByRoleId(id); result.Error != nil {
+			return result.Error
+		}
+		return setRolePermissions(tx, id, req.Permissions)
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error edit role")
+		apperror.Abort(c, err)
+		return
+	}
+
+	roleRepo := repository.NewRoleRepository(s.db.WithContext(c))
+	role, _ := roleRepo.OneById(id, "RolePermission")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    role,
+	})
+}
+
+// deleteRole	goDocs
+// @Summary      delete role
+// @Description  delete role and remove it from accounts, need permission role:write
+// @Tags         Role
+// @Param				 id path int true "delete by id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /role/{id} [delete]
+func (s *RoleController) DeleteRole(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "DeleteRole",
+	})
+
+	idS := c.Param("id")
+	id, err := strconv.Atoi(idS)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error parse id")
+		apperror.Abort(c, errInvalidId)
+		return
+	}
+
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		roleRepo := repository.NewRoleRepository(tx)
+		_, result := roleRepo.OneById(id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errRoleNotFound
+		}
+
+		accountRoleRepo := repository.NewAccountRoleRepository(tx)
+		if result := accountRoleRepo.DeleteByRoleId(id); result.Error != nil {
+			return result.Error
+		}
+		rolePermissionRepo := repository.NewRolePermissionRepository(tx)
+		if result := rolePermissionRepo.DeleteByRoleId(id); result.Error != nil {
+			return result.Error
+		}
+		if result := roleRepo.Delete(id, false); result.Error != nil {
+			return result.Error
+		}
+		return nil
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error delete role")
+		apperror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+	})
+}
+
+// AssignRole	goDocs
+// @Summary      assign role to account
+// @Description  grant the role to an account and make it ADMIN, applies to tokens issued afterwards, need permission role:write
+// @Tags         Role
+// @Param				 id path int true "role id"
+// @Param				 account_id path int true "account id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /role/{id}/account/{account_id} [post]
+func (s *RoleController) PostAssignRole(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostAssignRole",
+	})
+
+	role, account, ok := s.roleAndAccount(c, logCtx)
+	if !ok {
+		return
+	}
+
+	accountRoleRepo := repository.NewAccountRoleRepository(s.db.WithContext(c))
+	if _, result := accountRoleRepo.OneByAccountIdAndRoleId(int(account.ID), int(role.ID)); result.RowsAffected > 0 {
+		logCtx.WithField("reason", "role assigned").Error("error assign role")
+		apperror.Abort(c, errRoleAssigned)
+		return
+	}
+
+	var accountRole model.AccountRole
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		accountRoleRepo := repository.NewAccountRoleRepository(tx)
+		var result *gorm.DB
+		accountRole, result = accountRoleRepo.Create(model.AccountRole{
+			AccountID: account.ID,
+			RoleID:    role.ID,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		// holding a role makes the account an admin, e.g. for mandatory two factor authentication
+		accountRepo := repository.NewAccountRepository(tx)
+		return accountRepo.UpdateType(int(account.ID), "ADMIN", c.GetString("username")).Error
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error assign role")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    accountRole,
+	})
+}
+
+// UnassignRole	goDocs
+// @Summary      remove role from account
+// @Description  revoke the role from an account, applies to tokens issued afterwards, need permission role:write
+// @Tags         Role
+// @Param				 id path int true "role id"
+// @Param				 account_id path int true "account id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /role/{id}/account/{account_id} [delete]
+func (s *RoleController) DeleteAssignRole(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "DeleteAssignRole",
+	})
+
+	role, account, ok := s.roleAndAccount(c, logCtx)
+	if !ok {
+		return
+	}
+
+	accountRoleRepo := repository.NewAccountRoleRepository(s.db.WithContext(c))
+	accountRole, result := accountRoleRepo.OneByAccountIdAndRoleId(int(account.ID), int(role.ID))
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account role")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account role")
+		apperror.Abort(c, errRoleNotAssigned)
+		return
+	}
+
+	if result := accountRoleRepo.Delete(int(accountRole.ID), true); result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error unassign role")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+	})
+}
+
+func (s *RoleController) roleAndAccount(c *gin.Context, logCtx *log.Entry) (model.Role, model.Account, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error parse id")
+		apperror.Abort(c, errInvalidId)
+		return model.Role{}, model.Account{}, false
+	}
+	accountId, err := strconv.Atoi(c.Param("account_id"))
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error parse account id")
+		apperror.Abort(c, errInvalidId)
+		return model.Role{}, model.Account{}, false
+	}
+
+	roleRepo := repository.NewRoleRepository(s.db.WithContext(c))
+	role, result := roleRepo.OneById(id)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find role")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find role")
+		apperror.Abort(c, errRoleNotFound)
+		return model.Role{}, model.Account{}, false
+	}
+
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneById(accountId)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return model.Role{}, model.Account{}, false
+	}
+
+	return role, account, true
+}
+
+func setRolePermissions(db *gorm.DB, roleId int, permissions []string) error {
+	rolePermissionRepo := repository.NewRolePermissionRepository(db)
+	seen := map[string]bool{}
+	for _, v := range permissions {
+		if !auth.IsPermission(v) {
+			return fmt.Errorf("%s: %w", v, errUnknownPermission)
+		}
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+
+		if _, result := rolePermissionRepo.Create(model.RolePermission{
+			RoleID:     uint(roleId),
+			Permission: v,
+		}); result.Error != nil {
+			return result.Error
+		}
+	}
+	return nil
+}
@@ -0,0 +1,710 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/auth"
+	"github.com/avarian/online-shopping-cart/service/logging"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type PostConfirmTwoFactorRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type DeleteTwoFactorRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type PostLoginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode   string `json:"recovery_code" validate:"required_without=Code"`
+}
+
+type PostLoginEnrollTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+}
+
+type PostLoginConfirmTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type TwoFactorChallengeResponse struct {
+	ChallengeToken     string `json:"challenge_token"`
+	ExpiresIn          int64  `json:"expires_in"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+}
+
+type TwoFactorEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningUri string `json:"provisioning_uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TwoFactorLoginEnrollResponse struct {
+	auth.TokenPair
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorConfig is the issuer shown in authenticator apps, how long the login challenge works,
+// and whether ADMIN accounts must use two factor authentication
+type TwoFactorConfig struct {
+	Issuer           string
+	ChallengeTTL     time.Duration
+	RequiredForAdmin bool
+}
+
+const recoveryCodeCount = 10
+
+var (
+	errTwoFactorEnabled     = apperror.New(apperror.Conflict, "two_factor_enabled", "two factor authentication already enabled")
+	errTwoFactorNotEnrolled = apperror.New(apperror.Conflict, "two_factor_not_enrolled", "two factor authentication not enrolled")
+	errTwoFactorRequired    = apperror.New(apperror.Invalid, "two_factor_required", "two factor authentication is required for admin accounts")
+	errInvalidTwoFactorCode = apperror.New(apperror.Invalid, "invalid_code", "invalid code")
+	// a wrong code while logging in counts as a failed login
+	errTwoFactorLoginFailed = apperror.New(apperror.Unauthorized, "invalid_code", "invalid code")
+)
+
+type TwoFactorController struct {
+	db        *gorm.DB
+	validator *util.Validator
+	jwtSecret string
+	tokens    *auth.TokenService
+	throttle  *auth.LoginThrottle
+	config    TwoFactorConfig
+}
+
+func NewTwoFactorController(db *gorm.DB, validator *util.Validator, jwtSecret string, tokens *auth.TokenService, throttle *auth.LoginThrottle, config TwoFactorConfig) *TwoFactorController {
+	return &TwoFactorController{
+		db:        db,
+		validator: validator,
+		jwtSecret: jwtSecret,
+		tokens:    tokens,
+		throttle:  throttle,
+		config:    config,
+	}
+}
+
+// EnrollTwoFactor	goDocs
+// @Summary      start two factor enrollment
+// @Description  generate a TOTP secret and its provisioning uri to show as QR code, two factor authentication is enabled once a code is confirmed, need credentials
+// @Tags         Account
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /account/me/2fa [post]
+func (s *TwoFactorController) PostEnrollTwoFactor(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostEnrollTwoFactor",
+	})
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	enrollment, err := enrollTwoFactor(s.db.WithContext(c), s.config, account)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error enroll two factor")
+		apperror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    enrollment,
+	})
+}
+
+// ConfirmTwoFactor	goDocs
+// @Summary      confirm two factor enrollment
+// @Description  enable two factor authentication with a code of the authenticator app, the recovery codes are only shown once, need credentials
+// @Tags         Account
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PostConfirmTwoFactorRequest true "Body Request"
+// @Produce      application/json
+// @Router       /account/me/2fa/confirm [post]
+func (s *TwoFactorController) PostConfirmTwoFactor(c *gin.Context) {
+	// bind data
+	var req PostConfirmTwoFactorRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostConfirmTwoFactor",
+	})
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	recoveryCodes, err := confirmTwoFactor(s.db.WithContext(c), account, req.Code)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error confirm two factor")
+		apperror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    RecoveryCodesResponse{RecoveryCodes: recoveryCodes},
+	})
+}
+
+// DisableTwoFactor	goDocs
+// @Summary      disable two factor authentication
+// @Description  disable two factor authentication with the password and a current code, refused for ADMIN accounts when it is mandatory, need credentials
+// @Tags         Account
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body DeleteTwoFactorRequest true "Body Request"
+// @Produce      application/json
+// @Router       /account/me/2fa [delete]
+func (s *TwoFactorController) DeleteTwoFactor(c *gin.Context) {
+	// bind data
+	var req DeleteTwoFactorRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "DeleteTwoFactor",
+	})
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	if s.config.RequiredForAdmin && account.Type == "ADMIN" {
+		logCtx.WithField("reason", errTwoFactorRequired).Error("error disable two factor")
+		apperror.Abort(c, errTwoFactorRequired)
+		return
+	}
+
+	if err := checkCurrentPassword(account, req.Password); err != nil {
+		logCtx.WithField("reason", err).Error("error compare password")
+		apperror.Abort(c, err)
+		return
+	}
+
+	if err := verifySecondFactor(s.db.WithContext(c), account, req.Code, ""); err != nil {
+		logCtx.WithField("reason", err).Error("error verify two factor")
+		apperror.Abort(c, err)
+		return
+	}
+
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		accountRepo := repository.NewAccountRepository(tx)
+		if result := accountRepo.UpdateTotpSecret(int(account.ID), nil, username); result.Error != nil {
+			return result.Error
+		}
+		recoveryCodeRepo := repository.NewRecoveryCodeRepository(tx)
+		if result := recoveryCodeRepo.DeleteByAccountId(int(account.ID)); result.Error != nil {
+			return result.Error
+		}
+		return nil
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error disable two factor")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+	})
+}
+
+// RegenerateRecoveryCodes	goDocs
+// @Summary      regenerate recovery codes
+// @Description  replace the recovery codes with new ones after checking a current code, need credentials
+// @Tags         Account
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PostConfirmTwoFactorRequest true "Body Request"
+// @Produce      application/json
+// @Router       /account/me/2fa/recovery-codes [post]
+func (s *TwoFactorController) PostRegenerateRecoveryCodes(c *gin.Context) {
+	// bind data
+	var req PostConfirmTwoFactorRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostRegenerateRecoveryCodes",
+	})
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	if err := verifySecondFactor(s.db.WithContext(c), account, req.Code, ""); err != nil {
+		logCtx.WithField("reason", err).Error("error verify two factor")
+		apperror.Abort(c, err)
+		return
+	}
+
+	var recoveryCodes []string
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var err error
+		recoveryCodes, err = replaceRecoveryCodes(tx, account)
+		return err
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error regenerate recovery codes")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    RecoveryCodesResponse{RecoveryCodes: recoveryCodes},
+	})
+}
+
+// LoginTwoFactor	goDocs
+// @Summary      finish login with two factor code
+// @Description  exchange the challenge token of /login and a code of the authenticator app, or an unused recovery code, for a JWT token and a refresh token
+// @Tags         Account
+// @Param				 X-Guest-Token	header		string	false	"guest token from /cart/guest"
+// @Produce      application/json
+// @Param        tags body PostLoginTwoFactorRequest true "Body Request"
+// @Router       /login/2fa [post]
+func (s *TwoFactorController) PostLoginTwoFactor(c *gin.Context) {
+	// bind data
+	var req PostLoginTwoFactorRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostLoginTwoFactor",
+	})
+
+	account, ok := s.challengeAccount(c, logCtx, req.ChallengeToken, auth.ChallengeTwoFactor)
+	if !ok {
+		return
+	}
+
+	if err := verifySecondFactor(s.db.WithContext(c), account, req.Code, req.RecoveryCode); err != nil {
+		logCtx.WithField("reason", err).Error("error verify two factor")
+		if errors.Is(err, errInvalidTwoFactorCode) {
+			s.challengeFailed(c, logCtx, account)
+			return
+		}
+		apperror.Abort(c, err)
+		return
+	}
+
+	tokenPair, ok := s.finishLogin(c, logCtx, account)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenPair)
+}
+
+// LoginEnrollTwoFactor	goDocs
+// @Summary      enroll two factor during login
+// @Description  start the mandatory two factor enrollment with the challenge token of /login, returns the TOTP secret and its provisioning uri
+// @Tags         Account
+// @Produce      application/json
+// @Param        tags body PostLoginEnrollTwoFactorRequest true "Body Request"
+// @Router       /login/2fa/enroll [post]
+func (s *TwoFactorController) PostLoginEnrollTwoFactor(c *gin.Context) {
+	// bind data
+	var req PostLoginEnrollTwoFactorRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostLoginEnrollTwoFactor",
+	})
+
+	account, ok := s.challengeAccount(c, logCtx, req.ChallengeToken, auth.ChallengeTwoFactorEnroll)
+	if !ok {
+		return
+	}
+
+	enrollment, err := enrollTwoFactor(s.db.WithContext(c), s.config, account)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error enroll two factor")
+		apperror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    enrollment,
+	})
+}
+
+// LoginConfirmTwoFactor	goDocs
+// @Summary      confirm two factor enrollment during login
+// @Description  enable two factor authentication with the challenge token of /login and a code of the authenticator app, returns a JWT token, a refresh token and the recovery codes
+// @Tags         Account
+// @Param				 X-Guest-Token	header		string	false	"guest token from /cart/guest"
+// @Produce      application/json
+// @Param        tags body PostLoginConfirmTwoFactorRequest true "Body Request"
+// @Router       /login/2fa/enroll/confirm [post]
+func (s *TwoFactorController) PostLoginConfirmTwoFactor(c *gin.Context) {
+	// bind data
+	var req PostLoginConfirmTwoFactorRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostLoginConfirmTwoFactor",
+	})
+
+	account, ok := s.challengeAccount(c, logCtx, req.ChallengeToken, auth.ChallengeTwoFactorEnroll)
+	if !ok {
+		return
+	}
+
+	recoveryCodes, err := confirmTwoFactor(s.db.WithContext(c), account, req.Code)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error confirm two factor")
+		if errors.Is(err, errInvalidTwoFactorCode) {
+			s.challengeFailed(c, logCtx, account)
+			return
+		}
+		apperror.Abort(c, err)
+		return
+	}
+
+	tokenPair, ok := s.finishLogin(c, logCtx, account)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, TwoFactorLoginEnrollResponse{
+		TokenPair:     tokenPair,
+		RecoveryCodes: recoveryCodes,
+	})
+}
+
+// challengeAccount loads the account of a challenge token, a locked login stays locked during the challenge
+func (s *TwoFactorController) challengeAccount(c *gin.Context, logCtx *log.Entry, challengeToken string, purpose string) (model.Account, bool) {
+	accountId, err := s.tokens.ValidateChallenge(challengeToken, purpose)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error validate challenge")
+		apperror.Abort(c, apperror.As(err, auth.ErrInvalidChallenge))
+		return model.Account{}, false
+	}
+
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneById(accountId)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, auth.ErrInvalidChallenge)
+		return model.Account{}, false
+	}
+
+	lockedFor, err := s.throttle.LockedFor(c.Request.Context(), account.Email, c.ClientIP())
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error check login lockout")
+		apperror.Abort(c, apperror.ErrInternal)
+		return model.Account{}, false
+	}
+	if lockedFor > 0 {
+		logCtx.WithField("reason", auth.ErrLoginLocked).Error("error login")
+		abortLoginLocked(c, lockedFor)
+		return model.Account{}, false
+	}
+
+	return account, true
+}
+
+// challengeFailed counts a wrong code like a wrong password
+func (s *TwoFactorController) challengeFailed(c *gin.Context, logCtx *log.Entry, account model.Account) {
+	lockout, err := s.throttle.Fail(c.Request.Context(), account.Email, c.ClientIP())
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error count failed login")
+	}
+	if lockout > 0 {
+		abortLoginLocked(c, lockout)
+		return
+	}
+	apperror.Abort(c, errTwoFactorLoginFailed)
+}
+
+func (s *TwoFactorController) finishLogin(c *gin.Context, logCtx *log.Entry, account model.Account) (auth.TokenPair, bool) {
+	if err := s.throttle.Succeed(c.Request.Context(), account.Email); err != nil {
+		logCtx.WithField("reason", err).Error("error reset failed logins")
+	}
+
+	tokenPair, err := s.tokens.Issue(account)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error generate jwt")
+		apperror.Abort(c, err)
+		return auth.TokenPair{}, false
+	}
+
+	mergeRequestGuestCart(c, logCtx, s.db.WithContext(c), s.jwtSecret, account)
+
+	return tokenPair, true
+}
+
+// twoFactorChallenge returns the challenge a login has to pass before getting tokens, nil when there is none
+func twoFactorChallenge(tokens *auth.TokenService, config TwoFactorConfig, account model.Account) (*TwoFactorChallengeResponse, error) {
+	purpose := ""
+	switch {
+	case account.TotpEnabledAt != nil:
+		purpose = auth.ChallengeTwoFactor
+	case config.RequiredForAdmin && account.Type == "ADMIN":
+		purpose = auth.ChallengeTwoFactorEnroll
+	default:
+		return nil, nil
+	}
+
+	challengeToken, err := tokens.IssueChallenge(account, purpose, config.ChallengeTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &TwoFactorChallengeResponse{
+		ChallengeToken:     challengeToken,
+		ExpiresIn:          int64(config.ChallengeTTL.Seconds()),
+		EnrollmentRequired: purpose == auth.ChallengeTwoFactorEnroll,
+	}, nil
+}
+
+// enrollTwoFactor stores a new secret, replacing one of an enrollment never confirmed
+func enrollTwoFactor(db *gorm.DB, config TwoFactorConfig, account model.Account) (TwoFactorEnrollmentResponse, error) {
+	if account.TotpEnabledAt != nil {
+		return TwoFactorEnrollmentResponse{}, errTwoFactorEnabled
+	}
+
+	secret, err := auth.GenerateTotpSecret()
+	if err != nil {
+		return TwoFactorEnrollmentResponse{}, err
+	}
+
+	accountRepo := repository.NewAccountRepository(db)
+	if result := accountRepo.UpdateTotpSecret(int(account.ID), &secret, account.Email); result.Error != nil {
+		return TwoFactorEnrollmentResponse{}, result.Error
+	}
+
+	return TwoFactorEnrollmentResponse{
+		Secret:          secret,
+		ProvisioningUri: auth.TotpProvisioningUri(config.Issuer, account.Email, secret),
+	}, nil
+}
+
+// confirmTwoFactor enables two factor authentication and returns the recovery codes in plain text
+func confirmTwoFactor(db *gorm.DB, account model.Account, code string) ([]string, error) {
+	if account.TotpEnabledAt != nil {
+		return nil, errTwoFactorEnabled
+	}
+	if account.TotpSecret == nil {
+		return nil, errTwoFactorNotEnrolled
+	}
+	step, ok := auth.ValidateTotp(*account.TotpSecret, code, account.TotpLastStep, time.Now())
+	if !ok {
+		return nil, errInvalidTwoFactorCode
+	}
+
+	var recoveryCodes []string
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		accountRepo := repository.NewAccountRepository(tx)
+		result := accountRepo.EnableTotp(int(account.ID), account.Email)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errTwoFactorEnabled
+		}
+		if err := useTotpStep(tx, account, step); err != nil {
+			return err
+		}
+
+		var err error
+		recoveryCodes, err = replaceRecoveryCodes(tx, account)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return recoveryCodes, nil
+}
+
+// verifySecondFactor checks a TOTP code, or uses up a recovery code when no code is given
+func verifySecondFactor(db *gorm.DB, account model.Account, code string, recoveryCode string) error {
+	if account.TotpEnabledAt == nil || account.TotpSecret == nil {
+		return errTwoFactorNotEnrolled
+	}
+
+	if code != "" {
+		step, ok := auth.ValidateTotp(*account.TotpSecret, code, account.TotpLastStep, time.Now())
+		if !ok {
+			return errInvalidTwoFactorCode
+		}
+		return useTotpStep(db, account, step)
+	}
+
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db)
+	stored, result := recoveryCodeRepo.OneActiveByAccountIdAndCodeHash(int(account.ID), util.HashToken(normalizeRecoveryCode(recoveryCode)))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errInvalidTwoFactorCode
+	}
+	result = recoveryCodeRepo.MarkUsed(int(stored.ID))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// useTotpStep stores the step of an accepted code, a concurrent request that used the same code loses
+func useTotpStep(db *gorm.DB, account model.Account, step int64) error {
+	result := repository.NewAccountRepository(db).UseTotpStep(int(account.ID), step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(db *gorm.DB, account model.Account) ([]string, error) {
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db)
+	if result := recoveryCodeRepo.DeleteByAccountId(int(account.ID)); result.Error != nil {
+		return nil, result.Error
+	}
+
+	recoveryCodes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		token, err := util.RandomToken(5)
+		if err != nil {
+			return nil, err
+		}
+		code := token[:5] + "-" + token[5:]
+		if _, result := recoveryCodeRepo.Create(model.RecoveryCode{
+			AccountID: account.ID,
+			CodeHash:  util.HashToken(normalizeRecoveryCode(code)),
+			CreatedBy: account.Email,
+		}); result.Error != nil {
+			return nil, result.Error
+		}
+		recoveryCodes = append(recoveryCodes, code)
+	}
+	return recoveryCodes, nil
+}
+
+// normalizeRecoveryCode accepts codes typed without dash or in upper case
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
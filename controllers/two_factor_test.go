@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TwoFactorNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_verifySecondFactor(t *testing.T) {
+	secret, err := auth.GenerateTotpSecret()
+	assert.NoError(t, err)
+	now := time.Now()
+	code, err := auth.TotpCode(secret, now)
+	assert.NoError(t, err)
+	step := now.Unix() / 30
+	enabledAt := now.Add(-time.Hour)
+
+	expectUseStep := func(mock sqlmock.Sqlmock, affected int64) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `accounts` SET `totp_last_step`=?,`updated_at`=? WHERE (id = ? AND totp_last_step < ?)")).
+			WithArgs(step, sqlmock.AnyArg(), 1, step).
+			WillReturnResult(sqlmock.NewResult(0, affected))
+		mock.ExpectCommit()
+	}
+
+	tests := []struct {
+		name     string
+		lastStep int64
+		mock     func(mock sqlmock.Sqlmock)
+		wantErr  error
+	}{
+		{
+			name:     "Accept a new code",
+			lastStep: step - 1,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectUseStep(mock, 1)
+			},
+		},
+		{
+			name:     "Refuse a replayed code",
+			lastStep: step,
+			mock:     func(mock sqlmock.Sqlmock) {},
+			wantErr:  errInvalidTwoFactorCode,
+		},
+		{
+			// another request used the same code between reading the account and storing the step
+			name:     "Refuse a code used concurrently",
+			lastStep: step - 1,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectUseStep(mock, 0)
+			},
+			wantErr: errInvalidTwoFactorCode,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := TwoFactorNewMockDB()
+			tt.mock(mock)
+
+			account := model.Account{ID: 1, Email: "email@mail.com", TotpSecret: &secret, TotpEnabledAt: &enabledAt, TotpLastStep: tt.lastStep}
+			assert.Equal(t, tt.wantErr, verifySecondFactor(db, account, code, ""))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
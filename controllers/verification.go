@@ -0,0 +1,399 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/jobs"
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/logging"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type PostConfirmEmailVerificationRequest struct {
+	Token string `json:"token"  validate:"required"`
+}
+
+type PostConfirmPhoneVerificationRequest struct {
+	Code string `json:"code"  validate:"required"`
+}
+
+// VerificationConfig limits codes per account, MaxAttempts wrong sms codes and DailyLimit codes or links
+// of a channel within verificationWindow whatever code they were sent with, and ResendCooldown between two
+type VerificationConfig struct {
+	EmailUrl       string
+	EmailTTL       time.Duration
+	CodeTTL        time.Duration
+	MaxAttempts    int
+	ResendCooldown time.Duration
+	DailyLimit     int
+}
+
+const verificationWindow = 24 * time.Hour
+
+var (
+	errInvalidVerification  = apperror.New(apperror.Invalid, "invalid_verification", "invalid or expired code")
+	errVerificationAttempts = apperror.New(apperror.TooManyRequests, "verification_attempts", "too many wrong codes, try again tomorrow")
+	errVerificationCooldown = apperror.New(apperror.TooManyRequests, "verification_cooldown", "code sent recently, wait before requesting a new one")
+	errVerificationLimit    = apperror.New(apperror.TooManyRequests, "verification_limit", "too many codes requested, try again tomorrow")
+	errEmailVerified        = apperror.New(apperror.PreconditionFailed, "email_verified", "email already verified")
+	errPhoneNumberVerified  = apperror.New(apperror.PreconditionFailed, "phone_number_verified", "phone number already verified")
+	errPhoneNumberMissing   = apperror.New(apperror.PreconditionFailed, "phone_number_missing", "no phone number to verify, set one in the profile first")
+)
+
+type VerificationController struct {
+	db        *gorm.DB
+	validator *util.Validator
+	config    VerificationConfig
+}
+
+func NewVerificationController(db *gorm.DB, validator *util.Validator, config VerificationConfig) *VerificationController {
+	return &VerificationController{
+		db:        db,
+		validator: validator,
+		config:    config,
+	}
+}
+
+// SendEmailVerification	goDocs
+// @Summary      send email verification link
+// @Description  email a new verification link to own email, earlier links stop working, 429 with retry_after while the previous link was sent too recently or when too many links were sent today, need credentials
+// @Tags         Verification
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /verification/email [post]
+func (s *VerificationController) PostSendEmailVerification(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostSendEmailVerification",
+	})
+
+	account, ok := s.account(c, logCtx)
+	if !ok {
+		return
+	}
+	if account.EmailVerifiedAt != nil {
+		logCtx.WithField("reason", "email verified").Error("error send email verification")
+		apperror.Abort(c, errEmailVerified)
+		return
+	}
+	if _, ok := s.usage(c, logCtx, account, "EMAIL"); !ok {
+		return
+	}
+
+	if err := sendEmailVerification(s.db.WithContext(c), s.config, account); err != nil {
+		logCtx.WithField("reason", err).Error("error send email verification")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+	})
+}
+
+// ConfirmEmailVerification	goDocs
+// @Summary      confirm email verification
+// @Description  verify the email with the token from the verification link
+// @Tags         Verification
+// @Produce      application/json
+// @Param        tags body PostConfirmEmailVerificationRequest true "Body Request"
+// @Router       /verification/email/confirm [post]
+func (s *VerificationController) PostConfirmEmailVerification(c *gin.Context) {
+	// bind data
+	var req PostConfirmEmailVerificationRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostConfirmEmailVerification",
+	})
+
+	verificationRepo := repository.NewVerificationRepository(s.db.WithContext(c))
+	verification, result := verificationRepo.OneActiveByCodeHashAndChannel(util.HashToken(req.Token), "EMAIL")
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error find verification")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+	if result.RowsAffected == 0 || time.Now().After(verification.ExpiredAt) {
+		logCtx.WithField("reason", errInvalidVerification).Error("error confirm email verification")
+		apperror.Abort(c, errInvalidVerification)
+		return
+	}
+
+	if err := s.confirm(c, verification); err != nil {
+		logCtx.WithField("reason", err).Error("error confirm email verification")
+		apperror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+	})
+}
+
+// SendPhoneVerification	goDocs
+// @Summary      send phone verification code
+// @Description  send a new one time code by sms to own phone number, earlier codes stop working, 429 with retry_after while the previous code was sent too recently or when too many codes or wrong codes were sent today, need credentials
+// @Tags         Verification
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /verification/phone [post]
+func (s *VerificationController) PostSendPhoneVerification(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostSendPhoneVerification",
+	})
+
+	account, ok := s.account(c, logCtx)
+	if !ok {
+		return
+	}
+	if account.PhoneVerifiedAt != nil {
+		logCtx.WithField("reason", "phone verified").Error("error send phone verification")
+		apperror.Abort(c, errPhoneNumberVerified)
+		return
+	}
+	if account.PhoneNumber == nil {
+		logCtx.WithField("reason", "no phone number").Error("error send phone verification")
+		apperror.Abort(c, errPhoneNumberMissing)
+		return
+	}
+
+	usage, ok := s.usage(c, logCtx, account, "PHONE")
+	if !ok {
+		return
+	}
+	// a new code does not reset the wrong codes tried, sending one when they are used up only costs an sms
+	if usage.Attempts >= s.config.MaxAttempts {
+		logCtx.WithField("reason", errVerificationAttempts).Error("error send phone verification")
+		apperror.Abort(c, errVerificationAttempts)
+		return
+	}
+
+	code, err := util.RandomDigits(6)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error generate code")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+	if err := createVerification(s.db.WithContext(c), account, "PHONE", *account.PhoneNumber, code, s.config.CodeTTL); err != nil {
+		logCtx.WithField("reason", err).Error("error create verification")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	text := fmt.Sprintf("Your verification code is %s, valid for %d minutes.", code, int(s.config.CodeTTL.Minutes()))
+	if err := jobs.Dispatch(jobs.NewSendSMSJob(*account.PhoneNumber, text)); err != nil {
+		logCtx.WithField("reason", err).Error("error dispatch sms")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+	})
+}
+
+// ConfirmPhoneVerification	goDocs
+// @Summary      confirm phone verification
+// @Description  verify own phone number with the code sent by sms, after too many wrong codes in a day no code works until the next day, need credentials
+// @Tags         Verification
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Param        tags body PostConfirmPhoneVerificationRequest true "Body Request"
+// @Router       /verification/phone/confirm [post]
+func (s *VerificationController) PostConfirmPhoneVerification(c *gin.Context) {
+	// bind data
+	var req PostConfirmPhoneVerificationRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostConfirmPhoneVerification",
+	})
+
+	account, ok := s.account(c, logCtx)
+	if !ok {
+		return
+	}
+
+	verificationRepo := repository.NewVerificationRepository(s.db.WithContext(c))
+	verification, result := verificationRepo.OneLatestActiveByAccountIdAndChannel(int(account.ID), "PHONE")
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error find verification")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+	if result.RowsAffected == 0 || time.Now().After(verification.ExpiredAt) {
+		logCtx.WithField("reason", errInvalidVerification).Error("error confirm phone verification")
+		apperror.Abort(c, errInvalidVerification)
+		return
+	}
+	usage, result := verificationRepo.UsageByAccountIdAndChannelSince(int(account.ID), "PHONE", time.Now().Add(-verificationWindow))
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error find verification usage")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+	if usage.Attempts >= s.config.MaxAttempts {
+		logCtx.WithField("reason", errVerificationAttempts).Error("error confirm phone verification")
+		apperror.Abort(c, errVerificationAttempts)
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(util.HashToken(req.Code)), []byte(verification.CodeHash)) != 1 {
+		if result := verificationRepo.IncrementAttempts(int(verification.ID)); result.Error != nil {
+			logCtx.WithField("reason", result.Error).Error("error update verification")
+		}
+		logCtx.WithField("reason", "wrong code").Error("error confirm phone verification")
+		apperror.Abort(c, errInvalidVerification)
+		return
+	}
+
+	if err := s.confirm(c, verification); err != nil {
+		logCtx.WithField("reason", err).Error("error confirm phone verification")
+		apperror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+	})
+}
+
+// confirm uses up the code and verifies the email or phone number it was sent to
+func (s *VerificationController) confirm(c *gin.Context, verification model.Verification) error {
+	return s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		verificationRepo := repository.NewVerificationRepository(tx)
+		result := verificationRepo.MarkUsed(int(verification.ID))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvalidVerification
+		}
+
+		accountRepo := repository.NewAccountRepository(tx)
+		if verification.Channel == "EMAIL" {
+			result = accountRepo.MarkEmailVerified(int(verification.AccountID), verification.Target)
+		} else {
+			result = accountRepo.MarkPhoneVerified(int(verification.AccountID), verification.Target)
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvalidVerification
+		}
+		return nil
+	})
+}
+
+func (s *VerificationController) account(c *gin.Context, logCtx *log.Entry) (model.Account, bool) {
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return model.Account{}, false
+	}
+	return account, true
+}
+
+// usage is what the account was sent on the channel within verificationWindow, the request is aborted
+// when the ResendCooldown or DailyLimit does not allow sending another code or link
+func (s *VerificationController) usage(c *gin.Context, logCtx *log.Entry, account model.Account, channel string) (repository.VerificationUsage, bool) {
+	verificationRepo := repository.NewVerificationRepository(s.db.WithContext(c))
+	usage, result := verificationRepo.UsageByAccountIdAndChannelSince(int(account.ID), channel, time.Now().Add(-verificationWindow))
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error find verification usage")
+		apperror.Abort(c, apperror.ErrInternal)
+		return usage, false
+	}
+	if usage.LastSentAt != nil {
+		if wait := time.Until(usage.LastSentAt.Add(s.config.ResendCooldown)); wait > 0 {
+			logCtx.WithField("reason", errVerificationCooldown).Error("error send verification")
+			abortRetryAfter(c, errVerificationCooldown, wait)
+			return usage, false
+		}
+	}
+	if usage.Sent >= s.config.DailyLimit {
+		logCtx.WithField("reason", errVerificationLimit).Error("error send verification")
+		apperror.Abort(c, errVerificationLimit)
+		return usage, false
+	}
+	return usage, true
+}
+
+// sendEmailVerification emails a verification link, earlier links of the account stop working
+func sendEmailVerification(db *gorm.DB, config VerificationConfig, account model.Account) error {
+	token, err := util.RandomToken(32)
+	if err != nil {
+		return err
+	}
+	if err := createVerification(db, account, "EMAIL", account.Email, token, config.EmailTTL); err != nil {
+		return err
+	}
+
+	link := config.EmailUrl + "?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("<p>Hi %s,</p><p>Use <a href=\"%s\">this link</a> to verify your email, it expires in %d hours.</p>",
+		html.EscapeString(account.Name), html.EscapeString(link), int(config.EmailTTL.Hours()))
+	return jobs.Dispatch(jobs.NewSendEmailJob(account.Email, "Verify your email", body))
+}
+
+func createVerification(db *gorm.DB, account model.Account, channel string, target string, code string, ttl time.Duration) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		verificationRepo := repository.NewVerificationRepository(tx)
+		if result := verificationRepo.MarkUsedByAccountIdAndChannel(int(account.ID), channel); result.Error != nil {
+			return result.Error
+		}
+		if _, result := verificationRepo.Create(model.Verification{
+			AccountID: account.ID,
+			Channel:   channel,
+			Target:    target,
+			CodeHash:  util.HashToken(code),
+			ExpiredAt: time.Now().Add(ttl),
+			CreatedBy: account.Email,
+		}); result.Error != nil {
+			return result.Error
+		}
+		return nil
+	})
+}
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func VerificationNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_VerificationLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	config := VerificationConfig{
+		CodeTTL:        10 * time.Minute,
+		MaxAttempts:    5,
+		ResendCooldown: time.Minute,
+		DailyLimit:     5,
+	}
+
+	expectUsage := func(mock sqlmock.Sqlmock, channel string, sent int, attempts int, lastSentAt time.Time) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE email = ?")).
+			WithArgs("email@mail.com").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "phone_number"}).AddRow(1, "email@mail.com", "+620000"))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) AS sent, COALESCE(SUM(attempts), 0) AS attempts, MAX(created_at) AS last_sent_at FROM `verifications`")).
+			WithArgs(1, channel, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"sent", "attempts", "last_sent_at"}).AddRow(sent, attempts, lastSentAt))
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		mock           func(mock sqlmock.Sqlmock)
+		wantCode       string
+		wantRetryAfter bool
+	}{
+		{
+			name: "Resend too soon",
+			path: "/verification/phone",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectUsage(mock, "PHONE", 1, 0, time.Now().Add(-10*time.Second))
+			},
+			wantCode:       "verification_cooldown",
+			wantRetryAfter: true,
+		},
+		{
+			name: "Daily limit reached",
+			path: "/verification/phone",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectUsage(mock, "PHONE", 5, 0, time.Now().Add(-time.Hour))
+			},
+			wantCode: "verification_limit",
+		},
+		{
+			name: "Wrong codes used up",
+			path: "/verification/phone",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectUsage(mock, "PHONE", 2, 5, time.Now().Add(-time.Hour))
+			},
+			wantCode: "verification_attempts",
+		},
+		{
+			name: "Resend email link too soon",
+			path: "/verification/email",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectUsage(mock, "EMAIL", 1, 0, time.Now().Add(-10*time.Second))
+			},
+			wantCode:       "verification_cooldown",
+			wantRetryAfter: true,
+		},
+		{
+			name: "Daily email link limit reached",
+			path: "/verification/email",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectUsage(mock, "EMAIL", 5, 0, time.Now().Add(-time.Hour))
+			},
+			wantCode: "verification_limit",
+		},
+		{
+			// a fresh code has no attempts of its own, the wrong codes of earlier codes still count
+			name: "Wrong codes used up with a new code",
+			path: "/verification/phone/confirm",
+			body: `{"code":"123456"}`,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE email = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "email@mail.com"))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `verifications` WHERE (account_id = ? AND channel = ? AND used_at IS NULL)")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "channel", "code_hash", "attempts", "expired_at"}).
+						AddRow(3, 1, "PHONE", util.HashToken("123456"), 0, time.Now().Add(time.Minute)))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) AS sent, COALESCE(SUM(attempts), 0) AS attempts, MAX(created_at) AS last_sent_at FROM `verifications`")).
+					WillReturnRows(sqlmock.NewRows([]string{"sent", "attempts", "last_sent_at"}).AddRow(3, 5, time.Now()))
+			},
+			wantCode: "verification_attempts",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := VerificationNewMockDB()
+			tt.mock(mock)
+
+			s := NewVerificationController(db, util.ValidatorTranslate(), config)
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "email@mail.com")
+			})
+			router.POST("/verification/email", s.PostSendEmailVerification)
+			router.POST("/verification/phone", s.PostSendPhoneVerification)
+			router.POST("/verification/phone/confirm", s.PostConfirmPhoneVerification)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			var body apperror.Response
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, http.StatusTooManyRequests, w.Code)
+			assert.Equal(t, tt.wantCode, body.Error.Code)
+			assert.Equal(t, tt.wantRetryAfter, w.Header().Get("Retry-After") != "")
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,670 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/logging"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type PostCreateWishlistRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+type PutEditWishlistRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+type PostAddWishlistItemRequest struct {
+	ItemId int `json:"item_id" validate:"required"`
+}
+
+type PostMoveWishlistItemToCartRequest struct {
+	Qty int `json:"qty" validate:"required,gt=0"`
+}
+
+type SharedWishlistResponse struct {
+	Name  string       `json:"name"`
+	Items []model.Item `json:"items"`
+}
+
+var (
+	errWishlistNotFound  = apperror.New(apperror.NotFound, "wishlist_not_found", "wishlist not found")
+	errWishlistNameTaken = apperror.New(apperror.Conflict, "wishlist_name_taken", "wishlist name already used")
+	errItemInWishlist    = apperror.New(apperror.Conflict, "item_in_wishlist", "item already in wishlist")
+	errItemNotInWishlist = apperror.New(apperror.NotFound, "item_not_in_wishlist", "item not in wishlist")
+)
+
+type WishlistController struct {
+	db        *gorm.DB
+	validator *util.Validator
+}
+
+func NewWishlistController(db *gorm.DB, validator *util.Validator) *WishlistController {
+	return &WishlistController{
+		db:        db,
+		validator: validator,
+	}
+}
+
+// GetAllWishlists	goDocs
+// @Summary      get all own wishlists
+// @Description  get all own wishlists with their items, need credentials
+// @Tags         Wishlist
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /wishlist/all [get]
+func (s *WishlistController) GetWishlists(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "GetWishlists",
+	})
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	wishlistRepo := repository.NewWishlistRepository(s.db.WithContext(c))
+	wishlist, result := wishlistRepo.AllByAccountId(int(account.ID), "WishlistItem.Item")
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error find wishlist")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    wishlist,
+	})
+}
+
+// GetOneWishlistDetail	goDocs
+// @Summary      get one own wishlist detail
+// @Description  get one own wishlist detail with its items, need credentials
+// @Tags         Wishlist
+// @Param				 id path int true "get detail by id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /wishlist/{id} [get]
+func (s *WishlistController) GetWishlistDetail(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "GetWishlist",
+	})
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	idS := c.Param("id")
+	id, err := strconv.Atoi(idS)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error parse id")
+		apperror.Abort(c, errInvalidId)
+		return
+	}
+
+	wishlistRepo := repository.NewWishlistRepository(s.db.WithContext(c))
+	wishlist, result := wishlistRepo.OneByIdAndAccountId(id, int(account.ID), "WishlistItem.Item")
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find wishlist")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find wishlist")
+		apperror.Abort(c, errWishlistNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    wishlist,
+		"message": "Sucess!",
+	})
+}
+
+// AddWishlist	goDocs
+// @Summary      create own named wishlist
+// @Description  create own named wishlist, names are unique per account and a used name answers 409, need credentials
+// @Tags         Wishlist
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PostCreateWishlistRequest true "Body Request"
+// @Produce      application/json
+// @Router       /wishlist [post]
+func (s *WishlistController) PostCreateWishlist(c *gin.Context) {
+	// bind data
+	var req PostCreateWishlistRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostCreateWishlist",
+	})
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	wishlistRepo := repository.NewWishlistRepository(s.db.WithContext(c))
+	wishlist, result := wishlistRepo.Create(model.Wishlist{
+		AccountID: account.ID,
+		Name:      req.Name,
+	})
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error create wishlist")
+		abortWishlistWrite(c, result.Error, errWishlistNameTaken)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    wishlist,
+		"message": "Sucess!",
+	})
+}
+
+// EditWishlist	goDocs
+// @Summary      rename own wishlist
+// @Description  rename own wishlist, a name used by another own wishlist answers 409, need credentials
+// @Tags         Wishlist
+// @Param				 id path int true "edit by id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PutEditWishlistRequest true "Body Request"
+// @Produce      application/json
+// @Router       /wishlist/{id} [put]
+func (s *WishlistController) PutEditWishlist(c *gin.Context) {
+	// bind data
+	var req PutEditWishlistRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PutEditWishlist",
+	})
+
+	wishlist, ok := s.ownWishlist(c, logCtx)
+	if !ok {
+		return
+	}
+
+	wishlistRepo := repository.NewWishlistRepository(s.db.WithContext(c))
+	wishlist, result := wishlistRepo.Update(int(wishlist.ID), model.Wishlist{
+		Name: req.Name,
+	})
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error update wishlist")
+		abortWishlistWrite(c, result.Error, errWishlistNameTaken)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data":    wishlist,
+	})
+}
+
+// DeleteWishlist	goDocs
+// @Summary      delete own wishlist
+// @Description  delete own wishlist and its items, need credentials
+// @Tags         Wishlist
+// @Param				 id path int true "delete by id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /wishlist/{id} [delete]
+func (s *WishlistController) DeleteWishlist(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "DeleteWishlist",
+	})
+
+	wishlist, ok := s.ownWishlist(c, logCtx)
+	if !ok {
+		return
+	}
+
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		wishlistItemRepo := repository.NewWishlistItemRepository(tx)
+		if result := wishlistItemRepo.DeleteByWishlistId(int(wishlist.ID), true); result.Error != nil {
+			return result.Error
+		}
+		wishlistRepo := repository.NewWishlistRepository(tx)
+		if result := wishlistRepo.Delete(int(wishlist.ID), true); result.Error != nil {
+			return result.Error
+		}
+		return nil
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error delete wishlist")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+	})
+}
+
+// AddWishlistItem	goDocs
+// @Summary      add item to own wishlist
+// @Description  add item to own wishlist, need credentials
+// @Tags         Wishlist
+// @Param				 id path int true "wishlist id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PostAddWishlistItemRequest true "Body Request"
+// @Produce      application/json
+// @Router       /wishlist/{id}/item [post]
+func (s *WishlistController) PostAddWishlistItem(c *gin.Context) {
+	// bind data
+	var req PostAddWishlistItemRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostAddWishlistItem",
+	})
+
+	wishlist, ok := s.ownWishlist(c, logCtx)
+	if !ok {
+		return
+	}
+
+	itemRepo := repository.NewItemRepository(s.db.WithContext(c))
+	item, result := itemRepo.OneById(req.ItemId)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find item")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find item")
+		apperror.Abort(c, errItemNotFound)
+		return
+	}
+
+	wishlistItemRepo := repository.NewWishlistItemRepository(s.db.WithContext(c))
+	if _, result := wishlistItemRepo.OneByWishlistIdAndItemId(int(wishlist.ID), int(item.ID)); result.RowsAffected > 0 {
+		logCtx.WithField("reason", "item exists").Error("error add wishlist item")
+		apperror.Abort(c, errItemInWishlist)
+		return
+	}
+
+	wishlistItem, result := wishlistItemRepo.Create(model.WishlistItem{
+		WishlistID: wishlist.ID,
+		ItemID:     item.ID,
+	})
+	if result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error create wishlist item")
+		abortWishlistWrite(c, result.Error, errItemInWishlist)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    wishlistItem,
+		"message": "Sucess!",
+	})
+}
+
+// DeleteWishlistItem	goDocs
+// @Summary      remove item from own wishlist
+// @Description  remove item from own wishlist, need credentials
+// @Tags         Wishlist
+// @Param				 id path int true "wishlist id"
+// @Param				 item_id path int true "item id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /wishlist/{id}/item/{item_id} [delete]
+func (s *WishlistController) DeleteWishlistItem(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "DeleteWishlistItem",
+	})
+
+	wishlist, ok := s.ownWishlist(c, logCtx)
+	if !ok {
+		return
+	}
+
+	wishlistItem, ok := s.ownWishlistItem(c, logCtx, wishlist)
+	if !ok {
+		return
+	}
+
+	wishlistItemRepo := repository.NewWishlistItemRepository(s.db.WithContext(c))
+	if result := wishlistItemRepo.Delete(int(wishlistItem.ID), true); result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error delete wishlist item")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+	})
+}
+
+// MoveWishlistItemToCart	goDocs
+// @Summary      move item from own wishlist to cart
+// @Description  move item from own wishlist to cart, failed when qty item < qty, need credentials
+// @Tags         Wishlist
+// @Param				 id path int true "wishlist id"
+// @Param				 item_id path int true "item id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Param        tags body PostMoveWishlistItemToCartRequest true "Body Request"
+// @Produce      application/json
+// @Router       /wishlist/{id}/item/{item_id}/cart [post]
+func (s *WishlistController) PostMoveWishlistItemToCart(c *gin.Context) {
+	// bind data
+	var req PostMoveWishlistItemToCartRequest
+	if err := c.ShouldBind(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("error Binding")
+		apperror.Abort(c, apperror.InvalidBody(err))
+		return
+	}
+	// validate
+	if err := s.validator.Validate.Struct(&req); err != nil {
+		logging.FromContext(c).WithField("reason", err).Error("invalid Request")
+		apperror.Abort(c, apperror.Validation(err, s.validator.Trans))
+		return
+	}
+
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostMoveWishlistItemToCart",
+	})
+
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return
+	}
+
+	wishlist, ok := s.ownWishlist(c, logCtx)
+	if !ok {
+		return
+	}
+
+	wishlistItem, ok := s.ownWishlistItem(c, logCtx, wishlist)
+	if !ok {
+		return
+	}
+
+	var cart model.Cart
+	if err := s.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		var err error
+		cart, err = addItemToCart(tx, cartOwner{accountId: int(account.ID)}, int(wishlistItem.ItemID), req.Qty, username)
+		if err != nil {
+			return err
+		}
+		wishlistItemRepo := repository.NewWishlistItemRepository(tx)
+		if result := wishlistItemRepo.Delete(int(wishlistItem.ID), true); result.Error != nil {
+			return result.Error
+		}
+		return nil
+	}); err != nil {
+		logCtx.WithField("reason", err).Error("error move wishlist item to cart")
+		apperror.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    cart,
+		"message": "Sucess!",
+	})
+}
+
+// ShareWishlist	goDocs
+// @Summary      create share link of own wishlist
+// @Description  create or regenerate a public read-only share token of own wishlist, need credentials
+// @Tags         Wishlist
+// @Param				 id path int true "wishlist id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /wishlist/{id}/share [post]
+func (s *WishlistController) PostShareWishlist(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "PostShareWishlist",
+	})
+
+	wishlist, ok := s.ownWishlist(c, logCtx)
+	if !ok {
+		return
+	}
+
+	token, err := util.RandomToken(24)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error generate share token")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	username := c.GetString("username")
+	wishlistRepo := repository.NewWishlistRepository(s.db.WithContext(c))
+	if result := wishlistRepo.UpdateShareToken(int(wishlist.ID), &token, username); result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error update share token")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+		"data": gin.H{
+			"share_token": token,
+			"path":        "/shared/wishlist/" + token,
+		},
+	})
+}
+
+// UnshareWishlist	goDocs
+// @Summary      revoke share link of own wishlist
+// @Description  revoke the public share token of own wishlist, need credentials
+// @Tags         Wishlist
+// @Param				 id path int true "wishlist id"
+// @Param				 Authorization	header		string	true	"Bearer {token}" default(Bearer {token})
+// @Produce      application/json
+// @Router       /wishlist/{id}/share [delete]
+func (s *WishlistController) DeleteShareWishlist(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "DeleteShareWishlist",
+	})
+
+	wishlist, ok := s.ownWishlist(c, logCtx)
+	if !ok {
+		return
+	}
+
+	username := c.GetString("username")
+	wishlistRepo := repository.NewWishlistRepository(s.db.WithContext(c))
+	if result := wishlistRepo.UpdateShareToken(int(wishlist.ID), nil, username); result.Error != nil {
+		logCtx.WithField("reason", result.Error).Error("error update share token")
+		apperror.Abort(c, apperror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sucess!",
+	})
+}
+
+// GetSharedWishlist	goDocs
+// @Summary      get shared wishlist
+// @Description  get a wishlist by its public share token, read-only and no credentials needed
+// @Tags         Wishlist
+// @Param				 token path string true "share token"
+// @Produce      application/json
+// @Router       /shared/wishlist/{token} [get]
+func (s *WishlistController) GetSharedWishlist(c *gin.Context) {
+	// log
+	logCtx := logging.FromContext(c).WithFields(log.Fields{
+		"api": "GetSharedWishlist",
+	})
+
+	wishlistRepo := repository.NewWishlistRepository(s.db.WithContext(c))
+	wishlist, result := wishlistRepo.OneByShareToken(c.Param("token"), "WishlistItem.Item")
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find wishlist")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find wishlist")
+		apperror.Abort(c, errWishlistNotFound)
+		return
+	}
+
+	// only expose the name and items, never the owner
+	res := SharedWishlistResponse{
+		Name:  wishlist.Name,
+		Items: []model.Item{},
+	}
+	for _, v := range wishlist.WishlistItem {
+		if v.Item != nil {
+			res.Items = append(res.Items, *v.Item)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    res,
+		"message": "Sucess!",
+	})
+}
+
+// abortWishlistWrite answers a unique index violation with conflict, names and items are unique per wishlist
+// and a concurrent request can take them between the check and the write
+func abortWishlistWrite(c *gin.Context, err error, conflict error) {
+	if repository.IsDuplicateKey(err) {
+		apperror.Abort(c, conflict)
+		return
+	}
+	apperror.Abort(c, apperror.ErrInternal)
+}
+
+// ownWishlist finds the wishlist from path id owned by the logged in account
+func (s *WishlistController) ownWishlist(c *gin.Context, logCtx *log.Entry) (model.Wishlist, bool) {
+	username := c.GetString("username")
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(c))
+	account, result := accountRepo.OneByEmail(username)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find account")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find account")
+		apperror.Abort(c, errAccountNotFound)
+		return model.Wishlist{}, false
+	}
+
+	idS := c.Param("id")
+	id, err := strconv.Atoi(idS)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error parse id")
+		apperror.Abort(c, errInvalidId)
+		return model.Wishlist{}, false
+	}
+
+	wishlistRepo := repository.NewWishlistRepository(s.db.WithContext(c))
+	wishlist, result := wishlistRepo.OneByIdAndAccountId(id, int(account.ID))
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find wishlist")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find wishlist")
+		apperror.Abort(c, errWishlistNotFound)
+		return model.Wishlist{}, false
+	}
+
+	return wishlist, true
+}
+
+// ownWishlistItem finds the wishlist item from path item_id inside the given wishlist
+func (s *WishlistController) ownWishlistItem(c *gin.Context, logCtx *log.Entry, wishlist model.Wishlist) (model.WishlistItem, bool) {
+	itemIdS := c.Param("item_id")
+	itemId, err := strconv.Atoi(itemIdS)
+	if err != nil {
+		logCtx.WithField("reason", err).Error("error parse item id")
+		apperror.Abort(c, errInvalidId)
+		return model.WishlistItem{}, false
+	}
+
+	wishlistItemRepo := repository.NewWishlistItemRepository(s.db.WithContext(c))
+	wishlistItem, result := wishlistItemRepo.OneByWishlistIdAndItemId(int(wishlist.ID), itemId)
+	if result.RowsAffected == 0 || result.Error != nil {
+		err := errors.New("error find wishlist item")
+		if result.Error != nil {
+			err = result.Error
+		}
+		logCtx.WithField("reason", err).Error("error find wishlist item")
+		apperror.Abort(c, errItemNotInWishlist)
+		return model.WishlistItem{}, false
+	}
+
+	return wishlistItem, true
+}
@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	gormMysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func WishlistNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(gormMysql.New(gormMysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_WishlistNameTaken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	duplicate := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry '1-Birthday' for key 'idx_wishlist_account_name_key'"}
+
+	expectAccount := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE email = ?")).
+			WithArgs("email@mail.com").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "email@mail.com"))
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		mock       func(mock sqlmock.Sqlmock)
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:   "Create with a used name",
+			method: http.MethodPost,
+			path:   "/wishlist",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectAccount(mock)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `wishlists`")).WillReturnError(duplicate)
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusConflict,
+			wantCode:   "wishlist_name_taken",
+		},
+		{
+			name:   "Rename to a used name",
+			method: http.MethodPut,
+			path:   "/wishlist/2",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectAccount(mock)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `wishlists` WHERE (id = ? AND account_id = ?)")).
+					WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "name"}).AddRow(2, 1, "Holiday"))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `wishlists` WHERE id = ?")).
+					WithArgs(2).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "name"}).AddRow(2, 1, "Holiday"))
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `wishlists`")).WillReturnError(duplicate)
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusConflict,
+			wantCode:   "wishlist_name_taken",
+		},
+		{
+			name:   "Other errors stay internal",
+			method: http.MethodPost,
+			path:   "/wishlist",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectAccount(mock)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `wishlists`")).WillReturnError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found"})
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   apperror.ErrInternal.Code,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := WishlistNewMockDB()
+			tt.mock(mock)
+
+			s := NewWishlistController(db, util.ValidatorTranslate())
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "email@mail.com")
+			})
+			router.POST("/wishlist", s.PostCreateWishlist)
+			router.PUT("/wishlist/:id", s.PutEditWishlist)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"name":"Birthday"}`))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			var body apperror.Response
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			assert.Equal(t, tt.wantCode, body.Error.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_WishlistMoveToCart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	expectWishlistItem := func(mock sqlmock.Sqlmock) {
+		for i := 0; i < 2; i++ {
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE email = ?")).
+				WithArgs("email@mail.com").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "email@mail.com"))
+		}
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `wishlists` WHERE (id = ? AND account_id = ?)")).
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "name"}).AddRow(2, 1, "Birthday"))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `wishlist_items` WHERE (wishlist_id = ? AND item_id = ?)")).
+			WithArgs(2, 7).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "wishlist_id", "item_id"}).AddRow(5, 2, 7))
+	}
+
+	tests := []struct {
+		name       string
+		mock       func(mock sqlmock.Sqlmock)
+		wantStatus int
+	}{
+		{
+			name: "Move into a new cart line",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectWishlistItem(mock)
+				mock.ExpectBegin()
+				expectItem(mock, 7, 10, 5)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE item_id = ? AND account_id = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `carts`")).WillReturnResult(sqlmock.NewResult(9, 1))
+				mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `wishlist_items` WHERE `wishlist_items`.`id` = ?")).
+					WithArgs(5).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			// the wishlist item stays when the cart refuses the qty
+			name: "Qty exceeds stock",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectWishlistItem(mock)
+				mock.ExpectBegin()
+				expectItem(mock, 7, 1, 5)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE item_id = ? AND account_id = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name: "Wishlist of another account",
+			mock: func(mock sqlmock.Sqlmock) {
+				for i := 0; i < 2; i++ {
+					mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE email = ?")).
+						WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "email@mail.com"))
+				}
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `wishlists` WHERE (id = ? AND account_id = ?)")).
+					WithArgs(2, 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := WishlistNewMockDB()
+			tt.mock(mock)
+
+			s := NewWishlistController(db, util.ValidatorTranslate())
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "email@mail.com")
+			})
+			router.POST("/wishlist/:id/item/:item_id/cart", s.PostMoveWishlistItemToCart)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/wishlist/2/item/7/cart", strings.NewReader(`{"qty":2}`))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code, w.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_GetSharedWishlist(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, mock := WishlistNewMockDB()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `wishlists` WHERE share_token = ?")).
+		WithArgs("token").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "name", "share_token"}).AddRow(2, 1, "Birthday", "token"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `wishlist_items` WHERE `wishlist_items`.`wishlist_id` = ?")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "wishlist_id", "item_id"}).AddRow(5, 2, 7))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE `items`.`id` = ?")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(7, "Book"))
+
+	s := NewWishlistController(db, util.ValidatorTranslate())
+	router := gin.New()
+	router.GET("/shared/wishlist/:token", s.GetSharedWishlist)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/shared/wishlist/token", nil))
+
+	// only the name and items are exposed, never the owner or the token
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "Birthday", body.Data["name"])
+	assert.Len(t, body.Data["items"], 1)
+	assert.NotContains(t, body.Data, "account_id")
+	assert.NotContains(t, body.Data, "share_token")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
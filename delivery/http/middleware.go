@@ -1,76 +1,191 @@
 package http
 
 import (
-	"errors"
 	"net/http"
+	"runtime/debug"
 	"strings"
 	"time"
 
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/audit"
+	"github.com/avarian/online-shopping-cart/service/auth"
+	"github.com/avarian/online-shopping-cart/service/logging"
+	"github.com/avarian/online-shopping-cart/util"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
-	"github.com/spf13/viper"
+	log "github.com/sirupsen/logrus"
 )
 
-type JWTClaim struct {
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Type     string `json:"type"`
-	jwt.StandardClaims
+const requestIdHeader = "X-Request-ID"
+
+var (
+	errNoAccessToken        = apperror.New(apperror.Unauthorized, "missing_access_token", "request does not contain an access token")
+	errNoAccessOrGuestToken = apperror.New(apperror.Unauthorized, "missing_access_token", "request does not contain an access token or guest token")
+	errRouteNotFound        = apperror.New(apperror.NotFound, "route_not_found", "route not found")
+	errApiKeyNotAllowed     = apperror.New(apperror.Forbidden, "api_key_not_allowed", "route is not available to api keys")
+)
+
+// RequestContext identifies the request and puts its id and client ip in the request context for the audit log,
+// and a log entry carrying the request id and route for the logs of the controllers. Auth adds the user to both
+func RequestContext() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		requestId := context.GetHeader(requestIdHeader)
+		if !validRequestId(requestId) {
+			requestId, _ = util.RandomToken(16)
+		}
+		context.Header(requestIdHeader, requestId)
+
+		ctx := audit.WithInfo(context.Request.Context(), audit.Info{
+			RequestID: requestId,
+			ClientIP:  context.ClientIP(),
+		})
+		ctx = logging.WithFields(ctx, log.Fields{
+			"request_id": requestId,
+			"route":      context.FullPath(),
+		})
+		context.Request = context.Request.WithContext(ctx)
+		context.Next()
+	}
 }
 
-func Auth() gin.HandlerFunc {
+// validRequestId accepts ids of proxies and clients that are safe to log and answer with
+func validRequestId(requestId string) bool {
+	if requestId == "" || len(requestId) > 64 {
+		return false
+	}
+	for _, r := range requestId {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// AccessLog logs every request once it is answered, with the fields of its log entry
+func AccessLog() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		start := time.Now()
+		context.Next()
+
+		status := context.Writer.Status()
+		logCtx := logging.FromContext(context.Request.Context()).WithFields(log.Fields{
+			"method":     context.Request.Method,
+			"path":       context.Request.URL.Path,
+			"status":     status,
+			"latency_ms": time.Since(start).Milliseconds(),
+			"bytes":      context.Writer.Size(),
+			"client_ip":  context.ClientIP(),
+			"user_agent": context.Request.UserAgent(),
+		})
+		switch {
+		case status >= http.StatusInternalServerError:
+			logCtx.Error("request")
+		case status >= http.StatusBadRequest:
+			logCtx.Warn("request")
+		default:
+			logCtx.Info("request")
+		}
+	}
+}
+
+// Recovery answers requests whose handler panicked as internal errors and logs the panic with its request
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(context *gin.Context, recovered interface{}) {
+		logging.FromContext(context.Request.Context()).WithFields(log.Fields{
+			"panic": recovered,
+			"stack": string(debug.Stack()),
+		}).Error("panic recovered")
+		apperror.Abort(context, apperror.ErrInternal)
+	})
+}
+
+// setActor names who acts in the request for the audit log and the request logs
+func setActor(context *gin.Context, actor string) {
+	ctx := audit.WithActor(context.Request.Context(), actor)
+	ctx = logging.WithFields(ctx, log.Fields{"user": actor})
+	context.Request = context.Request.WithContext(ctx)
+}
+
+// Auth rejects tokens of revoked sessions, suspending an account or forcing its password reset revokes all of them.
+// "Authorization: ApiKey {key}" authenticates as the owner of the key instead, with the permissions of the key,
+// routes without RequirePermission refuse it with HumanOnly
+func Auth(tokens *auth.TokenService) gin.HandlerFunc {
 	return func(context *gin.Context) {
 		authorization := context.GetHeader("Authorization")
 		if authorization == "" {
-			context.JSON(http.StatusUnauthorized, gin.H{"error": "request does not contain an access token"})
-			context.Abort()
+			apperror.Abort(context, errNoAccessToken)
 			return
 		}
-		_, tokenString, _ := strings.Cut(authorization, " ")
-		claims, err := validateToken(tokenString)
+		scheme, tokenString, _ := strings.Cut(authorization, " ")
+
+		var claims *auth.Claims
+		var err error
+		if strings.EqualFold(scheme, "ApiKey") {
+			claims, err = tokens.ValidateApiKey(context.Request.Context(), tokenString, context.ClientIP())
+		} else {
+			claims, err = tokens.Validate(context.Request.Context(), tokenString)
+		}
 		if err != nil {
-			context.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-			context.Abort()
+			apperror.Abort(context, apperror.As(err, apperror.ErrUnauthorized))
 			return
 		}
 
 		context.Set("username", claims.Username)
 		context.Set("type", claims.Type)
+		context.Set("session_id", claims.SessionID)
+		context.Set("permissions", claims.Permissions)
+		if claims.ApiKeyID != 0 {
+			context.Set("api_key_id", int(claims.ApiKeyID))
+		}
+		setActor(context, claims.Username)
 		context.Next()
 	}
 }
 
-func Admin() gin.HandlerFunc {
+// AuthOrGuest accepts a JWT like Auth, or falls back to a guest token signed with jwtSecret for anonymous carts
+func AuthOrGuest(tokens *auth.TokenService, jwtSecret string) gin.HandlerFunc {
+	authenticate := Auth(tokens)
 	return func(context *gin.Context) {
-		typeAccount := context.GetString("type")
-		if typeAccount != "ADMIN" {
-			context.JSON(http.StatusPreconditionFailed, gin.H{"error": "unauthorized"})
-			context.Abort()
+		if context.GetHeader("Authorization") != "" {
+			authenticate(context)
 			return
 		}
+
+		guestToken := context.GetHeader(util.GuestTokenHeader)
+		if guestToken == "" {
+			apperror.Abort(context, errNoAccessOrGuestToken)
+			return
+		}
+		guestId, err := util.ParseGuestToken(jwtSecret, guestToken)
+		if err != nil {
+			apperror.Abort(context, apperror.As(err, apperror.ErrUnauthorized))
+			return
+		}
+
+		context.Set("guest_id", guestId)
+		setActor(context, "GUEST")
 		context.Next()
 	}
 }
 
-func validateToken(signedToken string) (claims *JWTClaim, err error) {
-	token, err := jwt.ParseWithClaims(
-		signedToken,
-		&JWTClaim{},
-		func(token *jwt.Token) (interface{}, error) {
-			return []byte(viper.GetString("jwt_secret")), nil
-		},
-	)
-	if err != nil {
-		return
-	}
-	claims, ok := token.Claims.(*JWTClaim)
-	if !ok {
-		err = errors.New("couldn't parse claims")
-		return
+// HumanOnly needs Auth first and refuses api keys, routes acting on the own account or data of the owner
+// carry no permission an api key could be scoped to, so only a logged in person may use them
+func HumanOnly() gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if _, ok := context.Get("api_key_id"); ok {
+			apperror.Abort(context, errApiKeyNotAllowed)
+			return
+		}
+		context.Next()
 	}
-	if claims.ExpiresAt < time.Now().Local().Unix() {
-		err = errors.New("token expired")
-		return
+}
+
+// RequirePermission needs Auth first and refuses accounts whose roles lack any of the permissions
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		if !auth.HasPermissions(context.GetStringSlice("permissions"), permissions...) {
+			apperror.Abort(context, apperror.ErrForbidden)
+			return
+		}
+		context.Next()
 	}
-	return
 }
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HumanOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		apiKeyId   int
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "Logged in person",
+			wantStatus: http.StatusOK,
+		},
+		{
+			// a key scoped to item:write must not act as its owner on the own account routes
+			name:       "Api key",
+			apiKeyId:   3,
+			wantStatus: http.StatusForbidden,
+			wantCode:   "api_key_not_allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("username", "email@mail.com")
+				c.Set("permissions", []string{"item:write"})
+				if tt.apiKeyId != 0 {
+					c.Set("api_key_id", tt.apiKeyId)
+				}
+			})
+			router.GET("/account/me/export", HumanOnly(), func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "Sucess!"})
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/account/me/export", nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				var body apperror.Response
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, tt.wantCode, body.Error.Code)
+			}
+		})
+	}
+}
+
+func Test_AuthOrGuest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	guestId, guestToken, _, err := util.NewGuestToken("secret", time.Hour)
+	assert.NoError(t, err)
+	_, otherToken, _, err := util.NewGuestToken("other secret", time.Hour)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		guestToken string
+		wantStatus int
+	}{
+		{
+			name:       "Guest token signed with the secret",
+			guestToken: guestToken,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Guest token signed with another secret",
+			guestToken: otherToken,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "No token",
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			router := gin.New()
+			router.GET("/cart/all", AuthOrGuest(nil, "secret"), func(c *gin.Context) {
+				assert.Equal(t, guestId, c.GetString("guest_id"))
+				c.JSON(http.StatusOK, gin.H{"message": "Sucess!"})
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/cart/all", nil)
+			if tt.guestToken != "" {
+				req.Header.Set(util.GuestTokenHeader, tt.guestToken)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func Test_RequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		permissions []string
+		wantStatus  int
+	}{
+		{name: "Key holds the permission", permissions: []string{"item:write"}, wantStatus: http.StatusOK},
+		{name: "Key lacks the permission", permissions: []string{"voucher:write"}, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("permissions", tt.permissions)
+				c.Set("api_key_id", 3)
+			})
+			router.PUT("/item/:id", RequirePermission("item:write"), func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "Sucess!"})
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/item/1", nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
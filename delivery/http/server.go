@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/avarian/online-shopping-cart/controllers"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/auth"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	swaggerFiles "github.com/swaggo/files"
@@ -18,15 +20,36 @@ type Server struct {
 }
 
 func NewServer(listenAddress string,
+	tokens *auth.TokenService,
+	jwtSecret string,
 	home *controllers.HomeController,
 	account *controllers.AccountController,
 	item *controllers.ItemController,
 	cart *controllers.CartController,
 	voucher *controllers.VoucherController,
 	order *controllers.OrderController,
+	review *controllers.ReviewController,
+	wishlist *controllers.WishlistController,
+	itemSubscription *controllers.ItemSubscriptionController,
+	password *controllers.PasswordController,
+	verification *controllers.VerificationController,
+	role *controllers.RoleController,
+	accountAddress *controllers.AccountAddressController,
+	adminAccount *controllers.AdminAccountController,
+	twoFactor *controllers.TwoFactorController,
+	oidc *controllers.OidcController,
+	apiKey *controllers.ApiKeyController,
+	accountData *controllers.AccountDataController,
+	auditLog *controllers.AuditLogController,
 ) *Server {
 
-	router := gin.Default()
+	router := gin.New()
+	// controllers hand the gin context to gorm, its values fall back to the request context
+	router.ContextWithFallback = true
+	router.Use(RequestContext(), AccessLog(), Recovery())
+	router.NoRoute(func(c *gin.Context) {
+		apperror.Abort(c, errRouteNotFound)
+	})
 	//
 	// Http Routings
 	//
@@ -37,39 +60,154 @@ func NewServer(listenAddress string,
 	router.GET("/", home.GetHome)
 	router.POST("/register", account.PostRegister)
 	router.POST("/login", account.PostLogin)
+	router.POST("/login/2fa", twoFactor.PostLoginTwoFactor)
+	router.POST("/login/2fa/enroll", twoFactor.PostLoginEnrollTwoFactor)
+	router.POST("/login/2fa/enroll/confirm", twoFactor.PostLoginConfirmTwoFactor)
+	router.GET("/login/oidc/:provider", oidc.GetOidcAuthorize)
+	router.POST("/login/oidc/:provider/callback", oidc.PostOidcCallback)
+	router.POST("/token/refresh", account.PostRefreshToken)
+	router.POST("/logout", Auth(tokens), HumanOnly(), account.PostLogout)
+	router.GET("/.well-known/jwks.json", account.GetJWKS)
+	router.POST("/password/forgot", password.PostForgotPassword)
+	router.POST("/password/reset", password.PostResetPassword)
+	router.POST("/verification/email/confirm", verification.PostConfirmEmailVerification)
 
-	itemRoute := router.Group("/item").Use(Auth())
+	verificationRoute := router.Group("/verification").Use(Auth(tokens), HumanOnly())
 	{
-		itemRoute.GET("/all", item.GetItems)
-		itemRoute.GET("/:id", item.GetItemDetail)
-		itemRoute.Use(Admin()).POST("/", item.PostCreateItem)
-		itemRoute.Use(Admin()).PUT("/:id", item.PutEditItem)
-		itemRoute.Use(Admin()).DELETE("/:id", item.DeleteItem)
+		verificationRoute.POST("/email", verification.PostSendEmailVerification)
+		verificationRoute.POST("/phone", verification.PostSendPhoneVerification)
+		verificationRoute.POST("/phone/confirm", verification.PostConfirmPhoneVerification)
 	}
 
-	cartRoute := router.Group("/cart").Use(Auth())
+	accountRoute := router.Group("/account").Use(Auth(tokens), HumanOnly())
+	{
+		accountRoute.GET("/me", account.GetProfile)
+		accountRoute.PUT("/me", account.PutEditProfile)
+		accountRoute.PUT("/me/password", account.PutChangePassword)
+		accountRoute.POST("/me/2fa", twoFactor.PostEnrollTwoFactor)
+		accountRoute.POST("/me/2fa/confirm", twoFactor.PostConfirmTwoFactor)
+		accountRoute.POST("/me/2fa/recovery-codes", twoFactor.PostRegenerateRecoveryCodes)
+		accountRoute.DELETE("/me/2fa", twoFactor.DeleteTwoFactor)
+		accountRoute.GET("/me/export", accountData.GetExportAccountData)
+		accountRoute.POST("/me/erasure", accountData.PostRequestErasure)
+		accountRoute.GET("/me/address/all", accountAddress.GetAccountAddresses)
+		accountRoute.GET("/me/address/:id", accountAddress.GetAccountAddressDetail)
+		accountRoute.POST("/me/address", accountAddress.PostCreateAccountAddress)
+		accountRoute.PUT("/me/address/:id", accountAddress.PutEditAccountAddress)
+		accountRoute.PUT("/me/address/:id/default", accountAddress.PutDefaultAccountAddress)
+		accountRoute.DELETE("/me/address/:id", accountAddress.DeleteAccountAddress)
+	}
+
+	itemRoute := router.Group("/item").Use(Auth(tokens))
+	{
+		itemRoute.GET("/all", HumanOnly(), item.GetItems)
+		itemRoute.GET("/:id", HumanOnly(), item.GetItemDetail)
+		itemRoute.GET("/:id/review", HumanOnly(), review.GetItemReviews)
+		itemRoute.POST("/:id/review", HumanOnly(), review.PostCreateReview)
+		itemRoute.POST("/:id/subscription", HumanOnly(), itemSubscription.PostCreateItemSubscription)
+		itemRoute.POST("/", RequirePermission("item:write"), item.PostCreateItem)
+		itemRoute.PUT("/:id", RequirePermission("item:write"), item.PutEditItem)
+		itemRoute.DELETE("/:id", RequirePermission("item:write"), item.DeleteItem)
+	}
+
+	router.POST("/cart/guest", cart.PostCreateGuestCart)
+
+	cartRoute := router.Group("/cart").Use(AuthOrGuest(tokens, jwtSecret), HumanOnly())
 	{
 		cartRoute.GET("/all", cart.GetCarts)
 		cartRoute.GET("/:id", cart.GetCartDetail)
 		cartRoute.POST("/", cart.PostCreateCartFromItem)
+		cartRoute.POST("/acknowledge", cart.PostAcknowledgeCarts)
+		cartRoute.PUT("/", cart.PutEditCarts)
 		cartRoute.PUT("/:id", cart.PutEditCart)
+		cartRoute.PUT("/:id/saved", cart.PutSaveCartForLater)
+		cartRoute.PUT("/:id/active", cart.PutMoveCartToActive)
 		cartRoute.DELETE("/:id", cart.DeleteCart)
 	}
 
-	voucherRoute := router.Group("/voucher").Use(Auth())
+	voucherRoute := router.Group("/voucher").Use(Auth(tokens))
 	{
-		voucherRoute.GET("/all", voucher.GetVouchers)
-		voucherRoute.GET("/:id", voucher.GetVoucherDetail)
-		voucherRoute.Use(Admin()).POST("/", voucher.PostCreateVoucher)
-		voucherRoute.Use(Admin()).PUT("/:id", voucher.PutEditVoucher)
-		voucherRoute.Use(Admin()).DELETE("/:id", voucher.DeleteVoucher)
+		voucherRoute.GET("/all", HumanOnly(), voucher.GetVouchers)
+		voucherRoute.GET("/:id", HumanOnly(), voucher.GetVoucherDetail)
+		voucherRoute.POST("/", RequirePermission("voucher:write"), voucher.PostCreateVoucher)
+		voucherRoute.PUT("/:id", RequirePermission("voucher:write"), voucher.PutEditVoucher)
+		voucherRoute.DELETE("/:id", RequirePermission("voucher:write"), voucher.DeleteVoucher)
 	}
 
-	orderRoute := router.Group("/order").Use(Auth())
+	orderRoute := router.Group("/order").Use(Auth(tokens), HumanOnly())
 	{
 		orderRoute.GET("/all", order.GetOrders)
 		orderRoute.GET("/:id", order.GetOrderDetail)
 		orderRoute.POST("/", order.PostCreateOrder)
+		orderRoute.POST("/:id/reorder", order.PostReorder)
+	}
+
+	reviewRoute := router.Group("/review").Use(Auth(tokens), RequirePermission("review:moderate"))
+	{
+		reviewRoute.GET("/all", review.GetReviews)
+		reviewRoute.PUT("/:id/approve", review.PutApproveReview)
+		reviewRoute.PUT("/:id/hide", review.PutHideReview)
+	}
+
+	wishlistRoute := router.Group("/wishlist").Use(Auth(tokens), HumanOnly())
+	{
+		wishlistRoute.GET("/all", wishlist.GetWishlists)
+		wishlistRoute.GET("/:id", wishlist.GetWishlistDetail)
+		wishlistRoute.POST("/", wishlist.PostCreateWishlist)
+		wishlistRoute.PUT("/:id", wishlist.PutEditWishlist)
+		wishlistRoute.DELETE("/:id", wishlist.DeleteWishlist)
+		wishlistRoute.POST("/:id/item", wishlist.PostAddWishlistItem)
+		wishlistRoute.DELETE("/:id/item/:item_id", wishlist.DeleteWishlistItem)
+		wishlistRoute.POST("/:id/item/:item_id/cart", wishlist.PostMoveWishlistItemToCart)
+		wishlistRoute.POST("/:id/share", wishlist.PostShareWishlist)
+		wishlistRoute.DELETE("/:id/share", wishlist.DeleteShareWishlist)
+	}
+
+	router.GET("/shared/wishlist/:token", wishlist.GetSharedWishlist)
+
+	subscriptionRoute := router.Group("/subscription").Use(Auth(tokens), HumanOnly())
+	{
+		subscriptionRoute.GET("/all", itemSubscription.GetItemSubscriptions)
+		subscriptionRoute.DELETE("/:id", itemSubscription.DeleteItemSubscription)
+	}
+
+	roleRoute := router.Group("/role").Use(Auth(tokens), RequirePermission("role:write"))
+	{
+		roleRoute.GET("/all", role.GetRoles)
+		roleRoute.GET("/permission", role.GetPermissions)
+		roleRoute.POST("/", role.PostCreateRole)
+		roleRoute.PUT("/:id", role.PutEditRole)
+		roleRoute.DELETE("/:id", role.DeleteRole)
+		roleRoute.POST("/:id/account/:account_id", role.PostAssignRole)
+		roleRoute.DELETE("/:id/account/:account_id", role.DeleteAssignRole)
+	}
+
+	adminAccountRoute := router.Group("/admin/account").Use(Auth(tokens), RequirePermission("account:manage"))
+	{
+		adminAccountRoute.GET("/all", adminAccount.GetAccounts)
+		adminAccountRoute.GET("/erasure/all", adminAccount.GetAccountErasures)
+		adminAccountRoute.GET("/action/all", adminAccount.GetAdminActions)
+		adminAccountRoute.GET("/:id", adminAccount.GetAccountDetail)
+		adminAccountRoute.PUT("/:id/suspend", adminAccount.PutSuspendAccount)
+		adminAccountRoute.PUT("/:id/reactivate", adminAccount.PutReactivateAccount)
+		adminAccountRoute.PUT("/:id/unlock", adminAccount.PutUnlockAccount)
+		adminAccountRoute.POST("/:id/password-reset", adminAccount.PostForcePasswordReset)
+		adminAccountRoute.PUT("/:id/type", adminAccount.PutEditAccountType)
+		adminAccountRoute.POST("/:id/erasure", adminAccount.PostEraseAccount)
+	}
+
+	apiKeyRoute := router.Group("/admin/api-key").Use(Auth(tokens), RequirePermission("api_key:manage"))
+	{
+		apiKeyRoute.GET("/all", apiKey.GetApiKeys)
+		apiKeyRoute.GET("/:id", apiKey.GetApiKeyDetail)
+		apiKeyRoute.POST("/", apiKey.PostCreateApiKey)
+		apiKeyRoute.PUT("/:id/revoke", apiKey.PutRevokeApiKey)
+	}
+
+	auditLogRoute := router.Group("/admin/audit-log").Use(Auth(tokens), RequirePermission("audit:read"))
+	{
+		auditLogRoute.GET("/all", auditLog.GetAuditLogs)
+		auditLogRoute.GET("/export", auditLog.GetExportAuditLogs)
 	}
 
 	httpServer := &http.Server{
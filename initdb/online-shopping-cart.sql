@@ -17,6 +17,111 @@
 SET NAMES utf8mb4;
 SET FOREIGN_KEY_CHECKS = 0;
 
+-- ----------------------------
+-- Table structure for account_addresses
+-- ----------------------------
+DROP TABLE IF EXISTS `account_addresses`;
+CREATE TABLE `account_addresses`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `account_id` bigint UNSIGNED NOT NULL,
+  `label` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `recipient_name` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `phone_number` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `address` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `is_default` tinyint(1) NOT NULL DEFAULT 0,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `fk_accounts_account_address`(`account_id` ASC) USING BTREE,
+  CONSTRAINT `fk_accounts_account_address` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 3 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of account_addresses
+-- ----------------------------
+INSERT INTO `account_addresses` VALUES (1, 1, 'home', 'Admin', '08544432132', 'Address Example', 1, 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+INSERT INTO `account_addresses` VALUES (2, 2, 'home', 'Customer 1', '085445672341', 'Address Customer Example', 1, 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:45:35.579', '2023-09-06 12:45:35.579', NULL);
+
+-- ----------------------------
+-- Table structure for account_erasures
+-- ----------------------------
+DROP TABLE IF EXISTS `account_erasures`;
+CREATE TABLE `account_erasures`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `account_id` bigint UNSIGNED NOT NULL,
+  `status` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL DEFAULT 'PENDING',
+  `completed_at` datetime(3) NULL DEFAULT NULL,
+  `error` varchar(1024) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `idx_account_erasures_account_id`(`account_id` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of account_erasures
+-- ----------------------------
+
+-- ----------------------------
+-- Table structure for account_identities
+-- ----------------------------
+DROP TABLE IF EXISTS `account_identities`;
+CREATE TABLE `account_identities`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `account_id` bigint UNSIGNED NOT NULL,
+  `provider` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `subject` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `email` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `idx_account_identities_provider_subject`(`provider` ASC, `subject` ASC) USING BTREE,
+  INDEX `fk_account_identities_account`(`account_id` ASC) USING BTREE,
+  CONSTRAINT `fk_account_identities_account` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of account_identities
+-- ----------------------------
+
+-- ----------------------------
+-- Table structure for account_roles
+-- ----------------------------
+DROP TABLE IF EXISTS `account_roles`;
+CREATE TABLE `account_roles`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `account_id` bigint UNSIGNED NOT NULL,
+  `role_id` bigint UNSIGNED NOT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `idx_account_role_key`(`account_id` ASC, `role_id` ASC) USING BTREE,
+  INDEX `fk_account_roles_role`(`role_id` ASC) USING BTREE,
+  CONSTRAINT `fk_account_roles_account` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE,
+  CONSTRAINT `fk_account_roles_role` FOREIGN KEY (`role_id`) REFERENCES `roles` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 2 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of account_roles
+-- ----------------------------
+INSERT INTO `account_roles` VALUES (1, 1, 1, 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+
 -- ----------------------------
 -- Table structure for accounts
 -- ----------------------------
@@ -29,6 +134,12 @@ CREATE TABLE `accounts`  (
   `password` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
   `address` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
   `type` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'CUSTOMER',
+  `email_verified_at` datetime(3) NULL DEFAULT NULL,
+  `phone_verified_at` datetime(3) NULL DEFAULT NULL,
+  `suspended_at` datetime(3) NULL DEFAULT NULL,
+  `password_reset_required` tinyint(1) NOT NULL DEFAULT 0,
+  `totp_secret` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `totp_enabled_at` datetime(3) NULL DEFAULT NULL,
   `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
   `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
   `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
@@ -43,8 +154,118 @@ CREATE TABLE `accounts`  (
 -- ----------------------------
 -- Records of accounts
 -- ----------------------------
-INSERT INTO `accounts` VALUES (1, 'Admin', 'admin@example.com', '08544432132', '$2a$05$WfPjV0pMYveDW/iP2AOtVu/xtgkfwY5IISEgjSaKbk5tdDgGUICFy', 'Address Example', 'ADMIN', 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
-INSERT INTO `accounts` VALUES (2, 'Customer 1', 'customer1@example.com', '085445672341', '$2a$05$VXgglOjbT0cSTq7N.V7T8O2a9.gog0APjAt3Ohgep01JqZTycp3X6', 'Address Customer Example', 'CUSTOMER', 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:45:35.579', '2023-09-06 12:45:35.579', NULL);
+INSERT INTO `accounts` VALUES (1, 'Admin', 'admin@example.com', '08544432132', '$2a$05$WfPjV0pMYveDW/iP2AOtVu/xtgkfwY5IISEgjSaKbk5tdDgGUICFy', 'Address Example', 'ADMIN', '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL, 0, NULL, NULL, 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+INSERT INTO `accounts` VALUES (2, 'Customer 1', 'customer1@example.com', '085445672341', '$2a$05$VXgglOjbT0cSTq7N.V7T8O2a9.gog0APjAt3Ohgep01JqZTycp3X6', 'Address Customer Example', 'CUSTOMER', '2023-09-06 12:45:35.579', '2023-09-06 12:45:35.579', NULL, 0, NULL, NULL, 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:45:35.579', '2023-09-06 12:45:35.579', NULL);
+
+-- ----------------------------
+-- Table structure for api_key_permissions
+-- ----------------------------
+DROP TABLE IF EXISTS `api_key_permissions`;
+CREATE TABLE `api_key_permissions`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `api_key_id` bigint UNSIGNED NOT NULL,
+  `permission` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `idx_api_key_permission_key`(`api_key_id` ASC, `permission` ASC) USING BTREE,
+  CONSTRAINT `fk_api_keys_api_key_permission` FOREIGN KEY (`api_key_id`) REFERENCES `api_keys` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of api_key_permissions
+-- ----------------------------
+
+-- ----------------------------
+-- Table structure for api_keys
+-- ----------------------------
+DROP TABLE IF EXISTS `api_keys`;
+CREATE TABLE `api_keys`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `account_id` bigint UNSIGNED NOT NULL,
+  `name` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `prefix` varchar(16) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `key_hash` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `expired_at` datetime(3) NULL DEFAULT NULL,
+  `last_used_at` datetime(3) NULL DEFAULT NULL,
+  `last_used_ip` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `revoked_at` datetime(3) NULL DEFAULT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `idx_api_keys_key_hash`(`key_hash` ASC) USING BTREE,
+  INDEX `idx_api_keys_prefix`(`prefix` ASC) USING BTREE,
+  INDEX `fk_api_keys_account`(`account_id` ASC) USING BTREE,
+  CONSTRAINT `fk_api_keys_account` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of api_keys
+-- ----------------------------
+
+-- ----------------------------
+-- Table structure for audit_logs
+-- ----------------------------
+DROP TABLE IF EXISTS `audit_logs`;
+CREATE TABLE `audit_logs`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `actor` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `action` varchar(16) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `entity` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `entity_id` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `before` text CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL,
+  `after` text CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL,
+  `request_id` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `client_ip` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `idx_audit_logs_actor`(`actor` ASC) USING BTREE,
+  INDEX `idx_audit_logs_entity`(`entity` ASC, `entity_id` ASC) USING BTREE,
+  INDEX `idx_audit_logs_request_id`(`request_id` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of audit_logs
+-- ----------------------------
+
+-- ----------------------------
+-- Table structure for cart_reminders
+-- ----------------------------
+DROP TABLE IF EXISTS `cart_reminders`;
+CREATE TABLE `cart_reminders`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `account_id` bigint UNSIGNED NOT NULL,
+  `voucher_id` bigint UNSIGNED NULL DEFAULT NULL,
+  `cart_updated_at` datetime(3) NOT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `fk_cart_reminders_account`(`account_id` ASC) USING BTREE,
+  INDEX `fk_cart_reminders_voucher`(`voucher_id` ASC) USING BTREE,
+  CONSTRAINT `fk_cart_reminders_account` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE,
+  CONSTRAINT `fk_cart_reminders_voucher` FOREIGN KEY (`voucher_id`) REFERENCES `vouchers` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of cart_reminders
+-- ----------------------------
 
 -- ----------------------------
 -- Table structure for carts
@@ -52,9 +273,12 @@ INSERT INTO `accounts` VALUES (2, 'Customer 1', 'customer1@example.com', '085445
 DROP TABLE IF EXISTS `carts`;
 CREATE TABLE `carts`  (
   `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
-  `account_id` bigint UNSIGNED NOT NULL,
+  `account_id` bigint UNSIGNED NULL DEFAULT NULL,
+  `guest_id` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
   `item_id` bigint UNSIGNED NOT NULL,
   `qty` bigint NOT NULL,
+  `price` double NOT NULL DEFAULT 0,
+  `saved_for_later` tinyint(1) NOT NULL DEFAULT 0,
   `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
   `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
   `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
@@ -63,6 +287,7 @@ CREATE TABLE `carts`  (
   `deleted_at` datetime(3) NULL DEFAULT NULL,
   PRIMARY KEY (`id`) USING BTREE,
   UNIQUE INDEX `idx_account_item_key`(`account_id` ASC, `item_id` ASC) USING BTREE,
+  UNIQUE INDEX `idx_guest_item_key`(`guest_id` ASC, `item_id` ASC) USING BTREE,
   INDEX `fk_items_cart`(`item_id` ASC) USING BTREE,
   CONSTRAINT `fk_accounts_cart` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE,
   CONSTRAINT `fk_carts_account` FOREIGN KEY (`account_id`) REFERENCES `items` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE,
@@ -73,6 +298,34 @@ CREATE TABLE `carts`  (
 -- Records of carts
 -- ----------------------------
 
+-- ----------------------------
+-- Table structure for item_subscriptions
+-- ----------------------------
+DROP TABLE IF EXISTS `item_subscriptions`;
+CREATE TABLE `item_subscriptions`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `account_id` bigint UNSIGNED NOT NULL,
+  `item_id` bigint UNSIGNED NOT NULL,
+  `type` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `target_price` double NULL DEFAULT NULL,
+  `notified_at` datetime(3) NULL DEFAULT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `fk_item_subscriptions_account`(`account_id` ASC) USING BTREE,
+  INDEX `fk_item_subscriptions_item`(`item_id` ASC) USING BTREE,
+  CONSTRAINT `fk_item_subscriptions_account` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE,
+  CONSTRAINT `fk_item_subscriptions_item` FOREIGN KEY (`item_id`) REFERENCES `items` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of item_subscriptions
+-- ----------------------------
+
 -- ----------------------------
 -- Table structure for items
 -- ----------------------------
@@ -83,6 +336,8 @@ CREATE TABLE `items`  (
   `description` varchar(256) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
   `price` double NOT NULL,
   `qty` bigint NOT NULL,
+  `rating_average` double NOT NULL DEFAULT 0,
+  `rating_count` bigint NOT NULL DEFAULT 0,
   `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
   `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
   `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
@@ -95,12 +350,38 @@ CREATE TABLE `items`  (
 -- ----------------------------
 -- Records of items
 -- ----------------------------
-INSERT INTO `items` VALUES (1, 'Baju', 'description baju', 200000, 61, 'admin@example.com', 'SYSTEM', NULL, '2023-09-06 13:32:14.313', '2023-09-07 00:53:27.811', NULL);
-INSERT INTO `items` VALUES (2, 'Celana', 'description celana', 60000, 0, 'admin@example.com', 'admin@example.com', NULL, '2023-09-06 13:33:37.082', '2023-09-06 23:21:02.514', NULL);
-INSERT INTO `items` VALUES (3, 'Sepatu', 'description sepatu', 540000, 10, 'admin@example.com', 'SYSTEM', NULL, '2023-09-06 13:35:12.721', '2023-09-06 13:35:12.721', NULL);
-INSERT INTO `items` VALUES (4, 'Sandal', 'description sandal', 143000, 18, 'admin@example.com', 'SYSTEM', NULL, '2023-09-06 13:38:06.505', '2023-09-06 13:38:06.505', NULL);
-INSERT INTO `items` VALUES (5, 'Hp', 'description hp', 1430000, 18, 'admin@example.com', 'SYSTEM', NULL, '2023-09-06 13:39:12.134', '2023-09-06 13:39:12.134', NULL);
-INSERT INTO `items` VALUES (6, 'Daster', 'description daster', 130000, 189, 'admin@example.com', 'SYSTEM', NULL, '2023-09-06 13:44:23.379', '2023-09-06 13:44:23.379', NULL);
+INSERT INTO `items` VALUES (1, 'Baju', 'description baju', 200000, 61, 0, 0, 'admin@example.com', 'SYSTEM', NULL, '2023-09-06 13:32:14.313', '2023-09-07 00:53:27.811', NULL);
+INSERT INTO `items` VALUES (2, 'Celana', 'description celana', 60000, 0, 0, 0, 'admin@example.com', 'admin@example.com', NULL, '2023-09-06 13:33:37.082', '2023-09-06 23:21:02.514', NULL);
+INSERT INTO `items` VALUES (3, 'Sepatu', 'description sepatu', 540000, 10, 0, 0, 'admin@example.com', 'SYSTEM', NULL, '2023-09-06 13:35:12.721', '2023-09-06 13:35:12.721', NULL);
+INSERT INTO `items` VALUES (4, 'Sandal', 'description sandal', 143000, 18, 0, 0, 'admin@example.com', 'SYSTEM', NULL, '2023-09-06 13:38:06.505', '2023-09-06 13:38:06.505', NULL);
+INSERT INTO `items` VALUES (5, 'Hp', 'description hp', 1430000, 18, 0, 0, 'admin@example.com', 'SYSTEM', NULL, '2023-09-06 13:39:12.134', '2023-09-06 13:39:12.134', NULL);
+INSERT INTO `items` VALUES (6, 'Daster', 'description daster', 130000, 189, 0, 0, 'admin@example.com', 'SYSTEM', NULL, '2023-09-06 13:44:23.379', '2023-09-06 13:44:23.379', NULL);
+
+-- ----------------------------
+-- Table structure for oidc_states
+-- ----------------------------
+DROP TABLE IF EXISTS `oidc_states`;
+CREATE TABLE `oidc_states`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `provider` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `state_hash` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `nonce` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `code_verifier` varchar(128) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `expired_at` datetime(3) NOT NULL,
+  `used_at` datetime(3) NULL DEFAULT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `idx_oidc_states_state_hash`(`state_hash` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of oidc_states
+-- ----------------------------
 
 -- ----------------------------
 -- Table structure for order_items
@@ -198,6 +479,254 @@ INSERT INTO `orders` VALUES (1, 2, 'Address Customer Example', '085445672341', 2
 INSERT INTO `orders` VALUES (3, 2, 'Address Customer Example', '085445672341', 190000, 'ORDERED', 'customer1@example.com', 'customer1@example.com', NULL, '2023-09-06 17:50:15.941', '2023-09-07 00:50:15.957', NULL);
 INSERT INTO `orders` VALUES (4, 2, 'Address Customer Example', '085445672341', 790000, 'ORDERED', 'customer1@example.com', 'customer1@example.com', NULL, '2023-09-06 17:53:27.803', '2023-09-07 00:53:27.817', NULL);
 
+-- ----------------------------
+-- Table structure for password_resets
+-- ----------------------------
+DROP TABLE IF EXISTS `password_resets`;
+CREATE TABLE `password_resets`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `account_id` bigint UNSIGNED NOT NULL,
+  `token_hash` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `expired_at` datetime(3) NOT NULL,
+  `used_at` datetime(3) NULL DEFAULT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `idx_password_resets_token_hash`(`token_hash` ASC) USING BTREE,
+  INDEX `fk_password_resets_account`(`account_id` ASC) USING BTREE,
+  CONSTRAINT `fk_password_resets_account` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of password_resets
+-- ----------------------------
+
+-- ----------------------------
+-- Table structure for recovery_codes
+-- ----------------------------
+DROP TABLE IF EXISTS `recovery_codes`;
+CREATE TABLE `recovery_codes`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `account_id` bigint UNSIGNED NOT NULL,
+  `code_hash` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `used_at` datetime(3) NULL DEFAULT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `idx_recovery_codes_code_hash`(`code_hash` ASC) USING BTREE,
+  INDEX `fk_recovery_codes_account`(`account_id` ASC) USING BTREE,
+  CONSTRAINT `fk_recovery_codes_account` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of recovery_codes
+-- ----------------------------
+
+-- ----------------------------
+-- Table structure for refresh_tokens
+-- ----------------------------
+DROP TABLE IF EXISTS `refresh_tokens`;
+CREATE TABLE `refresh_tokens`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `account_id` bigint UNSIGNED NOT NULL,
+  `family_id` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `token_hash` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `expired_at` datetime(3) NOT NULL,
+  `used_at` datetime(3) NULL DEFAULT NULL,
+  `revoked_at` datetime(3) NULL DEFAULT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `idx_refresh_tokens_token_hash`(`token_hash` ASC) USING BTREE,
+  INDEX `idx_refresh_tokens_family_id`(`family_id` ASC) USING BTREE,
+  INDEX `fk_refresh_tokens_account`(`account_id` ASC) USING BTREE,
+  CONSTRAINT `fk_refresh_tokens_account` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of refresh_tokens
+-- ----------------------------
+
+-- ----------------------------
+-- Table structure for review_images
+-- ----------------------------
+DROP TABLE IF EXISTS `review_images`;
+CREATE TABLE `review_images`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `review_id` bigint UNSIGNED NOT NULL,
+  `url` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `fk_reviews_review_image`(`review_id` ASC) USING BTREE,
+  CONSTRAINT `fk_reviews_review_image` FOREIGN KEY (`review_id`) REFERENCES `reviews` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of review_images
+-- ----------------------------
+
+-- ----------------------------
+-- Table structure for reviews
+-- ----------------------------
+DROP TABLE IF EXISTS `reviews`;
+CREATE TABLE `reviews`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `item_id` bigint UNSIGNED NOT NULL,
+  `account_id` bigint UNSIGNED NOT NULL,
+  `rating` bigint NOT NULL,
+  `title` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `body` longtext CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL,
+  `status` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL DEFAULT 'PENDING',
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `idx_review_item_account_key`(`item_id` ASC, `account_id` ASC) USING BTREE,
+  INDEX `fk_reviews_account`(`account_id` ASC) USING BTREE,
+  CONSTRAINT `fk_reviews_account` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE,
+  CONSTRAINT `fk_reviews_item` FOREIGN KEY (`item_id`) REFERENCES `items` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of reviews
+-- ----------------------------
+
+-- ----------------------------
+-- Table structure for role_permissions
+-- ----------------------------
+DROP TABLE IF EXISTS `role_permissions`;
+CREATE TABLE `role_permissions`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `role_id` bigint UNSIGNED NOT NULL,
+  `permission` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `idx_role_permission_key`(`role_id` ASC, `permission` ASC) USING BTREE,
+  CONSTRAINT `fk_roles_role_permission` FOREIGN KEY (`role_id`) REFERENCES `roles` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 13 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of role_permissions
+-- ----------------------------
+INSERT INTO `role_permissions` VALUES (1, 1, 'item:write', 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+INSERT INTO `role_permissions` VALUES (2, 1, 'voucher:write', 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+INSERT INTO `role_permissions` VALUES (3, 1, 'review:moderate', 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+INSERT INTO `role_permissions` VALUES (4, 1, 'order:refund', 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+INSERT INTO `role_permissions` VALUES (5, 1, 'role:write', 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+INSERT INTO `role_permissions` VALUES (6, 2, 'item:write', 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+INSERT INTO `role_permissions` VALUES (7, 2, 'voucher:write', 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+INSERT INTO `role_permissions` VALUES (8, 3, 'order:refund', 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+INSERT INTO `role_permissions` VALUES (9, 4, 'review:moderate', 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+INSERT INTO `role_permissions` VALUES (10, 1, 'account:manage', 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+INSERT INTO `role_permissions` VALUES (11, 1, 'api_key:manage', 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+INSERT INTO `role_permissions` VALUES (12, 1, 'audit:read', 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+
+-- ----------------------------
+-- Table structure for roles
+-- ----------------------------
+DROP TABLE IF EXISTS `roles`;
+CREATE TABLE `roles`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `name` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `description` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `name`(`name` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 5 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of roles
+-- ----------------------------
+INSERT INTO `roles` VALUES (1, 'SUPER_ADMIN', 'every permission', 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+INSERT INTO `roles` VALUES (2, 'CATALOG_MANAGER', 'manage items and vouchers', 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+INSERT INTO `roles` VALUES (3, 'ORDER_FULFILMENT', 'refund orders', 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+INSERT INTO `roles` VALUES (4, 'SUPPORT', 'moderate reviews', 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 12:42:53.279', '2023-09-06 12:42:53.279', NULL);
+
+-- ----------------------------
+-- Table structure for signing_keys
+-- ----------------------------
+DROP TABLE IF EXISTS `signing_keys`;
+CREATE TABLE `signing_keys`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `kid` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `algorithm` varchar(16) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `private_key` text CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `public_key` text CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `status` varchar(16) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL DEFAULT 'ACTIVE',
+  `retired_at` datetime(3) NULL DEFAULT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `idx_signing_keys_kid`(`kid` ASC) USING BTREE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of signing_keys
+-- ----------------------------
+
+-- ----------------------------
+-- Table structure for verifications
+-- ----------------------------
+DROP TABLE IF EXISTS `verifications`;
+CREATE TABLE `verifications`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `account_id` bigint UNSIGNED NOT NULL,
+  `channel` varchar(16) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `target` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `code_hash` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `attempts` bigint NOT NULL DEFAULT 0,
+  `expired_at` datetime(3) NOT NULL,
+  `used_at` datetime(3) NULL DEFAULT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  INDEX `idx_verifications_code_hash`(`code_hash` ASC) USING BTREE,
+  INDEX `fk_verifications_account`(`account_id` ASC) USING BTREE,
+  CONSTRAINT `fk_verifications_account` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of verifications
+-- ----------------------------
+
 -- ----------------------------
 -- Table structure for vouchers
 -- ----------------------------
@@ -209,6 +738,9 @@ CREATE TABLE `vouchers`  (
   `description` varchar(256) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
   `percentage` double NOT NULL,
   `max` double NOT NULL,
+  `account_id` bigint UNSIGNED NULL DEFAULT NULL,
+  `single_use` tinyint(1) NOT NULL DEFAULT 0,
+  `expired_at` datetime(3) NULL DEFAULT NULL,
   `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
   `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
   `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
@@ -222,6 +754,56 @@ CREATE TABLE `vouchers`  (
 -- ----------------------------
 -- Records of vouchers
 -- ----------------------------
-INSERT INTO `vouchers` VALUES (1, 'COBA', 'coba', 'oba aja', 100, 10000, 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 17:44:48.953', '2023-09-06 17:44:48.953', NULL);
+INSERT INTO `vouchers` VALUES (1, 'COBA', 'coba', 'oba aja', 100, 10000, NULL, 0, NULL, 'SYSTEM', 'SYSTEM', NULL, '2023-09-06 17:44:48.953', '2023-09-06 17:44:48.953', NULL);
+
+-- ----------------------------
+-- Table structure for wishlist_items
+-- ----------------------------
+DROP TABLE IF EXISTS `wishlist_items`;
+CREATE TABLE `wishlist_items`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `wishlist_id` bigint UNSIGNED NOT NULL,
+  `item_id` bigint UNSIGNED NOT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `idx_wishlist_item_key`(`wishlist_id` ASC, `item_id` ASC) USING BTREE,
+  INDEX `fk_wishlist_items_item`(`item_id` ASC) USING BTREE,
+  CONSTRAINT `fk_wishlist_items_item` FOREIGN KEY (`item_id`) REFERENCES `items` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE,
+  CONSTRAINT `fk_wishlists_wishlist_item` FOREIGN KEY (`wishlist_id`) REFERENCES `wishlists` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of wishlist_items
+-- ----------------------------
+
+-- ----------------------------
+-- Table structure for wishlists
+-- ----------------------------
+DROP TABLE IF EXISTS `wishlists`;
+CREATE TABLE `wishlists`  (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `account_id` bigint UNSIGNED NOT NULL,
+  `name` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NOT NULL,
+  `share_token` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `updated_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT 'SYSTEM',
+  `deleted_by` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci NULL DEFAULT NULL,
+  `created_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `updated_at` datetime(3) NULL DEFAULT current_timestamp(3),
+  `deleted_at` datetime(3) NULL DEFAULT NULL,
+  PRIMARY KEY (`id`) USING BTREE,
+  UNIQUE INDEX `idx_wishlist_account_name_key`(`account_id` ASC, `name` ASC) USING BTREE,
+  UNIQUE INDEX `share_token`(`share_token` ASC) USING BTREE,
+  CONSTRAINT `fk_wishlists_account` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`) ON DELETE RESTRICT ON UPDATE CASCADE
+) ENGINE = InnoDB AUTO_INCREMENT = 1 CHARACTER SET = utf8mb4 COLLATE = utf8mb4_general_ci ROW_FORMAT = Dynamic;
+
+-- ----------------------------
+-- Records of wishlists
+-- ----------------------------
 
 SET FOREIGN_KEY_CHECKS = 1;
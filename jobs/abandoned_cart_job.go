@@ -0,0 +1,214 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	"github.com/avarian/online-shopping-cart/util"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var AbandonedCartJobQueueId = "abandoned_cart"
+
+var abandonedCartTemplate = template.Must(template.New("abandoned_cart").Parse(`<p>Hi {{.Name}},</p>
+<p>You left these items in your cart:</p>
+<table>
+	<tr><th>Item</th><th>Qty</th><th>Price</th></tr>
+	{{- range .Carts}}
+	<tr><td>{{.Item.Name}}</td><td>{{.Qty}}</td><td>{{printf "%.2f" .Item.Price}}</td></tr>
+	{{- end}}
+</table>
+{{- if .Voucher}}
+<p>Use voucher <b>{{.Voucher.Code}}</b> for {{printf "%.0f" .Voucher.Percentage}}% off your order{{if .Voucher.ExpiredAt}}, valid until {{.Voucher.ExpiredAt.Format "2006-01-02 15:04"}}{{end}}.</p>
+{{- end}}
+`))
+
+type AbandonedCartJob struct {
+	IdleAfterHours    int     `json:"idle_after_hours"`
+	VoucherPercentage float64 `json:"voucher_percentage"`
+	VoucherMax        float64 `json:"voucher_max"`
+	VoucherValidHours int     `json:"voucher_valid_hours"`
+}
+
+func NewAbandonedCartJob(idleAfterHours int, voucherPercentage float64, voucherMax float64, voucherValidHours int) *AbandonedCartJob {
+	return &AbandonedCartJob{
+		IdleAfterHours:    idleAfterHours,
+		VoucherPercentage: voucherPercentage,
+		VoucherMax:        voucherMax,
+		VoucherValidHours: voucherValidHours,
+	}
+}
+
+// Return the queue id for this job
+func (j *AbandonedCartJob) QueueID() string { return AbandonedCartJobQueueId }
+
+// Find carts untouched for IdleAfterHours whose account did not order since, and remind each
+// account once per cart state, a voucher is attached when VoucherPercentage is set
+func (j *AbandonedCartJob) Handle(ctx context.Context) error {
+	logCtx := log.WithFields(log.Fields{
+		"IdleAfterHours":    j.IdleAfterHours,
+		"VoucherPercentage": j.VoucherPercentage,
+	})
+	logCtx.Info("Processing AbandonedCartJob.Handle()")
+
+	if db == nil {
+		return errors.New("database is uninitialized")
+	}
+
+	cartRepo := repository.NewCartRepository(db)
+	abandoned, result := cartRepo.AllAbandoned(time.Now().Add(-time.Duration(j.IdleAfterHours) * time.Hour))
+	if result.Error != nil {
+		return result.Error
+	}
+
+	var lastErr error
+	for _, v := range abandoned {
+		if err := j.remind(v); err != nil {
+			logCtx.WithField("account_id", v.AccountID).WithError(err).Error("failed to send cart reminder")
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// remind records one reminder row per cart state, the row is claimed before its mail is queued so
+// concurrent runs send it once, and a reminder whose mail was not queued is sent by the next run
+func (j *AbandonedCartJob) remind(abandoned repository.AbandonedCart) error {
+	accountId := int(abandoned.AccountID)
+
+	cartReminderRepo := repository.NewCartReminderRepository(db)
+	reminder, result := cartReminderRepo.OneLatestByAccountId(accountId, "Voucher")
+	if result.Error != nil {
+		return result.Error
+	}
+	reminded := result.RowsAffected > 0 && !reminder.CartUpdatedAt.Before(abandoned.CartUpdatedAt)
+	if reminded && reminder.SentAt != nil {
+		return nil
+	}
+
+	orderRepo := repository.NewOrderRepository(db)
+	ordered, result := orderRepo.CountByAccountIdSince(accountId, abandoned.CartUpdatedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if ordered > 0 {
+		return nil
+	}
+
+	accountRepo := repository.NewAccountRepository(db)
+	account, result := accountRepo.OneById(accountId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 || account.SuspendedAt != nil {
+		return nil
+	}
+
+	cartRepo := repository.NewCartRepository(db)
+	carts, result := cartRepo.AllByAccountId(accountId, "Item")
+	if result.Error != nil {
+		return result.Error
+	}
+	var lines []model.Cart
+	for _, v := range carts {
+		if v.Item != nil {
+			lines = append(lines, v)
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	if !reminded {
+		created, err := j.createReminder(account, abandoned.CartUpdatedAt)
+		if repository.IsDuplicateKey(err) {
+			// another run recorded this cart state first and sends its mail
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		reminder = created
+	}
+
+	var body bytes.Buffer
+	if err := abandonedCartTemplate.Execute(&body, map[string]interface{}{
+		"Name":    account.Name,
+		"Carts":   lines,
+		"Voucher": reminder.Voucher,
+	}); err != nil {
+		return err
+	}
+
+	result = cartReminderRepo.ClaimSend(int(reminder.ID))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	if err := Dispatch(NewSendEmailJob(account.Email, "You left items in your cart", body.String())); err != nil {
+		if result := cartReminderRepo.ReleaseSend(int(reminder.ID)); result.Error != nil {
+			log.WithField("cart_reminder_id", reminder.ID).WithError(result.Error).Error("failed to release cart reminder")
+		}
+		return err
+	}
+	return nil
+}
+
+// createReminder records the reminder of a cart state with its voucher, when VoucherPercentage is set
+func (j *AbandonedCartJob) createReminder(account model.Account, cartUpdatedAt time.Time) (model.CartReminder, error) {
+	reminder := model.CartReminder{
+		AccountID:     account.ID,
+		CartUpdatedAt: cartUpdatedAt,
+	}
+
+	var voucher *model.Voucher
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if j.VoucherPercentage > 0 {
+			code, err := util.RandomToken(5)
+			if err != nil {
+				return err
+			}
+			var expiredAt *time.Time
+			if j.VoucherValidHours > 0 {
+				t := time.Now().Add(time.Duration(j.VoucherValidHours) * time.Hour)
+				expiredAt = &t
+			}
+
+			voucherRepo := repository.NewVoucherRepository(tx)
+			created, result := voucherRepo.Create(model.Voucher{
+				Code:        "CART-" + strings.ToUpper(code),
+				Name:        "Abandoned cart voucher",
+				Description: "one time voucher for " + account.Email,
+				Percentage:  j.VoucherPercentage,
+				Max:         j.VoucherMax,
+				AccountID:   &account.ID,
+				SingleUse:   true,
+				ExpiredAt:   expiredAt,
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			voucher = &created
+			reminder.VoucherID = &voucher.ID
+		}
+
+		created, result := repository.NewCartReminderRepository(tx).Create(reminder)
+		if result.Error != nil {
+			return result.Error
+		}
+		reminder.ID = created.ID
+		return nil
+	})
+	reminder.Voucher = voucher
+	return reminder, err
+}
@@ -0,0 +1,173 @@
+package jobs
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	gomysql "github.com/go-sql-driver/mysql"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func AbandonedCartNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+// not parallel, the database and queue of the jobs are shared by the package
+func Test_AbandonedCartJobRemind(t *testing.T) {
+	cartUpdatedAt := time.Now().Add(-48 * time.Hour)
+	abandoned := repository.AbandonedCart{AccountID: 1, CartUpdatedAt: cartUpdatedAt}
+	reminderColumns := []string{"id", "account_id", "cart_updated_at", "sent_at"}
+
+	expectLatest := func(mock sqlmock.Sqlmock, rows *sqlmock.Rows) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `cart_reminders` WHERE account_id = ?")).
+			WithArgs(1).
+			WillReturnRows(rows)
+	}
+	expectCart := func(mock sqlmock.Sqlmock, ordered int) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `orders` WHERE (account_id = ? AND created_at >= ?)")).
+			WithArgs(1, cartUpdatedAt).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(ordered))
+		if ordered > 0 {
+			return
+		}
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE id = ?")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "email"}).AddRow(1, "Budi", "email@mail.com"))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE (account_id = ? AND saved_for_later = ?)")).
+			WithArgs(1, false).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "item_id", "qty"}).AddRow(3, 1, 2, 1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE `items`.`id` = ?")).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price"}).AddRow(2, "Book", 10))
+	}
+	expectClaim := func(mock sqlmock.Sqlmock, claimed int64) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE `cart_reminders` SET `sent_at`=?,`updated_at`=? WHERE (id = ? AND sent_at IS NULL)")).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 5).
+			WillReturnResult(sqlmock.NewResult(0, claimed))
+		mock.ExpectCommit()
+	}
+
+	tests := []struct {
+		name      string
+		queueErr  error
+		mock      func(mock sqlmock.Sqlmock)
+		wantMails int
+		wantErr   bool
+	}{
+		{
+			name: "First reminder of the cart state",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectLatest(mock, sqlmock.NewRows(reminderColumns))
+				expectCart(mock, 0)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `cart_reminders`")).
+					WillReturnResult(sqlmock.NewResult(5, 1))
+				mock.ExpectCommit()
+				expectClaim(mock, 1)
+			},
+			wantMails: 1,
+		},
+		{
+			name: "Cart state already reminded",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectLatest(mock, sqlmock.NewRows(reminderColumns).AddRow(5, 1, cartUpdatedAt, time.Now()))
+			},
+		},
+		{
+			// the mail of an earlier run was not queued, the recorded reminder is sent again
+			name: "Reminder recorded but not sent",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectLatest(mock, sqlmock.NewRows(reminderColumns).AddRow(5, 1, cartUpdatedAt, nil))
+				expectCart(mock, 0)
+				expectClaim(mock, 1)
+			},
+			wantMails: 1,
+		},
+		{
+			name: "Ordered since the cart changed",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectLatest(mock, sqlmock.NewRows(reminderColumns))
+				expectCart(mock, 1)
+			},
+		},
+		{
+			// an overlapping run recorded the same cart state first, it sends the mail
+			name: "Cart state recorded by another run",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectLatest(mock, sqlmock.NewRows(reminderColumns))
+				expectCart(mock, 0)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `cart_reminders`")).
+					WillReturnError(&gomysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name: "Send claimed by another run",
+			mock: func(mock sqlmock.Sqlmock) {
+				expectLatest(mock, sqlmock.NewRows(reminderColumns).AddRow(5, 1, cartUpdatedAt, nil))
+				expectCart(mock, 0)
+				expectClaim(mock, 0)
+			},
+		},
+		{
+			// the claim is released so the next run sends the reminder
+			name:     "Mail not queued",
+			queueErr: errors.New("redis unreachable"),
+			mock: func(mock sqlmock.Sqlmock) {
+				expectLatest(mock, sqlmock.NewRows(reminderColumns).AddRow(5, 1, cartUpdatedAt, nil))
+				expectCart(mock, 0)
+				expectClaim(mock, 1)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `cart_reminders` SET `sent_at`=?,`updated_at`=? WHERE id = ?")).
+					WithArgs(nil, sqlmock.AnyArg(), 5).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := &fakeQueue{err: tt.queueErr}
+			SetRedisQueue(queue)
+			defer SetRedisQueue(nil)
+
+			gormDB, mock := AbandonedCartNewMockDB()
+			SetDB(gormDB)
+			defer SetDB(nil)
+			tt.mock(mock)
+
+			err := NewAbandonedCartJob(24, 0, 0, 0).remind(abandoned)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+			assert.Len(t, queue.queueIds, tt.wantMails)
+			for _, v := range queue.payloads {
+				assert.Equal(t, "email@mail.com", v["to"])
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/audit"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var AccountErasureJobQueueId = "account_erasure"
+
+// erasedOrderValue replaces the address and phone number snapshots of orders of an erased account
+const erasedOrderValue = "ERASED"
+
+// accountOwnedModels have rows of one account by account_id, the values of their audit logs hold the personal
+// data of the account, e.g. order address snapshots or the target of a verification
+var accountOwnedModels = []interface{}{
+	&model.AccountAddress{},
+	&model.AccountErasure{},
+	&model.AccountIdentity{},
+	&model.AccountRole{},
+	&model.AdminAction{},
+	&model.ApiKey{},
+	&model.Cart{},
+	&model.CartReminder{},
+	&model.ItemSubscription{},
+	&model.Order{},
+	&model.PasswordReset{},
+	&model.RecoveryCode{},
+	&model.RefreshToken{},
+	&model.Review{},
+	&model.Verification{},
+	&model.Voucher{},
+	&model.Wishlist{},
+}
+
+type AccountErasureJob struct {
+	ErasureID int `json:"erasure_id"`
+}
+
+func NewAccountErasureJob(erasureId int) *AccountErasureJob {
+	return &AccountErasureJob{
+		ErasureID: erasureId,
+	}
+}
+
+// Return the queue id for this job
+func (j *AccountErasureJob) QueueID() string { return AccountErasureJobQueueId }
+
+// Anonymise the account of the erasure and delete its personal data, orders stay for the books
+// with their address and phone number replaced. The erasure is marked COMPLETED or FAILED
+func (j *AccountErasureJob) Handle(ctx context.Context) error {
+	logCtx := log.WithFields(log.Fields{
+		"ErasureID": j.ErasureID,
+	})
+	logCtx.Info("Processing AccountErasureJob.Handle()")
+
+	if db == nil {
+		return errors.New("database is uninitialized")
+	}
+
+	accountErasureRepo := repository.NewAccountErasureRepository(db)
+	erasure, result := accountErasureRepo.OneById(j.ErasureID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		logCtx.Warn("account erasure not found")
+		return nil
+	}
+	if erasure.Status == "COMPLETED" {
+		logCtx.Info("account already erased")
+		return nil
+	}
+
+	if err := j.erase(ctx, int(erasure.AccountID)); err != nil {
+		logCtx.WithError(err).Error("failed to erase account")
+		if result := accountErasureRepo.MarkFailed(j.ErasureID, err.Error()); result.Error != nil {
+			logCtx.WithError(result.Error).Error("failed to mark account erasure failed")
+		}
+		return err
+	}
+
+	if result := accountErasureRepo.MarkCompleted(j.ErasureID); result.Error != nil {
+		return result.Error
+	}
+	logCtx.WithField("account_id", erasure.AccountID).Info("account erased")
+	return nil
+}
+
+func (j *AccountErasureJob) erase(ctx context.Context, accountId int) error {
+	accountRepo := repository.NewAccountRepository(db)
+	account, result := accountRepo.OneById(accountId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("account %d not found", accountId)
+	}
+
+	// the audit log keeps what changed but not the personal data itself
+	return db.WithContext(audit.WithoutValues(ctx)).Transaction(func(tx *gorm.DB) error {
+		return eraseAccount(tx, account)
+	})
+}
+
+// eraseAccount deletes and anonymises the rows of the account, the values of the audit logs of its rows are
+// cleared first while the rows they belong to can still be found
+func eraseAccount(tx *gorm.DB, account model.Account) error {
+	accountId := int(account.ID)
+	// placeholder for the email wherever the account stays referenced
+	erased := fmt.Sprintf("erased-%d", accountId)
+
+	auditLogRepo := repository.NewAuditLogRepository(tx)
+	if err := auditLogRepo.ClearValuesByAccountId(accountId, accountOwnedModels...); err != nil {
+		return err
+	}
+	if result := auditLogRepo.ClearValuesByEntity("accounts", strconv.Itoa(accountId)); result.Error != nil {
+		return result.Error
+	}
+
+	deletes := []func(accountId int) *gorm.DB{
+		repository.NewWishlistItemRepository(tx).DeleteByAccountId,
+		repository.NewWishlistRepository(tx).DeleteByAccountId,
+		repository.NewCartReminderRepository(tx).DeleteByAccountId,
+		repository.NewCartRepository(tx).DeleteByAccountId,
+		repository.NewItemSubscriptionRepository(tx).DeleteByAccountId,
+		repository.NewAccountAddressRepository(tx).DeleteByAccountId,
+		repository.NewVerificationRepository(tx).DeleteByAccountId,
+		repository.NewPasswordResetRepository(tx).DeleteByAccountId,
+		repository.NewRecoveryCodeRepository(tx).DeleteByAccountId,
+		repository.NewAccountIdentityRepository(tx).DeleteByAccountId,
+		repository.NewAccountRoleRepository(tx).DeleteByAccountId,
+		repository.NewRefreshTokenRepository(tx).DeleteByAccountId,
+	}
+	for _, deleteByAccountId := range deletes {
+		if result := deleteByAccountId(accountId); result.Error != nil {
+			return result.Error
+		}
+	}
+
+	if result := repository.NewApiKeyRepository(tx).RevokeByAccountId(accountId, erased); result.Error != nil {
+		return result.Error
+	}
+	if result := repository.NewVoucherRepository(tx).AnonymizeByAccountId(accountId); result.Error != nil {
+		return result.Error
+	}
+	if result := repository.NewOrderRepository(tx).AnonymizeByAccountId(accountId, erasedOrderValue); result.Error != nil {
+		return result.Error
+	}
+
+	accountRepo := repository.NewAccountRepository(tx)
+	if err := accountRepo.ReplaceAuditEmail(account.Email, erased,
+		&model.Account{},
+		&model.AccountErasure{},
+		&model.AdminAction{},
+		&model.ApiKey{},
+		&model.ApiKeyPermission{},
+		&model.Item{},
+		&model.Order{},
+		&model.OrderItem{},
+		&model.OrderVoucher{},
+		&model.Review{},
+		&model.ReviewImage{},
+		&model.Role{},
+		&model.RolePermission{},
+		&model.Voucher{},
+	); err != nil {
+		return err
+	}
+
+	if result := auditLogRepo.ReplaceActor(account.Email, erased); result.Error != nil {
+		return result.Error
+	}
+
+	result := accountRepo.Anonymize(accountId, fmt.Sprintf("%s@erased.invalid", erased), erased, audit.SystemActor)
+	return result.Error
+}
@@ -0,0 +1,130 @@
+package jobs
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// statementRecorder keeps the sql of every statement gorm builds
+type statementRecorder struct {
+	mu   sync.Mutex
+	sqls []string
+}
+
+func (r *statementRecorder) LogMode(logger.LogLevel) logger.Interface      { return r }
+func (r *statementRecorder) Info(context.Context, string, ...interface{})  {}
+func (r *statementRecorder) Warn(context.Context, string, ...interface{})  {}
+func (r *statementRecorder) Error(context.Context, string, ...interface{}) {}
+func (r *statementRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sqls = append(r.sqls, sql)
+}
+
+// index returns the position of the first statement containing all parts, -1 when none does
+func (r *statementRecorder) index(parts ...string) int {
+	for i, sql := range r.sqls {
+		found := true
+		for _, part := range parts {
+			if !strings.Contains(sql, part) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return i
+		}
+	}
+	return -1
+}
+
+func AccountErasureNewMockDB(recorder *statementRecorder) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, Logger: recorder})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_accountOwnedModels(t *testing.T) {
+	// every model of the schema, a new one with an account_id has to be added to accountOwnedModels
+	models := []interface{}{
+		&model.Account{}, &model.AccountAddress{}, &model.RecoveryCode{}, &model.AccountIdentity{}, &model.OidcState{},
+		&model.ApiKey{}, &model.ApiKeyPermission{}, &model.AccountErasure{}, &model.AdminAction{}, &model.AuditLog{},
+		&model.AccountRole{}, &model.Item{}, &model.ItemSubscription{}, &model.Cart{}, &model.CartReminder{}, &model.Order{},
+		&model.Voucher{},
+		&model.OrderItem{}, &model.OrderVoucher{}, &model.PasswordReset{}, &model.RefreshToken{}, &model.Review{},
+		&model.ReviewImage{}, &model.Role{}, &model.RolePermission{}, &model.SigningKey{}, &model.Verification{},
+		&model.Wishlist{}, &model.WishlistItem{},
+	}
+
+	owned := map[reflect.Type]bool{}
+	for _, m := range accountOwnedModels {
+		owned[reflect.TypeOf(m)] = true
+	}
+	for _, m := range models {
+		if _, ok := reflect.TypeOf(m).Elem().FieldByName("AccountID"); ok {
+			assert.True(t, owned[reflect.TypeOf(m)], "%T has an account id but its audit values are kept", m)
+		}
+	}
+}
+
+func Test_eraseAccount(t *testing.T) {
+	recorder := &statementRecorder{}
+	db, mock := AccountErasureNewMockDB(recorder)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	account := model.Account{ID: 7, Email: "email@mail.com"}
+	assert.NoError(t, db.Transaction(func(tx *gorm.DB) error {
+		return eraseAccount(tx, account)
+	}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// the audit values of the account and of every row it owns are cleared, before the rows are deleted
+	accountCleared := recorder.index("UPDATE `audit_logs` SET `after`='',`before`=''", "entity = 'accounts' AND entity_id = '7'")
+	assert.NotEqual(t, -1, accountCleared)
+	for _, table := range []string{"orders", "account_addresses", "verifications", "account_identities", "reviews", "vouchers"} {
+		cleared := recorder.index("UPDATE `audit_logs` SET `after`='',`before`=''", "entity = '"+table+"'", "SELECT CAST(id AS CHAR) FROM `"+table+"` WHERE account_id = 7")
+		assert.NotEqual(t, -1, cleared, table)
+
+		changed := -1
+		for i, sql := range recorder.sqls {
+			if !strings.Contains(sql, "audit_logs") && strings.Contains(sql, "`"+table+"`") {
+				changed = i
+				break
+			}
+		}
+		assert.Less(t, cleared, changed, table)
+	}
+
+	// the email is left nowhere but in the statements replacing it
+	for _, sql := range recorder.sqls {
+		if strings.Contains(sql, "email@mail.com") {
+			assert.Contains(t, sql, "erased-7", sql)
+		}
+	}
+}
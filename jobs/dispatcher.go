@@ -7,6 +7,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/taylorchu/work"
+	"gorm.io/gorm"
 )
 
 // The job namespace is assigned automatically using running binary name
@@ -19,6 +20,13 @@ func SetRedisQueue(q work.RedisQueue) {
 	redisQueue = q
 }
 
+// The database used by jobs, assigned by the worker
+var db *gorm.DB
+
+func SetDB(d *gorm.DB) {
+	db = d
+}
+
 type Job interface {
 	QueueID() string
 }
@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var ItemSubscriptionJobQueueId = "item_subscription"
+
+// ItemSubscriptionJob is one back in stock or price drop transition of an item, the item values are
+// the ones of the transition so a retry describes it the same way
+type ItemSubscriptionJob struct {
+	ItemID   int     `json:"item_id"`
+	Type     string  `json:"type"`
+	Name     string  `json:"name"`
+	Qty      int     `json:"qty"`
+	OldPrice float64 `json:"old_price"`
+	Price    float64 `json:"price"`
+}
+
+func NewItemSubscriptionJob(item model.Item, subscriptionType string, oldPrice float64) *ItemSubscriptionJob {
+	qty := 0
+	if item.Qty != nil {
+		qty = *item.Qty
+	}
+	return &ItemSubscriptionJob{
+		ItemID:   int(item.ID),
+		Type:     subscriptionType,
+		Name:     item.Name,
+		Qty:      qty,
+		OldPrice: oldPrice,
+		Price:    item.Price,
+	}
+}
+
+// Return the queue id for this job
+func (j *ItemSubscriptionJob) QueueID() string { return ItemSubscriptionJobQueueId }
+
+// Alert every active subscription of the transition by email and sms and mark it notified. Failed
+// subscriptions stay active and fail the job, its retry only reaches the ones not notified yet
+func (j *ItemSubscriptionJob) Handle(ctx context.Context) error {
+	logCtx := log.WithFields(log.Fields{
+		"ItemID": j.ItemID,
+		"Type":   j.Type,
+	})
+	logCtx.Info("Processing ItemSubscriptionJob.Handle()")
+
+	if db == nil {
+		return errors.New("database is uninitialized")
+	}
+
+	return notifyItemSubscriptions(db.WithContext(ctx), logCtx, j)
+}
+
+func notifyItemSubscriptions(db *gorm.DB, logCtx *log.Entry, j *ItemSubscriptionJob) error {
+	var subject, body string
+	switch j.Type {
+	case "BACK_IN_STOCK":
+		subject = fmt.Sprintf("%s is back in stock", j.Name)
+		body = fmt.Sprintf("<p><b>%s</b> is back in stock, %d left.</p>", html.EscapeString(j.Name), j.Qty)
+	case "PRICE_DROP":
+		subject = fmt.Sprintf("Price drop on %s", j.Name)
+		body = fmt.Sprintf("<p>The price of <b>%s</b> dropped from %.2f to %.2f.</p>", html.EscapeString(j.Name), j.OldPrice, j.Price)
+	default:
+		logCtx.Warn("unknown item subscription type")
+		return nil
+	}
+
+	itemSubscriptionRepo := repository.NewItemSubscriptionRepository(db)
+	subscriptions, result := itemSubscriptionRepo.AllActiveByItemIdAndType(j.ItemID, j.Type, "Account")
+	if result.Error != nil {
+		return result.Error
+	}
+
+	var failed int
+	for _, v := range subscriptions {
+		if v.TargetPrice != nil && j.Price > *v.TargetPrice {
+			continue
+		}
+		if v.Account == nil || v.Account.SuspendedAt != nil {
+			continue
+		}
+		if err := notifyItemSubscription(itemSubscriptionRepo, v, subject, body); err != nil {
+			logCtx.WithField("item_subscription_id", v.ID).WithError(err).Error("failed to notify item subscription")
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d item subscriptions not notified", failed, len(subscriptions))
+	}
+	return nil
+}
+
+func notifyItemSubscription(itemSubscriptionRepo *repository.ItemSubscriptionRepository, subscription model.ItemSubscription, subject string, body string) error {
+	if err := Dispatch(NewSendEmailJob(subscription.Account.Email, subject, body)); err != nil {
+		return err
+	}
+	// the email is the alert, a missing sms is not worth alerting twice by email
+	if subscription.Account.PhoneNumber != nil && *subscription.Account.PhoneNumber != "" {
+		if err := Dispatch(NewSendSMSJob(*subscription.Account.PhoneNumber, subject)); err != nil {
+			log.WithField("item_subscription_id", subscription.ID).WithError(err).Error("failed to dispatch sms")
+		}
+	}
+	return itemSubscriptionRepo.MarkNotified(int(subscription.ID)).Error
+}
@@ -0,0 +1,117 @@
+package jobs
+
+import (
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/taylorchu/work"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// fakeQueue keeps the jobs dispatched to it, or refuses them when err is set
+type fakeQueue struct {
+	work.RedisQueue
+	mu       sync.Mutex
+	err      error
+	queueIds []string
+	payloads []map[string]interface{}
+}
+
+func (q *fakeQueue) Enqueue(job *work.Job, opts *work.EnqueueOptions) error {
+	if q.err != nil {
+		return q.err
+	}
+	var payload map[string]interface{}
+	if err := job.UnmarshalJSONPayload(&payload); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.queueIds = append(q.queueIds, opts.QueueID)
+	q.payloads = append(q.payloads, payload)
+	return nil
+}
+
+func ItemSubscriptionNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+// not parallel, the queue of Dispatch is shared by the package
+func Test_notifyItemSubscriptions(t *testing.T) {
+	priceDrop := &ItemSubscriptionJob{ItemID: 2, Type: "PRICE_DROP", Name: "Book", OldPrice: 10, Price: 7}
+
+	expectSubscriptions := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `item_subscriptions` WHERE (item_id = ? AND type = ? AND notified_at IS NULL)")).
+			WithArgs(2, "PRICE_DROP").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "item_id", "type", "target_price"}).
+				AddRow(1, 1, 2, "PRICE_DROP", nil).
+				AddRow(2, 2, 2, "PRICE_DROP", 5).
+				AddRow(3, 3, 2, "PRICE_DROP", 8).
+				AddRow(4, 4, 2, "PRICE_DROP", nil))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE `accounts`.`id` IN (?,?,?,?)")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "email", "phone_number", "suspended_at"}).
+				AddRow(1, "one@mail.com", "0811", nil).
+				AddRow(2, "two@mail.com", nil, nil).
+				AddRow(3, "three@mail.com", nil, nil).
+				AddRow(4, "four@mail.com", nil, time.Now()))
+	}
+
+	t.Run("Alert subscriptions the drop reaches", func(t *testing.T) {
+		queue := &fakeQueue{}
+		SetRedisQueue(queue)
+		defer SetRedisQueue(nil)
+
+		db, mock := ItemSubscriptionNewMockDB()
+		expectSubscriptions(mock)
+		// the target price of 5 is not reached and account 4 is suspended
+		for _, id := range []int{1, 3} {
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE `item_subscriptions` SET `notified_at`=?")).
+				WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), id).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+		}
+
+		assert.NoError(t, notifyItemSubscriptions(db, log.WithField("test", t.Name()), priceDrop))
+		assert.Equal(t, []string{SendEmailJobQueueId, SendSMSJobQueueId, SendEmailJobQueueId}, queue.queueIds)
+		assert.Equal(t, "one@mail.com", queue.payloads[0]["to"])
+		assert.Equal(t, "0811", queue.payloads[1]["to"])
+		assert.Equal(t, "three@mail.com", queue.payloads[2]["to"])
+		assert.Contains(t, queue.payloads[0]["body_html"], "dropped from 10.00 to 7.00")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Keep subscriptions active when the alert is not dispatched", func(t *testing.T) {
+		queue := &fakeQueue{err: errors.New("redis unreachable")}
+		SetRedisQueue(queue)
+		defer SetRedisQueue(nil)
+
+		db, mock := ItemSubscriptionNewMockDB()
+		expectSubscriptions(mock)
+
+		// the failed job is retried, nothing was marked notified
+		assert.Error(t, notifyItemSubscriptions(db, log.WithField("test", t.Name()), priceDrop))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,23 @@
+package jobs
+
+import "github.com/avarian/online-shopping-cart/util"
+
+// Mailer and sender used by email jobs, dummy until the worker assigns one
+var (
+	mailer       util.Mailer = util.NewDummyMailer()
+	mailFromName string
+	mailFrom     string
+)
+
+// Messenger used by sms jobs, dummy until the worker assigns one
+var messenger util.Messenger = util.NewDummyMessenger()
+
+func SetMailer(m util.Mailer, fromName string, from string) {
+	mailer = m
+	mailFromName = fromName
+	mailFrom = from
+}
+
+func SetMessenger(m util.Messenger) {
+	messenger = m
+}
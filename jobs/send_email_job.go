@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var SendEmailJobQueueId = "send_email"
+
+type SendEmailJob struct {
+	To       string `json:"to"`
+	Subject  string `json:"subject"`
+	BodyHtml string `json:"body_html"`
+}
+
+func NewSendEmailJob(to string, subject string, bodyHtml string) *SendEmailJob {
+	return &SendEmailJob{
+		To:       to,
+		Subject:  subject,
+		BodyHtml: bodyHtml,
+	}
+}
+
+// Return the queue id for this job
+func (j *SendEmailJob) QueueID() string { return SendEmailJobQueueId }
+
+// Send the email through the assigned mailer
+func (j *SendEmailJob) Handle(ctx context.Context) error {
+	log.WithFields(log.Fields{
+		"To":      j.To,
+		"Subject": j.Subject,
+	}).Info("Processing SendEmailJob.Handle()")
+	return mailer.SendEmail(mailFromName, mailFrom, j.Subject, j.To, j.BodyHtml)
+}
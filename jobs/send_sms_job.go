@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var SendSMSJobQueueId = "send_sms"
+
+type SendSMSJob struct {
+	To   string `json:"to"`
+	Text string `json:"text"`
+}
+
+func NewSendSMSJob(to string, text string) *SendSMSJob {
+	return &SendSMSJob{
+		To:   to,
+		Text: text,
+	}
+}
+
+// Return the queue id for this job
+func (j *SendSMSJob) QueueID() string { return SendSMSJobQueueId }
+
+// Send the sms through the assigned messenger
+func (j *SendSMSJob) Handle(ctx context.Context) error {
+	log.WithFields(log.Fields{
+		"To": j.To,
+	}).Info("Processing SendSMSJob.Handle()")
+	return messenger.SendSMS(j.To, j.Text)
+}
@@ -7,19 +7,27 @@ import (
 )
 
 type Account struct {
-	ID          uint            `json:"id" gorm:"not null"`
-	Name        string          `json:"name" gorm:"not null;size:255"`
-	Email       string          `json:"email" gorm:"size:255;unique"`
-	PhoneNumber string          `json:"phone_number" gorm:"size:255;unique"`
-	Password    string          `json:"-" gorm:"size:255"`
-	Address     string          `json:"address" gorm:"size:255"`
-	Type        string          `json:"type" gorm:"size:255;default:CUSTOMER"`
-	CreatedBy   string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
-	UpdatedBy   string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
-	DeletedBy   *string         `json:"deleted_by" gorm:"size:255"`
-	CreatedAt   *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
-	UpdatedAt   *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
-	DeletedAt   *gorm.DeletedAt `json:"deleted_at"`
+	ID                    uint            `json:"id" gorm:"not null"`
+	Name                  string          `json:"name" gorm:"not null;size:255"`
+	Email                 string          `json:"email" gorm:"size:255;unique"`
+	PhoneNumber           *string         `json:"phone_number" gorm:"size:255;unique"`
+	Password              string          `json:"-" gorm:"size:255"`
+	Address               string          `json:"address" gorm:"size:255"`
+	Type                  string          `json:"type" gorm:"size:255;default:CUSTOMER"`
+	EmailVerifiedAt       *time.Time      `json:"email_verified_at"`
+	PhoneVerifiedAt       *time.Time      `json:"phone_verified_at"`
+	SuspendedAt           *time.Time      `json:"suspended_at"`
+	PasswordResetRequired bool            `json:"password_reset_required" gorm:"not null;default:false"`
+	TotpSecret            *string         `json:"-" gorm:"size:64"`
+	TotpEnabledAt         *time.Time      `json:"totp_enabled_at"`
+	TotpLastStep          int64           `json:"-" gorm:"not null;default:0"`
+	CreatedBy             string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
+	UpdatedBy             string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
+	DeletedBy             *string         `json:"deleted_by" gorm:"size:255"`
+	CreatedAt             *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt             *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
+	DeletedAt             *gorm.DeletedAt `json:"deleted_at"`
 
-	Cart *Cart `json:"cart,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:AccountID;references:ID"`
+	Cart           *Cart            `json:"cart,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:AccountID;references:ID"`
+	AccountAddress []AccountAddress `json:"account_address,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:AccountID;references:ID"`
 }
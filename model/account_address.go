@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AccountAddress struct {
+	ID            uint            `json:"id" gorm:"not null"`
+	AccountID     uint            `json:"account_id" gorm:"not null"`
+	Label         string          `json:"label" gorm:"not null;size:64"`
+	RecipientName string          `json:"recipient_name" gorm:"not null;size:255"`
+	PhoneNumber   string          `json:"phone_number" gorm:"not null;size:255"`
+	Address       string          `json:"address" gorm:"not null;size:255"`
+	IsDefault     bool            `json:"is_default" gorm:"not null;default:false"`
+	CreatedBy     string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
+	UpdatedBy     string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
+	DeletedBy     *string         `json:"deleted_by" gorm:"size:255"`
+	CreatedAt     *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt     *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
+	DeletedAt     *gorm.DeletedAt `json:"deleted_at"`
+
+	Account *Account `json:"account,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:AccountID;references:ID"`
+}
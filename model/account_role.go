@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AccountRole struct {
+	ID        uint            `json:"id" gorm:"not null"`
+	AccountID uint            `json:"account_id" gorm:"not null;uniqueIndex:idx_account_role_key"`
+	RoleID    uint            `json:"role_id" gorm:"not null;uniqueIndex:idx_account_role_key"`
+	CreatedBy string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
+	UpdatedBy string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
+	DeletedBy *string         `json:"deleted_by" gorm:"size:255"`
+	CreatedAt *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
+	DeletedAt *gorm.DeletedAt `json:"deleted_at"`
+
+	Account *Account `json:"account,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:AccountID;references:ID"`
+	Role    *Role    `json:"role,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:RoleID;references:ID"`
+}
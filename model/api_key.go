@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ApiKey struct {
+	ID         uint            `json:"id" gorm:"not null"`
+	AccountID  uint            `json:"account_id" gorm:"not null"`
+	Name       string          `json:"name" gorm:"not null;size:255"`
+	Prefix     string          `json:"prefix" gorm:"not null;size:16;index"`
+	KeyHash    string          `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	ExpiredAt  *time.Time      `json:"expired_at"`
+	LastUsedAt *time.Time      `json:"last_used_at"`
+	LastUsedIp string          `json:"last_used_ip" gorm:"size:64"`
+	RevokedAt  *time.Time      `json:"revoked_at"`
+	CreatedBy  string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
+	UpdatedBy  string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
+	DeletedBy  *string         `json:"deleted_by" gorm:"size:255"`
+	CreatedAt  *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt  *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
+	DeletedAt  *gorm.DeletedAt `json:"deleted_at"`
+
+	Account          *Account           `json:"account,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:AccountID;references:ID"`
+	ApiKeyPermission []ApiKeyPermission `json:"api_key_permission,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:ApiKeyID;references:ID"`
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AuditLog struct {
+	ID        uint            `json:"id" gorm:"not null"`
+	Actor     string          `json:"actor" gorm:"not null;size:255;index"`
+	Action    string          `json:"action" gorm:"not null;size:16"`
+	Entity    string          `json:"entity" gorm:"not null;size:255;index:idx_audit_logs_entity"`
+	EntityID  string          `json:"entity_id" gorm:"size:64;index:idx_audit_logs_entity"`
+	Before    string          `json:"before" gorm:"type:text"`
+	After     string          `json:"after" gorm:"type:text"`
+	RequestID string          `json:"request_id" gorm:"size:64;index"`
+	ClientIP  string          `json:"client_ip" gorm:"size:64"`
+	CreatedBy string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
+	UpdatedBy string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
+	DeletedBy *string         `json:"deleted_by" gorm:"size:255"`
+	CreatedAt *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
+	DeletedAt *gorm.DeletedAt `json:"deleted_at"`
+}
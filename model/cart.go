@@ -7,16 +7,19 @@ import (
 )
 
 type Cart struct {
-	ID        uint            `json:"id" gorm:"not null"`
-	AccountID uint            `json:"account_id" gorm:"uniqueIndex:idx_account_item_key;not null"`
-	ItemID    uint            `json:"item_id" gorm:"uniqueIndex:idx_account_item_key;not null"`
-	Qty       int             `json:"qty"  gorm:"not null"`
-	CreatedBy string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
-	UpdatedBy string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
-	DeletedBy *string         `json:"deleted_by" gorm:"size:255"`
-	CreatedAt *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
-	UpdatedAt *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
-	DeletedAt *gorm.DeletedAt `json:"deleted_at"`
+	ID            uint            `json:"id" gorm:"not null"`
+	AccountID     *uint           `json:"account_id" gorm:"uniqueIndex:idx_account_item_key"`
+	GuestID       *string         `json:"guest_id" gorm:"size:64;uniqueIndex:idx_guest_item_key"`
+	ItemID        uint            `json:"item_id" gorm:"uniqueIndex:idx_account_item_key;uniqueIndex:idx_guest_item_key;not null"`
+	Qty           int             `json:"qty"  gorm:"not null"`
+	Price         float64         `json:"price" gorm:"not null;default:0"`
+	SavedForLater bool            `json:"saved_for_later" gorm:"not null;default:false"`
+	CreatedBy     string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
+	UpdatedBy     string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
+	DeletedBy     *string         `json:"deleted_by" gorm:"size:255"`
+	CreatedAt     *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt     *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
+	DeletedAt     *gorm.DeletedAt `json:"deleted_at"`
 
 	Account *Item `json:"account,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:AccountID;references:ID"`
 	Item    *Item `json:"item,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:ItemID;references:ID"`
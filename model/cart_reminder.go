@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CartReminder struct {
+	ID            uint            `json:"id" gorm:"not null"`
+	AccountID     uint            `json:"account_id" gorm:"not null;uniqueIndex:idx_cart_reminder_key"`
+	VoucherID     *uint           `json:"voucher_id"`
+	CartUpdatedAt time.Time       `json:"cart_updated_at" gorm:"not null;uniqueIndex:idx_cart_reminder_key"`
+	SentAt        *time.Time      `json:"sent_at"`
+	CreatedBy     string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
+	UpdatedBy     string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
+	DeletedBy     *string         `json:"deleted_by" gorm:"size:255"`
+	CreatedAt     *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt     *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
+	DeletedAt     *gorm.DeletedAt `json:"deleted_at"`
+
+	Account *Account `json:"account,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:AccountID;references:ID"`
+	Voucher *Voucher `json:"voucher,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:VoucherID;references:ID"`
+}
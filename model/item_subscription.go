@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ItemSubscription struct {
+	ID          uint            `json:"id" gorm:"not null"`
+	AccountID   uint            `json:"account_id" gorm:"not null"`
+	ItemID      uint            `json:"item_id" gorm:"not null"`
+	Type        string          `json:"type" gorm:"not null;size:255"`
+	TargetPrice *float64        `json:"target_price"`
+	NotifiedAt  *time.Time      `json:"notified_at"`
+	CreatedBy   string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
+	UpdatedBy   string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
+	DeletedBy   *string         `json:"deleted_by" gorm:"size:255"`
+	CreatedAt   *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt   *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
+	DeletedAt   *gorm.DeletedAt `json:"deleted_at"`
+
+	Account *Account `json:"account,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:AccountID;references:ID"`
+	Item    *Item    `json:"item,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:ItemID;references:ID"`
+}
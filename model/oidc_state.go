@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OidcState struct {
+	ID           uint            `json:"id" gorm:"not null"`
+	Provider     string          `json:"provider" gorm:"not null;size:64"`
+	StateHash    string          `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	Nonce        string          `json:"-" gorm:"not null;size:64"`
+	CodeVerifier string          `json:"-" gorm:"not null;size:128"`
+	ExpiredAt    time.Time       `json:"expired_at" gorm:"not null"`
+	UsedAt       *time.Time      `json:"used_at"`
+	CreatedBy    string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
+	UpdatedBy    string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
+	DeletedBy    *string         `json:"deleted_by" gorm:"size:255"`
+	CreatedAt    *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt    *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
+	DeletedAt    *gorm.DeletedAt `json:"deleted_at"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PasswordReset struct {
+	ID        uint            `json:"id" gorm:"not null"`
+	AccountID uint            `json:"account_id" gorm:"not null"`
+	TokenHash string          `json:"-" gorm:"not null;size:64;uniqueIndex"`
+	ExpiredAt time.Time       `json:"expired_at" gorm:"not null"`
+	UsedAt    *time.Time      `json:"used_at"`
+	CreatedBy string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
+	UpdatedBy string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
+	DeletedBy *string         `json:"deleted_by" gorm:"size:255"`
+	CreatedAt *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
+	DeletedAt *gorm.DeletedAt `json:"deleted_at"`
+
+	Account *Account `json:"account,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:AccountID;references:ID"`
+}
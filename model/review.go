@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Review struct {
+	ID        uint            `json:"id" gorm:"not null"`
+	ItemID    uint            `json:"item_id" gorm:"uniqueIndex:idx_review_item_account_key;not null"`
+	AccountID uint            `json:"account_id" gorm:"uniqueIndex:idx_review_item_account_key;not null"`
+	Rating    int             `json:"rating" gorm:"not null"`
+	Title     string          `json:"title" gorm:"not null;size:255"`
+	Body      string          `json:"body"`
+	Status    string          `json:"status" gorm:"not null;size:255;default:PENDING"`
+	CreatedBy string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
+	UpdatedBy string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
+	DeletedBy *string         `json:"deleted_by" gorm:"size:255"`
+	CreatedAt *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
+	DeletedAt *gorm.DeletedAt `json:"deleted_at"`
+
+	Item        *Item         `json:"item,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:ItemID;references:ID"`
+	Account     *Account      `json:"account,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:AccountID;references:ID"`
+	ReviewImage []ReviewImage `json:"review_image,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:ReviewID;references:ID"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Role struct {
+	ID          uint            `json:"id" gorm:"not null"`
+	Name        string          `json:"name" gorm:"not null;size:64;unique"`
+	Description string          `json:"description" gorm:"size:255"`
+	CreatedBy   string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
+	UpdatedBy   string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
+	DeletedBy   *string         `json:"deleted_by" gorm:"size:255"`
+	CreatedAt   *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt   *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
+	DeletedAt   *gorm.DeletedAt `json:"deleted_at"`
+
+	RolePermission []RolePermission `json:"role_permission,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:RoleID;references:ID"`
+}
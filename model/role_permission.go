@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RolePermission struct {
+	ID         uint            `json:"id" gorm:"not null"`
+	RoleID     uint            `json:"role_id" gorm:"not null;uniqueIndex:idx_role_permission_key"`
+	Permission string          `json:"permission" gorm:"not null;size:64;uniqueIndex:idx_role_permission_key"`
+	CreatedBy  string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
+	UpdatedBy  string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
+	DeletedBy  *string         `json:"deleted_by" gorm:"size:255"`
+	CreatedAt  *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt  *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
+	DeletedAt  *gorm.DeletedAt `json:"deleted_at"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SigningKey struct {
+	ID         uint            `json:"id" gorm:"not null"`
+	Kid        string          `json:"kid" gorm:"not null;size:64;uniqueIndex"`
+	Algorithm  string          `json:"algorithm" gorm:"not null;size:16"`
+	PrivateKey string          `json:"-" gorm:"not null;type:text"`
+	PublicKey  string          `json:"public_key" gorm:"not null;type:text"`
+	Status     string          `json:"status" gorm:"not null;size:16;default:ACTIVE"`
+	RetiredAt  *time.Time      `json:"retired_at"`
+	CreatedBy  string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
+	UpdatedBy  string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
+	DeletedBy  *string         `json:"deleted_by" gorm:"size:255"`
+	CreatedAt  *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt  *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
+	DeletedAt  *gorm.DeletedAt `json:"deleted_at"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Verification struct {
+	ID        uint            `json:"id" gorm:"not null"`
+	AccountID uint            `json:"account_id" gorm:"not null"`
+	Channel   string          `json:"channel" gorm:"not null;size:16"`
+	Target    string          `json:"target" gorm:"not null;size:255"`
+	CodeHash  string          `json:"-" gorm:"not null;size:64;index"`
+	Attempts  int             `json:"attempts" gorm:"not null;default:0"`
+	ExpiredAt time.Time       `json:"expired_at" gorm:"not null"`
+	UsedAt    *time.Time      `json:"used_at"`
+	CreatedBy string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
+	UpdatedBy string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
+	DeletedBy *string         `json:"deleted_by" gorm:"size:255"`
+	CreatedAt *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
+	DeletedAt *gorm.DeletedAt `json:"deleted_at"`
+
+	Account *Account `json:"account,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:AccountID;references:ID"`
+}
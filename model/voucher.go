@@ -13,6 +13,9 @@ type Voucher struct {
 	Description string          `json:"description"`
 	Percentage  float64         `json:"percentage" gorm:"not null;size:255"`
 	Max         float64         `json:"price"  gorm:"not null;size:255"`
+	AccountID   *uint           `json:"account_id"`
+	SingleUse   bool            `json:"single_use" gorm:"not null;default:false"`
+	ExpiredAt   *time.Time      `json:"expired_at"`
 	CreatedBy   string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
 	UpdatedBy   string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
 	DeletedBy   string          `json:"deleted_by" gorm:"size:255"`
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Wishlist struct {
+	ID         uint            `json:"id" gorm:"not null"`
+	AccountID  uint            `json:"account_id" gorm:"uniqueIndex:idx_wishlist_account_name_key;not null"`
+	Name       string          `json:"name" gorm:"uniqueIndex:idx_wishlist_account_name_key;not null;size:255"`
+	ShareToken *string         `json:"share_token" gorm:"size:255;unique"`
+	CreatedBy  string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
+	UpdatedBy  string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
+	DeletedBy  *string         `json:"deleted_by" gorm:"size:255"`
+	CreatedAt  *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt  *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
+	DeletedAt  *gorm.DeletedAt `json:"deleted_at"`
+
+	Account      *Account       `json:"account,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:AccountID;references:ID"`
+	WishlistItem []WishlistItem `json:"wishlist_item,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:WishlistID;references:ID"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WishlistItem struct {
+	ID         uint            `json:"id" gorm:"not null"`
+	WishlistID uint            `json:"wishlist_id" gorm:"uniqueIndex:idx_wishlist_item_key;not null"`
+	ItemID     uint            `json:"item_id" gorm:"uniqueIndex:idx_wishlist_item_key;not null"`
+	CreatedBy  string          `json:"created_by" gorm:"size:255;default:SYSTEM"`
+	UpdatedBy  string          `json:"updated_by" gorm:"size:255;default:SYSTEM"`
+	DeletedBy  *string         `json:"deleted_by" gorm:"size:255"`
+	CreatedAt  *time.Time      `json:"created_at" gorm:"default:current_timestamp"`
+	UpdatedAt  *time.Time      `json:"updated_at" gorm:"default:current_timestamp"`
+	DeletedAt  *gorm.DeletedAt `json:"deleted_at"`
+
+	Wishlist *Wishlist `json:"wishlist,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:WishlistID;references:ID"`
+	Item     *Item     `json:"item,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:RESTRICT;foreignKey:ItemID;references:ID"`
+}
@@ -0,0 +1,129 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Kind is the class of an error, it decides the http status the error is answered with
+type Kind int
+
+const (
+	Internal Kind = iota
+	Invalid
+	Unauthorized
+	Forbidden
+	NotFound
+	Conflict
+	PreconditionFailed
+	TooManyRequests
+	BadGateway
+)
+
+var statuses = map[Kind]int{
+	Internal:           http.StatusInternalServerError,
+	Invalid:            http.StatusUnprocessableEntity,
+	Unauthorized:       http.StatusUnauthorized,
+	Forbidden:          http.StatusForbidden,
+	NotFound:           http.StatusNotFound,
+	Conflict:           http.StatusConflict,
+	PreconditionFailed: http.StatusPreconditionFailed,
+	TooManyRequests:    http.StatusTooManyRequests,
+	BadGateway:         http.StatusBadGateway,
+}
+
+// Status is the http status errors of the kind are answered with
+func (k Kind) Status() int {
+	if status, ok := statuses[k]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error is an error the api answers with. Code is stable for clients to match on, Message is for people,
+// Err is the cause and is only logged
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Details map[string]interface{}
+	Fields  map[string]string
+	Err     error
+}
+
+var (
+	ErrInternal     = New(Internal, "internal_error", "something went wrong, try again later")
+	ErrInvalidBody  = New(Invalid, "invalid_body", "request body is invalid")
+	ErrValidation   = New(Invalid, "validation_failed", "request is invalid")
+	ErrUnauthorized = New(Unauthorized, "unauthorized", "request does not contain a valid access token")
+	ErrForbidden    = New(Forbidden, "missing_permission", "missing permission")
+)
+
+func New(kind Kind, code string, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: message,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches errors of the same code, so copies made by Wrap and WithDetail still match their sentinel
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of e caused by err
+func (e *Error) Wrap(err error) *Error {
+	c := e.copy()
+	c.Err = err
+	return c
+}
+
+// WithDetail returns a copy of e with the detail added
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	c := e.copy()
+	c.Details = make(map[string]interface{}, len(e.Details)+1)
+	for k, v := range e.Details {
+		c.Details[k] = v
+	}
+	c.Details[key] = value
+	return c
+}
+
+// WithFields returns a copy of e with a message per invalid field
+func (e *Error) WithFields(fields map[string]string) *Error {
+	c := e.copy()
+	c.Fields = fields
+	return c
+}
+
+func (e *Error) copy() *Error {
+	c := *e
+	return &c
+}
+
+// As returns the Error in the chain of err, or fallback caused by err when there is none
+func As(err error, fallback *Error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return fallback.Wrap(err)
+}
+
+// From returns the Error in the chain of err, errors of unknown kind are internal
+func From(err error) *Error {
+	return As(err, ErrInternal)
+}
@@ -0,0 +1,83 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/avarian/online-shopping-cart/service/audit"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Error(t *testing.T) {
+	errNotFound := New(NotFound, "item_not_found", "item not found")
+
+	t.Run("Copies match their sentinel", func(t *testing.T) {
+		cause := errors.New("record not found")
+		err := errNotFound.Wrap(cause).WithDetail("id", 1)
+
+		assert.ErrorIs(t, err, errNotFound)
+		assert.ErrorIs(t, err, cause)
+		assert.Nil(t, errNotFound.Details)
+		assert.Equal(t, map[string]interface{}{"id": 1}, err.Details)
+		assert.Equal(t, http.StatusNotFound, err.Kind.Status())
+	})
+
+	t.Run("Resolve wrapped errors", func(t *testing.T) {
+		err := fmt.Errorf("find item: %w", errNotFound)
+
+		assert.Equal(t, errNotFound, From(err))
+		assert.Equal(t, errNotFound, As(err, ErrUnauthorized))
+	})
+
+	t.Run("Unknown errors are internal", func(t *testing.T) {
+		cause := errors.New("connection refused")
+		err := From(cause)
+
+		assert.ErrorIs(t, err, ErrInternal)
+		assert.ErrorIs(t, err, cause)
+		assert.Equal(t, http.StatusInternalServerError, err.Kind.Status())
+	})
+}
+
+func Test_Abort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Answer with the envelope", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request = req.WithContext(audit.WithInfo(req.Context(), audit.Info{RequestID: "req-1"}))
+
+		Abort(c, ErrValidation.WithFields(map[string]string{"email": "email is a required field"}))
+
+		var body Response
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.True(t, c.IsAborted())
+		assert.Equal(t, ResponseError{
+			Code:      "validation_failed",
+			Message:   "request is invalid",
+			Fields:    map[string]string{"email": "email is a required field"},
+			RequestID: "req-1",
+		}, body.Error)
+	})
+
+	t.Run("Hide the message of unknown errors", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+		Abort(c, errors.New("dial tcp: connection refused"))
+
+		var body Response
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, ErrInternal.Code, body.Error.Code)
+		assert.Equal(t, ErrInternal.Message, body.Error.Message)
+	})
+}
@@ -0,0 +1,62 @@
+package apperror
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/avarian/online-shopping-cart/service/audit"
+	"github.com/gin-gonic/gin"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// Response is the body of every error answer
+type Response struct {
+	Error ResponseError `json:"error"`
+}
+
+type ResponseError struct {
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	Fields    map[string]string      `json:"fields,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+}
+
+// Abort answers the request with err and stops the handler chain, errors of unknown kind are answered as
+// internal without their message
+func Abort(c *gin.Context, err error) {
+	e := From(err)
+	c.AbortWithStatusJSON(e.Kind.Status(), Response{
+		Error: ResponseError{
+			Code:      e.Code,
+			Message:   e.Message,
+			Details:   e.Details,
+			Fields:    e.Fields,
+			RequestID: audit.FromContext(c.Request.Context()).RequestID,
+		},
+	})
+}
+
+// InvalidBody is the error of a request body or query that could not be bound
+func InvalidBody(err error) *Error {
+	return ErrInvalidBody.Wrap(err).WithDetail("reason", err.Error())
+}
+
+// Validation is the error of a request failing validation, with the translated message of every field
+// keyed by its path in the request, e.g. carts[0].qty
+func Validation(err error, trans ut.Translator) *Error {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return ErrValidation.Wrap(err)
+	}
+	fields := make(map[string]string, len(errs))
+	for _, v := range errs {
+		field := v.Namespace()
+		if _, path, ok := strings.Cut(field, "."); ok {
+			field = path
+		}
+		fields[field] = v.Translate(trans)
+	}
+	return ErrValidation.Wrap(err).WithFields(fields)
+}
@@ -0,0 +1,281 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	ActionCreate = "CREATE"
+	ActionUpdate = "UPDATE"
+	ActionDelete = "DELETE"
+)
+
+// redactedValue replaces values of columns hidden from json, e.g. password and token hashes
+const redactedValue = "[REDACTED]"
+
+const beforeRowsKey = "audit:before_rows"
+
+var auditLogTable = "audit_logs"
+
+// Register logs every create, update and delete made through db to audit_logs, in the transaction of
+// the change, and fills the created_by, updated_by and deleted_by columns. The actor and request
+// come from the context of the statement, see WithInfo
+func Register(db *gorm.DB) error {
+	if err := registerColumns(db); err != nil {
+		return err
+	}
+	if err := db.Callback().Create().After("gorm:create").Before("gorm:commit_or_rollback_transaction").
+		Register("audit:after_create", afterCreate); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:setup_reflect_value").Before("gorm:update").
+		Register("audit:before_update", captureBefore); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Before("gorm:commit_or_rollback_transaction").
+		Register("audit:after_update", afterUpdate); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").
+		Register("audit:before_delete", captureBefore); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:delete").Before("gorm:commit_or_rollback_transaction").
+		Register("audit:after_delete", afterDelete)
+}
+
+func audited(db *gorm.DB) bool {
+	stmt := db.Statement
+	return db.Error == nil && stmt.Schema != nil && stmt.Schema.PrioritizedPrimaryField != nil && stmt.Table != auditLogTable
+}
+
+func afterCreate(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+
+	stmt := db.Statement
+	var rows []reflect.Value
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Struct:
+		rows = append(rows, stmt.ReflectValue)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			rows = append(rows, reflect.Indirect(stmt.ReflectValue.Index(i)))
+		}
+	}
+
+	for _, rv := range rows {
+		after := map[string]interface{}{}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			after[field.DBName], _ = field.ValueOf(stmt.Context, rv)
+		}
+		write(db, ActionCreate, after, nil, after)
+	}
+}
+
+// captureBefore keeps the rows the update or delete is about to change
+func captureBefore(db *gorm.DB) {
+	if !audited(db) {
+		return
+	}
+
+	stmt := db.Statement
+	tx := db.Session(&gorm.Session{NewDB: true}).Model(reflect.New(stmt.Schema.ModelType).Interface())
+	if stmt.Unscoped {
+		tx = tx.Unscoped()
+	}
+
+	conditions := false
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+			tx = tx.Clauses(where)
+			conditions = true
+		}
+	}
+	if ids := primaryKeys(stmt); len(ids) > 0 {
+		tx = tx.Where(clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: stmt.Schema.PrioritizedPrimaryField.DBName}, Values: ids})
+		conditions = true
+	}
+	// gorm refuses it anyway unless global updates are allowed, those are not logged
+	if !conditions {
+		return
+	}
+
+	var rows []map[string]interface{}
+	if err := tx.Find(&rows).Error; err != nil {
+		db.AddError(err)
+		return
+	}
+	db.InstanceSet(beforeRowsKey, rows)
+}
+
+func afterUpdate(db *gorm.DB) {
+	if !audited(db) || db.RowsAffected == 0 {
+		return
+	}
+	before := beforeRows(db)
+	if len(before) == 0 {
+		return
+	}
+
+	pk := db.Statement.Schema.PrioritizedPrimaryField.DBName
+	ids := make([]interface{}, 0, len(before))
+	for _, v := range before {
+		ids = append(ids, v[pk])
+	}
+	var rows []map[string]interface{}
+	if err := db.Session(&gorm.Session{NewDB: true}).Model(reflect.New(db.Statement.Schema.ModelType).Interface()).Unscoped().
+		Where(clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: pk}, Values: ids}).Find(&rows).Error; err != nil {
+		db.AddError(err)
+		return
+	}
+	after := map[string]map[string]interface{}{}
+	for _, v := range rows {
+		after[fmt.Sprint(v[pk])] = v
+	}
+
+	for _, old := range before {
+		updated, ok := after[fmt.Sprint(old[pk])]
+		if !ok {
+			continue
+		}
+		changedBefore := map[string]interface{}{}
+		changedAfter := map[string]interface{}{}
+		for column, value := range updated {
+			if column == "updated_at" || reflect.DeepEqual(normalize(old[column]), normalize(value)) {
+				continue
+			}
+			changedBefore[column] = old[column]
+			changedAfter[column] = value
+		}
+		if len(changedAfter) == 0 {
+			continue
+		}
+		write(db, ActionUpdate, old, changedBefore, changedAfter)
+	}
+}
+
+func afterDelete(db *gorm.DB) {
+	if !audited(db) || db.RowsAffected == 0 {
+		return
+	}
+	for _, v := range beforeRows(db) {
+		write(db, ActionDelete, v, v, nil)
+	}
+}
+
+func beforeRows(db *gorm.DB) []map[string]interface{} {
+	v, ok := db.InstanceGet(beforeRowsKey)
+	if !ok {
+		return nil
+	}
+	rows, _ := v.([]map[string]interface{})
+	return rows
+}
+
+// primaryKeys of the model the statement runs with, gorm adds them to the conditions itself
+func primaryKeys(stmt *gorm.Statement) []interface{} {
+	field := stmt.Schema.PrioritizedPrimaryField
+	var ids []interface{}
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Struct:
+		if id, zero := field.ValueOf(stmt.Context, stmt.ReflectValue); !zero {
+			ids = append(ids, id)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			rv := reflect.Indirect(stmt.ReflectValue.Index(i))
+			if rv.Kind() != reflect.Struct {
+				continue
+			}
+			if id, zero := field.ValueOf(stmt.Context, rv); !zero {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+func write(db *gorm.DB, action string, row map[string]interface{}, before map[string]interface{}, after map[string]interface{}) {
+	stmt := db.Statement
+	info := FromContext(stmt.Context)
+
+	auditLogRepo := repository.NewAuditLogRepository(db.Session(&gorm.Session{NewDB: true}))
+	if _, result := auditLogRepo.Create(model.AuditLog{
+		Actor:     info.Actor,
+		Action:    action,
+		Entity:    stmt.Table,
+		EntityID:  fmt.Sprint(normalize(row[stmt.Schema.PrioritizedPrimaryField.DBName])),
+		Before:    encode(stmt, info, before),
+		After:     encode(stmt, info, after),
+		RequestID: info.RequestID,
+		ClientIP:  info.ClientIP,
+		CreatedBy: info.Actor,
+		UpdatedBy: info.Actor,
+	}); result.Error != nil {
+		db.AddError(result.Error)
+	}
+}
+
+func encode(stmt *gorm.Statement, info Info, values map[string]interface{}) string {
+	if values == nil {
+		return ""
+	}
+	out := make(map[string]interface{}, len(values))
+	for column, value := range values {
+		value = normalize(value)
+		if value != nil && (info.WithoutValues || redacted(stmt, column)) {
+			value = redactedValue
+		}
+		out[column] = value
+	}
+	b, err := json.Marshal(out)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// redacted reports whether the column is hidden from json in its model
+func redacted(stmt *gorm.Statement, column string) bool {
+	field := stmt.Schema.LookUpField(column)
+	return field != nil && field.Tag.Get("json") == "-"
+}
+
+// normalize makes values read back from the database comparable to and as readable as the ones written
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case *gorm.DeletedAt:
+		if v == nil || !v.Valid {
+			return nil
+		}
+		return v.Time
+	case gorm.DeletedAt:
+		if !v.Valid {
+			return nil
+		}
+		return v.Time
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		return normalize(rv.Elem().Interface())
+	}
+	return value
+}
@@ -0,0 +1,254 @@
+package audit
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"log"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func AuditNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+	if err := Register(gormDB); err != nil {
+		log.Printf("An error '%s' was not expected when registering audit callbacks", err)
+	}
+
+	return gormDB, mock
+}
+
+// auditLogArgs matches the insert of an audit log and keeps its values
+type auditLogArgs struct {
+	values map[int]string
+}
+
+func (a *auditLogArgs) arg(i int) sqlmock.Argument {
+	return argFunc(func(v driver.Value) bool {
+		if a.values == nil {
+			a.values = map[int]string{}
+		}
+		s, _ := v.(string)
+		a.values[i] = s
+		return true
+	})
+}
+
+type argFunc func(v driver.Value) bool
+
+func (f argFunc) Match(v driver.Value) bool { return f(v) }
+
+func (a *auditLogArgs) expect(mock sqlmock.Sqlmock) {
+	args := make([]driver.Value, 12)
+	for i := range args {
+		args[i] = a.arg(i)
+	}
+	mock.ExpectExec("INSERT INTO `audit_logs`").WithArgs(args...).WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func decode(t *testing.T, s string) map[string]interface{} {
+	if s == "" {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func Test_AuditCreate(t *testing.T) {
+	db, mock := AuditNewMockDB()
+	ctx := WithActor(WithInfo(context.Background(), Info{RequestID: "request", ClientIP: "127.0.0.1"}), "admin@mail.com")
+
+	var logged auditLogArgs
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `roles`").WillReturnResult(sqlmock.NewResult(3, 1))
+	logged.expect(mock)
+	mock.ExpectCommit()
+
+	roleRepo := repository.NewRoleRepository(db.WithContext(ctx))
+	_, result := roleRepo.Create(model.Role{Name: "SUPPORT", Description: "support"})
+	assert.NoError(t, result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// actor, action, entity, entity_id, before, after, request_id, client_ip
+	assert.Equal(t, "admin@mail.com", logged.values[0])
+	assert.Equal(t, ActionCreate, logged.values[1])
+	assert.Equal(t, "roles", logged.values[2])
+	assert.Equal(t, "3", logged.values[3])
+	assert.Equal(t, "", logged.values[4])
+	assert.Equal(t, "SUPPORT", decode(t, logged.values[5])["name"])
+	assert.Equal(t, "request", logged.values[6])
+	assert.Equal(t, "127.0.0.1", logged.values[7])
+}
+
+func Test_AuditUpdate(t *testing.T) {
+	db, mock := AuditNewMockDB()
+
+	var logged auditLogArgs
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `accounts` WHERE id = \\? AND `accounts`.`deleted_at` IS NULL").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "password", "updated_by"}).AddRow(1, "name", "old", "SYSTEM"))
+	mock.ExpectExec("UPDATE `accounts` SET").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT \\* FROM `accounts` WHERE `accounts`.`id` = \\?").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "password", "updated_by"}).AddRow(1, "name", "new", "SYSTEM"))
+	logged.expect(mock)
+	mock.ExpectCommit()
+
+	result := db.Model(&model.Account{}).Where("id = ?", 1).Updates(map[string]interface{}{"password": "new"})
+	assert.NoError(t, result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, SystemActor, logged.values[0])
+	assert.Equal(t, ActionUpdate, logged.values[1])
+	assert.Equal(t, "accounts", logged.values[2])
+	assert.Equal(t, "1", logged.values[3])
+	assert.Equal(t, map[string]interface{}{"password": redactedValue}, decode(t, logged.values[4]))
+	assert.Equal(t, map[string]interface{}{"password": redactedValue}, decode(t, logged.values[5]))
+}
+
+func Test_AuditUpdateUnchanged(t *testing.T) {
+	db, mock := AuditNewMockDB()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `accounts`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "name"))
+	mock.ExpectExec("UPDATE `accounts` SET").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT \\* FROM `accounts`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "name"))
+	mock.ExpectCommit()
+
+	result := db.Model(&model.Account{}).Where("id = ?", 1).Updates(map[string]interface{}{"name": "name"})
+	assert.NoError(t, result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_AuditDelete(t *testing.T) {
+	db, mock := AuditNewMockDB()
+	ctx := WithoutValues(WithActor(context.Background(), "admin@mail.com"))
+
+	var logged auditLogArgs
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `items` WHERE `items`.`id` = \\? AND `items`.`deleted_at` IS NULL").WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "item"))
+	mock.ExpectExec("UPDATE `items` SET `deleted_at`").WillReturnResult(sqlmock.NewResult(0, 1))
+	logged.expect(mock)
+	mock.ExpectCommit()
+
+	itemRepo := repository.NewItemRepository(db.WithContext(ctx))
+	result := itemRepo.Delete(2, false)
+	assert.NoError(t, result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, "admin@mail.com", logged.values[0])
+	assert.Equal(t, ActionDelete, logged.values[1])
+	assert.Equal(t, "2", logged.values[3])
+	assert.Equal(t, map[string]interface{}{"id": redactedValue, "name": redactedValue}, decode(t, logged.values[4]))
+	assert.Equal(t, "", logged.values[5])
+}
+
+func Test_AuditColumnsCreate(t *testing.T) {
+	db, mock := AuditNewMockDB()
+	ctx := WithActor(context.Background(), "admin@mail.com")
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `roles`").WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec("INSERT INTO `audit_logs`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	roleRepo := repository.NewRoleRepository(db.WithContext(ctx))
+	role, result := roleRepo.Create(model.Role{Name: "SUPPORT", CreatedBy: "SYSTEM"})
+	assert.NoError(t, result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, "admin@mail.com", role.CreatedBy)
+	assert.Equal(t, "admin@mail.com", role.UpdatedBy)
+}
+
+func Test_AuditColumnsCreateWithoutActor(t *testing.T) {
+	db, mock := AuditNewMockDB()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `roles`").WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec("INSERT INTO `audit_logs`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	roleRepo := repository.NewRoleRepository(db)
+	role, result := roleRepo.Create(model.Role{Name: "SUPPORT", CreatedBy: "user@mail.com"})
+	assert.NoError(t, result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, "user@mail.com", role.CreatedBy)
+	assert.Equal(t, SystemActor, role.UpdatedBy)
+}
+
+func Test_AuditColumnsUpdate(t *testing.T) {
+	db, mock := AuditNewMockDB()
+	ctx := WithActor(context.Background(), "admin@mail.com")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `items`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "stock"}).AddRow(2, 1))
+	mock.ExpectExec("UPDATE `items` SET `stock`=\\?,`updated_by`=\\?,`updated_at`=\\? WHERE id = \\?").
+		WithArgs(2, "admin@mail.com", sqlmock.AnyArg(), 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT \\* FROM `items`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "stock"}).AddRow(2, 1))
+	mock.ExpectCommit()
+
+	result := db.WithContext(ctx).Model(&model.Item{}).Where("id = ?", 2).Updates(map[string]interface{}{"stock": 2})
+	assert.NoError(t, result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_AuditColumnsSoftDelete(t *testing.T) {
+	db, mock := AuditNewMockDB()
+	ctx := WithActor(context.Background(), "admin@mail.com")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `items`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "item"))
+	mock.ExpectExec("UPDATE `items` SET `deleted_at`=\\?,`deleted_by`=\\? WHERE `items`.`id` = \\? AND `items`.`deleted_at` IS NULL").
+		WithArgs(sqlmock.AnyArg(), "admin@mail.com", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `audit_logs`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	itemRepo := repository.NewItemRepository(db.WithContext(ctx))
+	result := itemRepo.Delete(2, false)
+	assert.NoError(t, result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_AuditColumnsHardDelete(t *testing.T) {
+	db, mock := AuditNewMockDB()
+	ctx := WithActor(context.Background(), "admin@mail.com")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `items`").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "item"))
+	mock.ExpectExec("DELETE FROM `items` WHERE `items`.`id` = \\?").
+		WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `audit_logs`").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	itemRepo := repository.NewItemRepository(db.WithContext(ctx))
+	result := itemRepo.Delete(2, true)
+	assert.NoError(t, result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
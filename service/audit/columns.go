@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const softDeleteKey = "audit:soft_delete"
+
+// registerColumns fills created_by, updated_by and deleted_by from the actor of the statement context.
+// An actor set on the context wins over the values given, without one (SystemActor) only the empty
+// ones are filled, so jobs and unauthenticated flows can still name who acts
+func registerColumns(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").
+		Register("audit:create_columns", createColumns); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:setup_reflect_value").Before("gorm:update").
+		Register("audit:update_columns", updateColumns); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().Before("gorm:delete").
+		Register("audit:delete_columns", deleteColumns); err != nil {
+		return err
+	}
+
+	// the soft delete of gorm builds its own SET clause, deleted_by is added to it as it is built
+	next := db.ClauseBuilders["SET"]
+	db.ClauseBuilders["SET"] = func(c clause.Clause, builder clause.Builder) {
+		if stmt, ok := builder.(*gorm.Statement); ok {
+			if set, ok := c.Expression.(clause.Set); ok {
+				if deletedBy, ok := stmt.InstanceGet(softDeleteKey); ok {
+					c.Expression = append(set, deletedBy.(clause.Assignment))
+				}
+			}
+		}
+		if next != nil {
+			next(c, builder)
+			return
+		}
+		c.Build(builder)
+	}
+	return nil
+}
+
+func actor(db *gorm.DB) (string, bool) {
+	info := FromContext(db.Statement.Context)
+	return info.Actor, info.Actor != SystemActor
+}
+
+func createColumns(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil {
+		return
+	}
+	actor, explicit := actor(db)
+
+	var rows []reflect.Value
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Struct:
+		rows = append(rows, stmt.ReflectValue)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			rows = append(rows, reflect.Indirect(stmt.ReflectValue.Index(i)))
+		}
+	}
+
+	for _, name := range []string{"CreatedBy", "UpdatedBy"} {
+		field := stmt.Schema.LookUpField(name)
+		if field == nil {
+			continue
+		}
+		for _, rv := range rows {
+			if !rv.CanAddr() {
+				continue
+			}
+			if _, zero := field.ValueOf(stmt.Context, rv); zero || explicit {
+				db.AddError(field.Set(stmt.Context, rv, actor))
+			}
+		}
+	}
+}
+
+func updateColumns(db *gorm.DB) {
+	stmt := db.Statement
+	// UpdateColumn and UpdateColumns skip hooks and change exactly what they are given, e.g. replacing erased emails
+	if db.Error != nil || stmt.Schema == nil || stmt.SkipHooks {
+		return
+	}
+	field := stmt.Schema.LookUpField("UpdatedBy")
+	if field == nil {
+		return
+	}
+	actor, explicit := actor(db)
+
+	switch dest := stmt.Dest.(type) {
+	case map[string]interface{}:
+		_, byColumn := dest[field.DBName]
+		_, byName := dest[field.Name]
+		if explicit || !byColumn && !byName {
+			delete(dest, field.Name)
+			dest[field.DBName] = actor
+		}
+	default:
+		destValue := reflect.Indirect(reflect.ValueOf(stmt.Dest))
+		if destValue.Kind() != reflect.Struct {
+			return
+		}
+		if _, zero := field.ValueOf(stmt.Context, destValue); zero || explicit {
+			stmt.SetColumn(field.Name, actor)
+		}
+	}
+}
+
+// deleteColumns marks soft deletes, the SET clause builder adds deleted_by to them
+func deleteColumns(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || stmt.Unscoped || len(stmt.Schema.DeleteClauses) == 0 {
+		return
+	}
+	field := stmt.Schema.LookUpField("DeletedBy")
+	if field == nil {
+		return
+	}
+	actor, _ := actor(db)
+	db.InstanceSet(softDeleteKey, clause.Assignment{Column: clause.Column{Name: field.DBName}, Value: actor})
+}
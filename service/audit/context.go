@@ -0,0 +1,47 @@
+package audit
+
+import "context"
+
+// SystemActor is the actor of changes made outside of an authenticated request, e.g. by jobs
+const SystemActor = "SYSTEM"
+
+// Info is who made a change and from which request
+type Info struct {
+	Actor     string
+	RequestID string
+	ClientIP  string
+	// WithoutValues logs the changed columns only, e.g. while erasing personal data
+	WithoutValues bool
+}
+
+type infoKey struct{}
+
+func WithInfo(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, infoKey{}, info)
+}
+
+// WithActor keeps the request of ctx and sets who acts in it
+func WithActor(ctx context.Context, actor string) context.Context {
+	info := FromContext(ctx)
+	info.Actor = actor
+	return WithInfo(ctx, info)
+}
+
+// WithoutValues keeps the actor and request of ctx and leaves the values out of the logs of changes made with it
+func WithoutValues(ctx context.Context) context.Context {
+	info := FromContext(ctx)
+	info.WithoutValues = true
+	return WithInfo(ctx, info)
+}
+
+// FromContext returns the info of ctx, the actor is SystemActor when ctx has none
+func FromContext(ctx context.Context) Info {
+	var info Info
+	if ctx != nil {
+		info, _ = ctx.Value(infoKey{}).(Info)
+	}
+	if info.Actor == "" {
+		info.Actor = SystemActor
+	}
+	return info
+}
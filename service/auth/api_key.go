@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	"github.com/avarian/online-shopping-cart/util"
+)
+
+var (
+	ErrInvalidApiKey = apperror.New(apperror.Unauthorized, "invalid_api_key", "invalid api key")
+	ErrApiKeyExpired = apperror.New(apperror.Unauthorized, "api_key_expired", "api key expired")
+)
+
+// ApiKeyPrefix marks api keys so they stand out in logs and secret scanners
+const ApiKeyPrefix = "osc_"
+
+// apiKeyLastUsedInterval is how often the last use of a key is written at most
+const apiKeyLastUsedInterval = time.Minute
+
+// GenerateApiKey returns a new api key and its prefix, only the prefix is shown once the key is created
+func GenerateApiKey() (string, string, error) {
+	id, err := util.RandomToken(4)
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := util.RandomToken(24)
+	if err != nil {
+		return "", "", err
+	}
+	prefix := ApiKeyPrefix + id
+	return prefix + "_" + secret, prefix, nil
+}
+
+// ValidateApiKey authenticates as the owner of the key, with the permissions of the key the owner
+// still has through its roles. Keys of suspended accounts are refused
+func (s *TokenService) ValidateApiKey(ctx context.Context, key string, ip string) (*Claims, error) {
+	if !strings.HasPrefix(key, ApiKeyPrefix) {
+		return nil, ErrInvalidApiKey
+	}
+
+	apiKeyRepo := repository.NewApiKeyRepository(s.db.WithContext(ctx))
+	apiKey, result := apiKeyRepo.OneByKeyHash(util.HashToken(key), "Account", "ApiKeyPermission")
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 || apiKey.RevokedAt != nil || apiKey.Account == nil {
+		return nil, ErrInvalidApiKey
+	}
+	if apiKey.ExpiredAt != nil && time.Now().After(*apiKey.ExpiredAt) {
+		return nil, ErrApiKeyExpired
+	}
+	if apiKey.Account.SuspendedAt != nil {
+		return nil, ErrAccountSuspended
+	}
+
+	accountRoleRepo := repository.NewAccountRoleRepository(s.db.WithContext(ctx))
+	granted, result := accountRoleRepo.AllPermissionsByAccountId(int(apiKey.AccountID))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	permissions := []string{}
+	for _, v := range apiKey.ApiKeyPermission {
+		if HasPermissions(granted, v.Permission) {
+			permissions = append(permissions, v.Permission)
+		}
+	}
+
+	if result := apiKeyRepo.MarkUsed(int(apiKey.ID), ip, apiKeyLastUsedInterval); result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &Claims{
+		Username:    apiKey.Account.Email,
+		Email:       apiKey.Account.Email,
+		Type:        apiKey.Account.Type,
+		Permissions: permissions,
+		ApiKeyID:    apiKey.ID,
+	}, nil
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GenerateApiKey(t *testing.T) {
+	key, prefix, err := GenerateApiKey()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(prefix, ApiKeyPrefix))
+	assert.True(t, strings.HasPrefix(key, prefix+"_"))
+
+	other, _, err := GenerateApiKey()
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func Test_TokenValidateApiKey(t *testing.T) {
+	key := ApiKeyPrefix + "1234abcd_secret"
+	past := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name      string
+		key       string
+		revokedAt *time.Time
+		expiredAt *time.Time
+		found     bool
+		wantErr   error
+	}{
+		{
+			name:  "Success",
+			key:   key,
+			found: true,
+		},
+		{
+			name:    "Wrong prefix",
+			key:     "secret",
+			wantErr: ErrInvalidApiKey,
+		},
+		{
+			name:    "Unknown",
+			key:     key,
+			wantErr: ErrInvalidApiKey,
+		},
+		{
+			name:      "Revoked",
+			key:       key,
+			found:     true,
+			revokedAt: &past,
+			wantErr:   ErrInvalidApiKey,
+		},
+		{
+			name:      "Expired",
+			key:       key,
+			found:     true,
+			expiredAt: &past,
+			wantErr:   ErrApiKeyExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := TokenNewMockDB()
+			tokens := NewTokenService(db, "secret", nil, time.Minute, time.Hour, util.NewMemoryRevocationList())
+
+			if tt.key == key {
+				rows := sqlmock.NewRows([]string{"id", "account_id", "name", "prefix", "key_hash", "expired_at", "revoked_at"})
+				if tt.found {
+					rows.AddRow(1, 2, "erp", ApiKeyPrefix+"1234abcd", util.HashToken(key), tt.expiredAt, tt.revokedAt)
+				}
+				mock.ExpectQuery("SELECT \\* FROM `api_keys` WHERE key_hash = \\?").WithArgs(util.HashToken(key)).WillReturnRows(rows)
+			}
+			if tt.found {
+				mock.ExpectQuery("SELECT \\* FROM `accounts`").WillReturnRows(sqlmock.NewRows([]string{"id", "email", "type"}).
+					AddRow(2, "erp@mail.com", "ADMIN"))
+				mock.ExpectQuery("SELECT \\* FROM `api_key_permissions`").WillReturnRows(sqlmock.NewRows([]string{"id", "api_key_id", "permission"}).
+					AddRow(1, 1, "item:write").
+					AddRow(2, 1, "order:refund"))
+			}
+			if tt.wantErr == nil {
+				mock.ExpectQuery("SELECT DISTINCT `role_permissions`.`permission`").WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"permission"}).
+					AddRow("item:write").
+					AddRow("voucher:write"))
+				mock.ExpectBegin()
+				mock.ExpectExec("UPDATE `api_keys` SET `last_used_at`=\\?,`last_used_ip`=\\?").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+
+			claims, err := tokens.ValidateApiKey(context.Background(), tt.key, "127.0.0.1")
+			assert.NoError(t, mock.ExpectationsWereMet())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "erp@mail.com", claims.Username)
+			assert.Equal(t, "ADMIN", claims.Type)
+			assert.Equal(t, []string{"item:write"}, claims.Permissions)
+			assert.Equal(t, uint(1), claims.ApiKeyID)
+			assert.Empty(t, claims.SessionID)
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/golang-jwt/jwt"
+)
+
+var ErrInvalidChallenge = apperror.New(apperror.Unauthorized, "invalid_challenge", "invalid or expired challenge token")
+
+// Purposes of challenge tokens, the token of one cannot be used for the other
+const (
+	ChallengeTwoFactor       = "2fa"
+	ChallengeTwoFactorEnroll = "2fa_enroll"
+)
+
+// ChallengeClaims prove the password step of a login passed, subject is the account id.
+// They carry no session id so Validate never accepts them as access token
+type ChallengeClaims struct {
+	Purpose string `json:"purpose"`
+	jwt.StandardClaims
+}
+
+// IssueChallenge returns a short lived token for finishing the login of the account
+func (s *TokenService) IssueChallenge(account model.Account, purpose string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := &ChallengeClaims{
+		Purpose: purpose,
+		StandardClaims: jwt.StandardClaims{
+			Subject:   strconv.Itoa(int(account.ID)),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(ttl).Unix(),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.secret))
+}
+
+// ValidateChallenge returns the account id of a challenge token issued for purpose
+func (s *TokenService) ValidateChallenge(signedToken string, purpose string) (int, error) {
+	claims := &ChallengeClaims{}
+	_, err := jwt.ParseWithClaims(signedToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || s.secret == "" {
+			return nil, ErrInvalidChallenge
+		}
+		return []byte(s.secret), nil
+	})
+	if err != nil || claims.Purpose != purpose {
+		return 0, ErrInvalidChallenge
+	}
+	accountId, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return 0, ErrInvalidChallenge
+	}
+	return accountId, nil
+}
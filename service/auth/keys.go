@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/golang-jwt/jwt"
+	"gorm.io/gorm"
+)
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+// KeyStore caches the active signing keys, the newest one signs and all of them verify
+type KeyStore struct {
+	db       *gorm.DB
+	refresh  time.Duration
+	mu       sync.Mutex
+	keys     []signingKey
+	loadedAt time.Time
+}
+
+func NewKeyStore(db *gorm.DB, refresh time.Duration) *KeyStore {
+	return &KeyStore{
+		db:      db,
+		refresh: refresh,
+	}
+}
+
+// GenerateSigningKey creates a PEM encoded key pair for RS256 or EdDSA
+func GenerateSigningKey(algorithm string) (model.SigningKey, error) {
+	var private crypto.Signer
+	var err error
+	switch algorithm {
+	case "RS256":
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return model.SigningKey{}, fmt.Errorf("unsupported algorithm %s", algorithm)
+	}
+	if err != nil {
+		return model.SigningKey{}, err
+	}
+
+	privateDer, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return model.SigningKey{}, err
+	}
+	publicDer, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return model.SigningKey{}, err
+	}
+	kid, err := util.RandomToken(8)
+	if err != nil {
+		return model.SigningKey{}, err
+	}
+
+	return model.SigningKey{
+		Kid:        kid,
+		Algorithm:  algorithm,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDer})),
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDer})),
+		Status:     "ACTIVE",
+	}, nil
+}
+
+// Signing returns the newest active key, nil when there is none
+func (s *KeyStore) Signing() (*signingKey, error) {
+	keys, err := s.load(false)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	return &keys[0], nil
+}
+
+// Verification returns the active key with kid, a kid not known yet reloads the keys early
+func (s *KeyStore) Verification(kid string) (*signingKey, error) {
+	keys, err := s.load(false)
+	if err != nil {
+		return nil, err
+	}
+	if key := findKey(keys, kid); key != nil {
+		return key, nil
+	}
+
+	keys, err = s.load(true)
+	if err != nil {
+		return nil, err
+	}
+	return findKey(keys, kid), nil
+}
+
+// JWKS returns the public part of the active keys
+func (s *KeyStore) JWKS() (JWKSet, error) {
+	keys, err := s.load(false)
+	if err != nil {
+		return JWKSet{}, err
+	}
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, v := range keys {
+		jwk := JWK{
+			Kid: v.kid,
+			Use: "sig",
+			Alg: v.method.Alg(),
+		}
+		switch public := v.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
+
+// load reloads the keys once they are older than refresh, force still waits a second between reloads
+// so tokens with made up kid can not hit the database on every request
+func (s *KeyStore) load(force bool) ([]signingKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	age := time.Since(s.loadedAt)
+	if age < s.refresh && (!force || age < time.Second) {
+		return s.keys, nil
+	}
+
+	signingKeyRepo := repository.NewSigningKeyRepository(s.db)
+	stored, result := signingKeyRepo.AllActive()
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	keys := []signingKey{}
+	for _, v := range stored {
+		key, err := parseSigningKey(v)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %s: %w", v.Kid, err)
+		}
+		keys = append(keys, key)
+	}
+	s.keys = keys
+	s.loadedAt = time.Now()
+
+	return s.keys, nil
+}
+
+func parseSigningKey(key model.SigningKey) (signingKey, error) {
+	block, _ := pem.Decode([]byte(key.PrivateKey))
+	if block == nil {
+		return signingKey{}, errors.New("invalid private key pem")
+	}
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return signingKey{}, err
+	}
+
+	method := jwt.GetSigningMethod(key.Algorithm)
+	if method == nil {
+		return signingKey{}, fmt.Errorf("unsupported algorithm %s", key.Algorithm)
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return signingKey{}, errors.New("private key can not sign")
+	}
+
+	return signingKey{
+		kid:     key.Kid,
+		method:  method,
+		private: signer,
+	}, nil
+}
+
+func findKey(keys []signingKey, kid string) *signingKey {
+	for i := range keys {
+		if keys[i].kid == kid {
+			return &keys[i]
+		}
+	}
+	return nil
+}
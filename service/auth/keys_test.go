@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_KeyStoreSignAndVerify(t *testing.T) {
+	account := model.Account{
+		ID:    1,
+		Email: "email@mail.com",
+		Type:  "CUSTOMER",
+	}
+
+	tests := []struct {
+		name      string
+		algorithm string
+		kty       string
+	}{
+		{
+			name:      "RS256",
+			algorithm: "RS256",
+			kty:       "RSA",
+		},
+		{
+			name:      "EdDSA",
+			algorithm: "EdDSA",
+			kty:       "OKP",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stored, err := GenerateSigningKey(tt.algorithm)
+			assert.NoError(t, err)
+			key, err := parseSigningKey(stored)
+			assert.NoError(t, err)
+
+			keys := &KeyStore{
+				refresh:  time.Hour,
+				keys:     []signingKey{key},
+				loadedAt: time.Now(),
+			}
+			s := NewTokenService(nil, "", keys, time.Minute, time.Hour, util.NewMemoryRevocationList())
+
+			token, err := s.accessToken(account, "session", []string{"item:write"})
+			assert.NoError(t, err)
+
+			claims, err := s.Validate(context.Background(), token)
+			assert.NoError(t, err)
+			assert.Equal(t, "email@mail.com", claims.Username)
+
+			// tokens signed with a key no longer in the store are refused
+			other := NewTokenService(nil, "", &KeyStore{refresh: time.Hour, loadedAt: time.Now()}, time.Minute, time.Hour, util.NewMemoryRevocationList())
+			_, err = other.Validate(context.Background(), token)
+			assert.Error(t, err)
+
+			// the shared secret is retired once there is a key
+			legacy, err := NewTokenService(nil, "secret", nil, time.Minute, time.Hour, nil).accessToken(account, "session", nil)
+			assert.NoError(t, err)
+			_, err = NewTokenService(nil, "secret", keys, time.Minute, time.Hour, util.NewMemoryRevocationList()).Validate(context.Background(), legacy)
+			assert.Error(t, err)
+
+			jwks, err := s.JWKS()
+			assert.NoError(t, err)
+			assert.Len(t, jwks.Keys, 1)
+			assert.Equal(t, stored.Kid, jwks.Keys[0].Kid)
+			assert.Equal(t, tt.algorithm, jwks.Keys[0].Alg)
+			assert.Equal(t, tt.kty, jwks.Keys[0].Kty)
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/golang-jwt/jwt"
+)
+
+var (
+	ErrOidcExchange   = apperror.New(apperror.Unauthorized, "oidc_exchange_failed", "authorization code exchange failed")
+	ErrInvalidIdToken = apperror.New(apperror.Unauthorized, "invalid_id_token", "invalid id token")
+)
+
+// oidcKeyRefresh is how long provider keys are cached, an unknown kid reloads them early
+// but at most once per oidcKeyMinRefresh so made up kid can not hammer the provider
+const (
+	oidcKeyRefresh    = time.Hour
+	oidcKeyMinRefresh = time.Minute
+)
+
+type OidcProviderConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectUrl  string
+	Scopes       []string
+}
+
+// OidcIdentity is what a provider tells about the account of a verified id token
+type OidcIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+type oidcTokenResponse struct {
+	IdToken string `json:"id_token"`
+	Error   string `json:"error"`
+}
+
+// OidcProvider runs the authorization code flow with PKCE against one OpenID Connect provider,
+// the discovery document and the provider keys are fetched on first use
+type OidcProvider struct {
+	name         string
+	config       OidcProviderConfig
+	client       *http.Client
+	mu           sync.Mutex
+	discovery    *oidcDiscovery
+	keys         map[string]crypto.PublicKey
+	keysLoadedAt time.Time
+}
+
+func NewOidcProvider(name string, config OidcProviderConfig, client *http.Client) *OidcProvider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	return &OidcProvider{
+		name:   name,
+		config: config,
+		client: client,
+	}
+}
+
+func (s *OidcProvider) Name() string {
+	return s.name
+}
+
+// NewPkceVerifier returns a random code verifier, it stays on the server until the code is exchanged
+func NewPkceVerifier() (string, error) {
+	return util.RandomToken(32)
+}
+
+// PkceChallenge returns the S256 code challenge of a code verifier
+func PkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeUrl returns the url of the provider login page
+func (s *OidcProvider) AuthCodeUrl(ctx context.Context, state string, nonce string, codeVerifier string) (string, error) {
+	discovery, err := s.loadDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("response_type", "code")
+	q.Set("client_id", s.config.ClientID)
+	q.Set("redirect_uri", s.config.RedirectUrl)
+	q.Set("scope", strings.Join(s.config.Scopes, " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", PkceChallenge(codeVerifier))
+	q.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + q.Encode(), nil
+}
+
+// Exchange trades the authorization code for an id token and returns its verified identity
+func (s *OidcProvider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (OidcIdentity, error) {
+	discovery, err := s.loadDiscovery(ctx)
+	if err != nil {
+		return OidcIdentity{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", s.config.RedirectUrl)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return OidcIdentity{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(s.config.ClientID), url.QueryEscape(s.config.ClientSecret))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return OidcIdentity{}, err
+	}
+	defer resp.Body.Close()
+
+	var token oidcTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return OidcIdentity{}, fmt.Errorf("%w: %s", ErrOidcExchange, err)
+	}
+	if resp.StatusCode != http.StatusOK || token.IdToken == "" {
+		return OidcIdentity{}, fmt.Errorf("%w: status %d %s", ErrOidcExchange, resp.StatusCode, token.Error)
+	}
+
+	return s.verifyIdToken(ctx, discovery, token.IdToken, nonce)
+}
+
+func (s *OidcProvider) verifyIdToken(ctx context.Context, discovery *oidcDiscovery, idToken string, nonce string) (OidcIdentity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		default:
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+		kid, _ := token.Header["kid"].(string)
+		return s.publicKey(ctx, discovery, kid)
+	})
+	if err != nil {
+		return OidcIdentity{}, fmt.Errorf("%w: %s", ErrInvalidIdToken, err)
+	}
+
+	now := time.Now().Unix()
+	switch {
+	case !claims.VerifyIssuer(discovery.Issuer, true):
+		return OidcIdentity{}, fmt.Errorf("%w: issuer", ErrInvalidIdToken)
+	case !claims.VerifyAudience(s.config.ClientID, true):
+		return OidcIdentity{}, fmt.Errorf("%w: audience", ErrInvalidIdToken)
+	case !claims.VerifyExpiresAt(now, true):
+		return OidcIdentity{}, fmt.Errorf("%w: expired", ErrInvalidIdToken)
+	}
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return OidcIdentity{}, fmt.Errorf("%w: nonce", ErrInvalidIdToken)
+	}
+
+	identity := OidcIdentity{}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.Name, _ = claims["name"].(string)
+	// some providers send email_verified as string
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = v
+	case string:
+		identity.EmailVerified = v == "true"
+	}
+	if identity.Subject == "" {
+		return OidcIdentity{}, fmt.Errorf("%w: subject", ErrInvalidIdToken)
+	}
+	return identity, nil
+}
+
+func (s *OidcProvider) loadDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.discovery != nil {
+		return s.discovery, nil
+	}
+
+	var discovery oidcDiscovery
+	if err := s.getJson(ctx, strings.TrimSuffix(s.config.Issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("oidc discovery of %s: %w", s.name, err)
+	}
+	if discovery.Issuer != s.config.Issuer {
+		return nil, fmt.Errorf("oidc discovery of %s: issuer %s does not match", s.name, discovery.Issuer)
+	}
+	s.discovery = &discovery
+	return s.discovery, nil
+}
+
+func (s *OidcProvider) publicKey(ctx context.Context, discovery *oidcDiscovery, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	age := time.Since(s.keysLoadedAt)
+	if key, ok := s.keys[kid]; ok && age < oidcKeyRefresh {
+		return key, nil
+	}
+	if age < oidcKeyMinRefresh {
+		return nil, fmt.Errorf("unknown key %s", kid)
+	}
+
+	var set JWKSet
+	if err := s.getJson(ctx, discovery.JwksUri, &set); err != nil {
+		return nil, fmt.Errorf("oidc keys of %s: %w", s.name, err)
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, v := range set.Keys {
+		if v.Use != "" && v.Use != "sig" {
+			continue
+		}
+		key, err := parseJWK(v)
+		if err != nil {
+			continue
+		}
+		keys[v.Kid] = key
+	}
+	s.keys = keys
+	s.keysLoadedAt = time.Now()
+
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %s", kid)
+	}
+	return key, nil
+}
+
+func (s *OidcProvider) getJson(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func parseJWK(jwk JWK) (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", jwk.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", jwk.Kty)
+	}
+}
@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/audit"
+	"github.com/avarian/online-shopping-cart/service/logging"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOidcEmailNotVerified   = apperror.New(apperror.Forbidden, "oidc_email_not_verified", "email of the provider account is not verified")
+	ErrOidcAccountNotVerified = apperror.New(apperror.Conflict, "oidc_account_not_verified", "the account with the email of the provider account is not verified, verify it or reset its password first")
+)
+
+// LinkOidcAccount returns the account linked to the provider account, on first use the provider account
+// is linked to the account with the same email when both the provider and the account verified it.
+// Accounts with an unverified email are never linked, whoever registered them may not own the email
+// and would keep their password on the account of the provider user. Without an account with the email
+// a CUSTOMER account without password is created, its email is verified by the provider
+func LinkOidcAccount(db *gorm.DB, provider string, identity OidcIdentity) (model.Account, error) {
+	accountIdentityRepo := repository.NewAccountIdentityRepository(db)
+	accountIdentity, result := accountIdentityRepo.OneByProviderAndSubject(provider, identity.Subject, "Account")
+	if result.Error != nil {
+		return model.Account{}, result.Error
+	}
+	if result.RowsAffected > 0 && accountIdentity.Account != nil {
+		return *accountIdentity.Account, nil
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return model.Account{}, ErrOidcEmailNotVerified
+	}
+
+	accountRepo := repository.NewAccountRepository(db)
+	account, result := accountRepo.OneByEmail(identity.Email)
+	if result.Error != nil {
+		return model.Account{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return createOidcAccount(db, provider, identity)
+	}
+	if account.EmailVerifiedAt == nil {
+		return model.Account{}, ErrOidcAccountNotVerified
+	}
+
+	if _, result := accountIdentityRepo.Create(model.AccountIdentity{
+		AccountID: account.ID,
+		Provider:  provider,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedBy: account.Email,
+	}); result.Error != nil {
+		return model.Account{}, result.Error
+	}
+
+	logging.FromContext(db.Statement.Context).WithFields(log.Fields{
+		"account_id": account.ID,
+		"provider":   provider,
+	}).Info("provider account linked")
+
+	return account, nil
+}
+
+// createOidcAccount creates the account of a provider user together with its link, without phone number
+// and password, a password can be set later with a password reset
+func createOidcAccount(db *gorm.DB, provider string, identity OidcIdentity) (model.Account, error) {
+	name := identity.Name
+	if name == "" {
+		name = identity.Email
+	}
+	verifiedAt := time.Now()
+
+	// nobody is logged in yet, the new account creates itself
+	db = db.WithContext(audit.WithActor(db.Statement.Context, identity.Email))
+
+	var account model.Account
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		accountRepo := repository.NewAccountRepository(tx)
+		var result *gorm.DB
+		account, result = accountRepo.Create(model.Account{
+			Name:            name,
+			Email:           identity.Email,
+			Type:            "CUSTOMER",
+			EmailVerifiedAt: &verifiedAt,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		accountIdentityRepo := repository.NewAccountIdentityRepository(tx)
+		if _, result := accountIdentityRepo.Create(model.AccountIdentity{
+			AccountID: account.ID,
+			Provider:  provider,
+			Subject:   identity.Subject,
+			Email:     identity.Email,
+			CreatedBy: identity.Email,
+		}); result.Error != nil {
+			return result.Error
+		}
+		return nil
+	}); err != nil {
+		return model.Account{}, err
+	}
+
+	logging.FromContext(db.Statement.Context).WithFields(log.Fields{
+		"account_id": account.ID,
+		"provider":   provider,
+	}).Info("provider account created")
+
+	return account, nil
+}
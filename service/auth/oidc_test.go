@@ -0,0 +1,320 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeOidcProvider serves discovery, keys and a token endpoint that checks client credentials and PKCE
+type fakeOidcProvider struct {
+	server        *httptest.Server
+	key           *rsa.PrivateKey
+	codeChallenge string
+	claims        jwt.MapClaims
+}
+
+func newFakeOidcProvider(t *testing.T) *fakeOidcProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeOidcProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(oidcDiscovery{
+			Issuer:                s.server.URL,
+			AuthorizationEndpoint: s.server.URL + "/authorize",
+			TokenEndpoint:         s.server.URL + "/token",
+			JwksUri:               s.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(JWKSet{Keys: []JWK{{
+			Kty: "RSA",
+			Kid: "fake",
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientId, clientSecret, _ := r.BasicAuth()
+		if clientId != "client" || clientSecret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(oidcTokenResponse{Error: "invalid_client"})
+			return
+		}
+		if r.PostFormValue("code") != "code" || PkceChallenge(r.PostFormValue("code_verifier")) != s.codeChallenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(oidcTokenResponse{Error: "invalid_grant"})
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, s.claims)
+		token.Header["kid"] = "fake"
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(oidcTokenResponse{IdToken: idToken})
+	})
+	s.server = httptest.NewServer(mux)
+	t.Cleanup(s.server.Close)
+
+	return s
+}
+
+func (s *fakeOidcProvider) validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            s.server.URL,
+		"aud":            []string{"client"},
+		"sub":            "subject-1",
+		"email":          "email@mail.com",
+		"email_verified": true,
+		"name":           "Name",
+		"nonce":          "nonce",
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Minute).Unix(),
+	}
+}
+
+func Test_OidcAuthCodeUrl(t *testing.T) {
+	fake := newFakeOidcProvider(t)
+	provider := NewOidcProvider("fake", OidcProviderConfig{
+		Issuer:      fake.server.URL,
+		ClientID:    "client",
+		RedirectUrl: "http://localhost/callback",
+	}, fake.server.Client())
+
+	got, err := provider.AuthCodeUrl(context.Background(), "state", "nonce", "verifier")
+	assert.NoError(t, err)
+
+	u, err := url.Parse(got)
+	assert.NoError(t, err)
+	assert.Equal(t, "/authorize", u.Path)
+	q := u.Query()
+	assert.Equal(t, "code", q.Get("response_type"))
+	assert.Equal(t, "client", q.Get("client_id"))
+	assert.Equal(t, "http://localhost/callback", q.Get("redirect_uri"))
+	assert.Equal(t, "openid email profile", q.Get("scope"))
+	assert.Equal(t, "state", q.Get("state"))
+	assert.Equal(t, "nonce", q.Get("nonce"))
+	assert.Equal(t, PkceChallenge("verifier"), q.Get("code_challenge"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+}
+
+func Test_OidcExchange(t *testing.T) {
+	tests := []struct {
+		name         string
+		clientSecret string
+		codeVerifier string
+		claims       func(claims jwt.MapClaims)
+		want         OidcIdentity
+		wantErr      error
+	}{
+		{
+			name:         "Success",
+			clientSecret: "secret",
+			codeVerifier: "verifier",
+			want: OidcIdentity{
+				Subject:       "subject-1",
+				Email:         "email@mail.com",
+				EmailVerified: true,
+				Name:          "Name",
+			},
+		},
+		{
+			name:         "Email verified as string",
+			clientSecret: "secret",
+			codeVerifier: "verifier",
+			claims: func(claims jwt.MapClaims) {
+				claims["email_verified"] = "false"
+			},
+			want: OidcIdentity{
+				Subject: "subject-1",
+				Email:   "email@mail.com",
+				Name:    "Name",
+			},
+		},
+		{
+			name:         "Wrong client secret",
+			clientSecret: "other",
+			codeVerifier: "verifier",
+			wantErr:      ErrOidcExchange,
+		},
+		{
+			name:         "Wrong code verifier",
+			clientSecret: "secret",
+			codeVerifier: "other",
+			wantErr:      ErrOidcExchange,
+		},
+		{
+			name:         "Wrong nonce",
+			clientSecret: "secret",
+			codeVerifier: "verifier",
+			claims: func(claims jwt.MapClaims) {
+				claims["nonce"] = "other"
+			},
+			wantErr: ErrInvalidIdToken,
+		},
+		{
+			name:         "Wrong audience",
+			clientSecret: "secret",
+			codeVerifier: "verifier",
+			claims: func(claims jwt.MapClaims) {
+				claims["aud"] = "other"
+			},
+			wantErr: ErrInvalidIdToken,
+		},
+		{
+			name:         "Wrong issuer",
+			clientSecret: "secret",
+			codeVerifier: "verifier",
+			claims: func(claims jwt.MapClaims) {
+				claims["iss"] = "http://other"
+			},
+			wantErr: ErrInvalidIdToken,
+		},
+		{
+			name:         "Expired",
+			clientSecret: "secret",
+			codeVerifier: "verifier",
+			claims: func(claims jwt.MapClaims) {
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+			},
+			wantErr: ErrInvalidIdToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeOidcProvider(t)
+			fake.codeChallenge = PkceChallenge("verifier")
+			fake.claims = fake.validClaims()
+			if tt.claims != nil {
+				tt.claims(fake.claims)
+			}
+
+			provider := NewOidcProvider("fake", OidcProviderConfig{
+				Issuer:       fake.server.URL,
+				ClientID:     "client",
+				ClientSecret: tt.clientSecret,
+				RedirectUrl:  "http://localhost/callback",
+			}, fake.server.Client())
+
+			got, err := provider.Exchange(context.Background(), "code", tt.codeVerifier, "nonce")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_LinkOidcAccount(t *testing.T) {
+	verifiedAt := time.Now()
+	tests := []struct {
+		name    string
+		mock    func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "Linked already",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_identities` WHERE (provider = ? AND subject = ?)")).
+					WithArgs("fake", "subject-1").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "provider", "subject"}).AddRow(1, 1, "fake", "subject-1"))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE `accounts`.`id` = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "email@mail.com"))
+			},
+		},
+		{
+			name: "Link account with verified email",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_identities` WHERE (provider = ? AND subject = ?)")).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE email = ?")).
+					WithArgs("email@mail.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "email_verified_at"}).AddRow(1, "email@mail.com", verifiedAt))
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `account_identities`")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			// whoever registered the email may not own it and would keep password access to the account
+			name: "Refuse account with unverified email",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_identities` WHERE (provider = ? AND subject = ?)")).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE email = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email", "email_verified_at"}).AddRow(1, "email@mail.com", nil))
+			},
+			wantErr: ErrOidcAccountNotVerified,
+		},
+		{
+			// the provider verified the email, nobody else can register it anymore
+			name: "Create account for unknown email",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_identities` WHERE (provider = ? AND subject = ?)")).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE email = ?")).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `accounts`")).
+					WithArgs("Name", "email@mail.com", nil, "", "", "CUSTOMER", sqlmock.AnyArg(), nil, nil, false, nil, nil, 0, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `account_identities`")).
+					WithArgs(1, "fake", "subject-1", "email@mail.com", sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeOidcProvider(t)
+			fake.codeChallenge = PkceChallenge("verifier")
+			fake.claims = fake.validClaims()
+			provider := NewOidcProvider("fake", OidcProviderConfig{
+				Issuer:       fake.server.URL,
+				ClientID:     "client",
+				ClientSecret: "secret",
+				RedirectUrl:  "http://localhost/callback",
+			}, fake.server.Client())
+			identity, err := provider.Exchange(context.Background(), "code", "verifier", "nonce")
+			assert.NoError(t, err)
+
+			db, mock := TokenNewMockDB()
+			tt.mock(mock)
+
+			account, err := LinkOidcAccount(db, provider.Name(), identity)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "email@mail.com", account.Email)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package auth
+
+// Permissions that can be granted to roles, routes require them through the RequirePermission middleware
+var Permissions = []string{
+	"item:write",
+	"voucher:write",
+	"review:moderate",
+	"order:refund",
+	"role:write",
+	"account:manage",
+	"api_key:manage",
+	"audit:read",
+}
+
+func IsPermission(permission string) bool {
+	for _, v := range Permissions {
+		if v == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermissions reports whether granted holds every required permission
+func HasPermissions(granted []string, required ...string) bool {
+	for _, r := range required {
+		found := false
+		for _, g := range granted {
+			if g == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
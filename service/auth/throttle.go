@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/util"
+)
+
+var ErrLoginLocked = apperror.New(apperror.TooManyRequests, "login_locked", "too many failed logins, try again later")
+
+// strikeTTL is how long past lockouts count towards the back-off
+const strikeTTL = 24 * time.Hour
+
+type ThrottleConfig struct {
+	MaxAttempts   int // failed logins of an account within Window before it is locked
+	IpMaxAttempts int // failed logins from a client ip within Window before it is locked
+	Window        time.Duration
+	Lockout       time.Duration // first lockout, doubled on every following one
+	MaxLockout    time.Duration
+}
+
+// LoginThrottle locks logins of an account or from a client ip after too many failed attempts,
+// a successful login forgets the failures of the account but not of the ip
+type LoginThrottle struct {
+	store  util.AttemptStore
+	config ThrottleConfig
+}
+
+func NewLoginThrottle(store util.AttemptStore, config ThrottleConfig) *LoginThrottle {
+	return &LoginThrottle{
+		store:  store,
+		config: config,
+	}
+}
+
+// LockedFor returns how long logins of the account or from the ip stay locked, 0 when they are not
+func (t *LoginThrottle) LockedFor(ctx context.Context, email string, ip string) (time.Duration, error) {
+	account, err := t.store.LockedFor(ctx, accountKey(email))
+	if err != nil {
+		return 0, err
+	}
+	client, err := t.store.LockedFor(ctx, ipKey(ip))
+	if err != nil {
+		return 0, err
+	}
+	if client > account {
+		return client, nil
+	}
+	return account, nil
+}
+
+// Fail records a failed login and returns the lockout it started, 0 when none did
+func (t *LoginThrottle) Fail(ctx context.Context, email string, ip string) (time.Duration, error) {
+	account, err := t.fail(ctx, accountKey(email), t.config.MaxAttempts)
+	if err != nil {
+		return 0, err
+	}
+	client, err := t.fail(ctx, ipKey(ip), t.config.IpMaxAttempts)
+	if err != nil {
+		return 0, err
+	}
+	if client > account {
+		return client, nil
+	}
+	return account, nil
+}
+
+func (t *LoginThrottle) Succeed(ctx context.Context, email string) error {
+	return t.Unlock(ctx, email)
+}
+
+// Unlock lifts the lockout of the account and resets its back-off
+func (t *LoginThrottle) Unlock(ctx context.Context, email string) error {
+	key := accountKey(email)
+	return t.store.Clear(ctx, key, strikeKey(key))
+}
+
+func (t *LoginThrottle) fail(ctx context.Context, key string, maxAttempts int) (time.Duration, error) {
+	if maxAttempts <= 0 {
+		return 0, nil
+	}
+	n, err := t.store.Hit(ctx, key, t.config.Window)
+	if err != nil {
+		return 0, err
+	}
+	if n < maxAttempts {
+		return 0, nil
+	}
+
+	strikes, err := t.store.Incr(ctx, strikeKey(key), strikeTTL)
+	if err != nil {
+		return 0, err
+	}
+	lockout := t.lockout(strikes)
+	if err := t.store.Lock(ctx, key, lockout); err != nil {
+		return 0, err
+	}
+	return lockout, nil
+}
+
+func (t *LoginThrottle) lockout(strikes int) time.Duration {
+	lockout := t.config.Lockout
+	for i := 1; i < strikes && lockout < t.config.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if t.config.MaxLockout > 0 && lockout > t.config.MaxLockout {
+		lockout = t.config.MaxLockout
+	}
+	return lockout
+}
+
+func accountKey(email string) string {
+	return "login:account:" + strings.ToLower(email)
+}
+
+func ipKey(ip string) string {
+	return "login:ip:" + ip
+}
+
+func strikeKey(key string) string {
+	return key + ":strikes"
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoginThrottle(t *testing.T) {
+	ctx := context.Background()
+	config := ThrottleConfig{
+		MaxAttempts:   3,
+		IpMaxAttempts: 10,
+		Window:        time.Minute,
+		Lockout:       time.Minute,
+		MaxLockout:    3 * time.Minute,
+	}
+
+	t.Run("Lock account after max attempts", func(t *testing.T) {
+		throttle := NewLoginThrottle(util.NewMemoryAttemptStore(), config)
+
+		for i := 1; i < config.MaxAttempts; i++ {
+			lockout, err := throttle.Fail(ctx, "email@mail.com", "10.0.0.1")
+			assert.NoError(t, err)
+			assert.Zero(t, lockout)
+		}
+		lockout, err := throttle.Fail(ctx, "Email@mail.com", "10.0.0.1")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Minute, lockout)
+
+		locked, err := throttle.LockedFor(ctx, "email@mail.com", "10.0.0.2")
+		assert.NoError(t, err)
+		assert.Greater(t, locked, time.Duration(0))
+
+		locked, err = throttle.LockedFor(ctx, "other@mail.com", "10.0.0.1")
+		assert.NoError(t, err)
+		assert.Zero(t, locked)
+	})
+
+	t.Run("Back-off doubles up to max", func(t *testing.T) {
+		throttle := NewLoginThrottle(util.NewMemoryAttemptStore(), config)
+
+		var lockouts []time.Duration
+		for i := 0; i < 3*config.MaxAttempts; i++ {
+			lockout, err := throttle.Fail(ctx, "email@mail.com", "10.0.0.1")
+			assert.NoError(t, err)
+			if lockout > 0 {
+				lockouts = append(lockouts, lockout)
+			}
+		}
+		assert.Equal(t, []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute}, lockouts)
+	})
+
+	t.Run("Lock ip across accounts", func(t *testing.T) {
+		throttle := NewLoginThrottle(util.NewMemoryAttemptStore(), config)
+
+		for i := 0; i < config.IpMaxAttempts; i++ {
+			_, err := throttle.Fail(ctx, "email"+string(rune('a'+i))+"@mail.com", "10.0.0.1")
+			assert.NoError(t, err)
+		}
+
+		locked, err := throttle.LockedFor(ctx, "new@mail.com", "10.0.0.1")
+		assert.NoError(t, err)
+		assert.Greater(t, locked, time.Duration(0))
+	})
+
+	t.Run("Unlock", func(t *testing.T) {
+		throttle := NewLoginThrottle(util.NewMemoryAttemptStore(), config)
+
+		for i := 0; i < config.MaxAttempts; i++ {
+			_, err := throttle.Fail(ctx, "email@mail.com", "10.0.0.1")
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, throttle.Unlock(ctx, "email@mail.com"))
+
+		locked, err := throttle.LockedFor(ctx, "email@mail.com", "10.0.0.2")
+		assert.NoError(t, err)
+		assert.Zero(t, locked)
+	})
+}
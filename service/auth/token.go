@@ -0,0 +1,277 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/service/apperror"
+	"github.com/avarian/online-shopping-cart/service/repository"
+	"github.com/avarian/online-shopping-cart/util"
+	"github.com/golang-jwt/jwt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidToken        = apperror.New(apperror.Unauthorized, "invalid_token", "invalid token")
+	ErrTokenRevoked        = apperror.New(apperror.Unauthorized, "token_revoked", "token revoked")
+	ErrInvalidRefreshToken = apperror.New(apperror.Unauthorized, "invalid_refresh_token", "invalid refresh token")
+	ErrRefreshTokenReused  = apperror.New(apperror.Unauthorized, "refresh_token_reused", "refresh token reused, session revoked")
+	ErrAccountSuspended    = apperror.New(apperror.Forbidden, "account_suspended", "account suspended")
+)
+
+type Claims struct {
+	Username    string   `json:"username"`
+	Email       string   `json:"email"`
+	Type        string   `json:"type"`
+	SessionID   string   `json:"sid"`
+	Permissions []string `json:"permissions"`
+	// ApiKeyID is set when authenticated with an api key instead of an access token
+	ApiKeyID uint `json:"-"`
+	jwt.StandardClaims
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// TokenService issues short lived access tokens along with rotating refresh tokens stored server side,
+// every login starts a session whose refresh tokens share the session id as family id.
+// Access tokens are signed with the newest key of the key store, or HS256 with secret while there is none
+type TokenService struct {
+	db          *gorm.DB
+	secret      string
+	keys        *KeyStore
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+	revocations util.RevocationList
+}
+
+func NewTokenService(db *gorm.DB, secret string, keys *KeyStore, accessTTL time.Duration, refreshTTL time.Duration, revocations util.RevocationList) *TokenService {
+	return &TokenService{
+		db:          db,
+		secret:      secret,
+		keys:        keys,
+		accessTTL:   accessTTL,
+		refreshTTL:  refreshTTL,
+		revocations: revocations,
+	}
+}
+
+// Issue starts a new session for the account
+func (s *TokenService) Issue(account model.Account) (TokenPair, error) {
+	sessionId, err := util.RandomToken(16)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	return s.issue(account, sessionId)
+}
+
+// Refresh rotates a refresh token, presenting an already rotated token again revokes its whole session
+func (s *TokenService) Refresh(ctx context.Context, refreshToken string) (TokenPair, error) {
+	refreshTokenRepo := repository.NewRefreshTokenRepository(s.db.WithContext(ctx))
+	stored, result := refreshTokenRepo.OneByTokenHash(util.HashToken(refreshToken))
+	if result.Error != nil {
+		return TokenPair{}, result.Error
+	}
+	if result.RowsAffected == 0 || stored.RevokedAt != nil || time.Now().After(stored.ExpiredAt) {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+	if stored.UsedAt != nil {
+		return TokenPair{}, s.revokeReused(ctx, stored)
+	}
+
+	// a concurrent rotation of the same token is reuse as well
+	result = refreshTokenRepo.MarkUsed(int(stored.ID))
+	if result.Error != nil {
+		return TokenPair{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return TokenPair{}, s.revokeReused(ctx, stored)
+	}
+
+	accountRepo := repository.NewAccountRepository(s.db.WithContext(ctx))
+	account, result := accountRepo.OneById(int(stored.AccountID))
+	if result.Error != nil {
+		return TokenPair{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	return s.issue(account, stored.FamilyID)
+}
+
+// RevokeSession revokes every refresh token of the session and rejects its access tokens still alive
+func (s *TokenService) RevokeSession(ctx context.Context, sessionId string, updatedBy string) error {
+	refreshTokenRepo := repository.NewRefreshTokenRepository(s.db.WithContext(ctx))
+	if result := refreshTokenRepo.RevokeFamily(sessionId, updatedBy); result.Error != nil {
+		return result.Error
+	}
+	return s.revocations.Revoke(ctx, sessionKey(sessionId), s.accessTTL)
+}
+
+// RevokeAccountSessions revokes every session of the account, e.g. after its password changed
+func (s *TokenService) RevokeAccountSessions(ctx context.Context, accountId int, updatedBy string) error {
+	refreshTokenRepo := repository.NewRefreshTokenRepository(s.db.WithContext(ctx))
+	sessionIds, result := refreshTokenRepo.AllActiveFamilyIdsByAccountId(accountId)
+	if result.Error != nil {
+		return result.Error
+	}
+	for _, v := range sessionIds {
+		if err := s.RevokeSession(ctx, v, updatedBy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate parses an access token and checks it against the revocation list
+func (s *TokenService) Validate(ctx context.Context, signedToken string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(signedToken, claims, s.verificationKey)
+	if err != nil {
+		return nil, err
+	}
+	if claims.SessionID == "" || claims.Id == "" {
+		return nil, ErrInvalidToken
+	}
+
+	revoked, err := s.revocations.IsRevoked(ctx, sessionKey(claims.SessionID))
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	return claims, nil
+}
+
+// issue embeds the permissions of the account roles at issue time, role changes apply on the next refresh.
+// Suspended accounts get no tokens, their sessions are revoked when suspended so Auth rejects the ones alive
+func (s *TokenService) issue(account model.Account, sessionId string) (TokenPair, error) {
+	if account.SuspendedAt != nil {
+		return TokenPair{}, ErrAccountSuspended
+	}
+
+	accountRoleRepo := repository.NewAccountRoleRepository(s.db)
+	permissions, result := accountRoleRepo.AllPermissionsByAccountId(int(account.ID))
+	if result.Error != nil {
+		return TokenPair{}, result.Error
+	}
+
+	accessToken, err := s.accessToken(account, sessionId, permissions)
+	if err != nil {
+		return TokenPair{}, err
+	}
+
+	refreshToken, err := util.RandomToken(32)
+	if err != nil {
+		return TokenPair{}, err
+	}
+	refreshTokenRepo := repository.NewRefreshTokenRepository(s.db)
+	if _, result := refreshTokenRepo.Create(model.RefreshToken{
+		AccountID: account.ID,
+		FamilyID:  sessionId,
+		TokenHash: util.HashToken(refreshToken),
+		ExpiredAt: time.Now().Add(s.refreshTTL),
+		CreatedBy: account.Email,
+	}); result.Error != nil {
+		return TokenPair{}, result.Error
+	}
+
+	return TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.accessTTL.Seconds()),
+	}, nil
+}
+
+func (s *TokenService) accessToken(account model.Account, sessionId string, permissions []string) (string, error) {
+	tokenId, err := util.RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := &Claims{
+		Email:       account.Email,
+		Username:    account.Email,
+		Type:        account.Type,
+		SessionID:   sessionId,
+		Permissions: permissions,
+		StandardClaims: jwt.StandardClaims{
+			Id:        tokenId,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(s.accessTTL).Unix(),
+		},
+	}
+
+	var key *signingKey
+	if s.keys != nil {
+		if key, err = s.keys.Signing(); err != nil {
+			return "", err
+		}
+	}
+	if key == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.secret))
+	}
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
+}
+
+// JWKS returns the public keys access tokens can be verified with
+func (s *TokenService) JWKS() (JWKSet, error) {
+	if s.keys == nil {
+		return JWKSet{Keys: []JWK{}}, nil
+	}
+	return s.keys.JWKS()
+}
+
+// verificationKey accepts any active key of the key store by kid, HS256 with secret only while the store has
+// no key, so the first generated key retires the shared secret and whoever knows it can no longer mint tokens
+func (s *TokenService) verificationKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if s.secret == "" {
+			return nil, ErrInvalidToken
+		}
+		if s.keys != nil {
+			key, err := s.keys.Signing()
+			if err != nil {
+				return nil, err
+			}
+			if key != nil {
+				return nil, ErrInvalidToken
+			}
+		}
+		return []byte(s.secret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if s.keys == nil || kid == "" {
+		return nil, ErrInvalidToken
+	}
+	key, err := s.keys.Verification(kid)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil || key.method.Alg() != token.Method.Alg() {
+		return nil, ErrInvalidToken
+	}
+	return key.private.Public(), nil
+}
+
+func (s *TokenService) revokeReused(ctx context.Context, stored model.RefreshToken) error {
+	if err := s.RevokeSession(ctx, stored.FamilyID, "SYSTEM"); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+func sessionKey(sessionId string) string {
+	return "sid:" + sessionId
+}
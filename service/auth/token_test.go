@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TokenNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_TokenValidate(t *testing.T) {
+	account := model.Account{
+		ID:    1,
+		Email: "email@mail.com",
+		Type:  "CUSTOMER",
+	}
+
+	tests := []struct {
+		name    string
+		secret  string
+		ttl     time.Duration
+		revoke  bool
+		wantErr bool
+	}{
+		{
+			name:   "Success",
+			secret: "secret",
+			ttl:    time.Minute,
+		},
+		{
+			name:    "Wrong secret",
+			secret:  "other",
+			ttl:     time.Minute,
+			wantErr: true,
+		},
+		{
+			name:    "Expired",
+			secret:  "secret",
+			ttl:     -time.Minute,
+			wantErr: true,
+		},
+		{
+			name:    "Revoked session",
+			secret:  "secret",
+			ttl:     time.Minute,
+			revoke:  true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := TokenNewMockDB()
+			s := NewTokenService(db, "secret", nil, tt.ttl, time.Hour, util.NewMemoryRevocationList())
+
+			token, err := NewTokenService(db, tt.secret, nil, tt.ttl, time.Hour, nil).accessToken(account, "session", []string{"item:write"})
+			assert.NoError(t, err)
+
+			if tt.revoke {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `refresh_tokens` SET `revoked_at`=?,`updated_by`=?,`updated_at`=? WHERE (family_id = ? AND revoked_at IS NULL) AND `refresh_tokens`.`deleted_at` IS NULL")).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				assert.NoError(t, s.RevokeSession(context.Background(), "session", "email@mail.com"))
+			}
+
+			claims, err := s.Validate(context.Background(), token)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "email@mail.com", claims.Username)
+			assert.Equal(t, []string{"item:write"}, claims.Permissions)
+			assert.Equal(t, "session", claims.SessionID)
+		})
+	}
+}
+
+func Test_TokenIssueSuspended(t *testing.T) {
+	db, mock := TokenNewMockDB()
+	now := time.Now()
+	tokens := NewTokenService(db, "secret", nil, time.Minute, time.Hour, util.NewMemoryRevocationList())
+
+	_, err := tokens.Issue(model.Account{
+		ID:          1,
+		Email:       "email@mail.com",
+		SuspendedAt: &now,
+	})
+	assert.ErrorIs(t, err, ErrAccountSuspended)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_TokenChallenge(t *testing.T) {
+	db, _ := TokenNewMockDB()
+	tokens := NewTokenService(db, "secret", nil, time.Minute, time.Hour, util.NewMemoryRevocationList())
+	account := model.Account{ID: 7, Email: "email@mail.com"}
+
+	challenge, err := tokens.IssueChallenge(account, ChallengeTwoFactor, time.Minute)
+	assert.NoError(t, err)
+
+	accountId, err := tokens.ValidateChallenge(challenge, ChallengeTwoFactor)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, accountId)
+
+	_, err = tokens.ValidateChallenge(challenge, ChallengeTwoFactorEnroll)
+	assert.ErrorIs(t, err, ErrInvalidChallenge)
+
+	_, err = tokens.Validate(context.Background(), challenge)
+	assert.Error(t, err)
+
+	expired, err := tokens.IssueChallenge(account, ChallengeTwoFactor, -time.Minute)
+	assert.NoError(t, err)
+	_, err = tokens.ValidateChallenge(expired, ChallengeTwoFactor)
+	assert.ErrorIs(t, err, ErrInvalidChallenge)
+}
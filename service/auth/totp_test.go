@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// secret of the RFC 6238 test vectors, "12345678901234567890" base32 encoded
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func Test_TotpCode(t *testing.T) {
+	tests := []struct {
+		name string
+		time int64
+		want string
+	}{
+		{name: "59", time: 59, want: "287082"},
+		{name: "1111111109", time: 1111111109, want: "081804"},
+		{name: "1234567890", time: 1234567890, want: "005924"},
+		{name: "2000000000", time: 2000000000, want: "279037"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TotpCode(rfcSecret, time.Unix(tt.time, 0))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ValidateTotp(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	step, ok := ValidateTotp(rfcSecret, "081804", 0, now)
+	assert.True(t, ok)
+	assert.Equal(t, now.Unix()/30, step)
+	_, ok = ValidateTotp(rfcSecret, "081804", 0, now.Add(30*time.Second))
+	assert.True(t, ok)
+	_, ok = ValidateTotp(rfcSecret, "081804", 0, now.Add(90*time.Second))
+	assert.False(t, ok)
+	_, ok = ValidateTotp(rfcSecret, "000000", 0, now)
+	assert.False(t, ok)
+	_, ok = ValidateTotp(rfcSecret, "81804", 0, now)
+	assert.False(t, ok)
+
+	// a code is accepted once, replaying it within its window is refused
+	_, ok = ValidateTotp(rfcSecret, "081804", step, now)
+	assert.False(t, ok)
+	_, ok = ValidateTotp(rfcSecret, "081804", step, now.Add(30*time.Second))
+	assert.False(t, ok)
+	next, err := TotpCode(rfcSecret, now.Add(30*time.Second))
+	assert.NoError(t, err)
+	nextStep, ok := ValidateTotp(rfcSecret, next, step, now.Add(30*time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, step+1, nextStep)
+
+	secret, err := GenerateTotpSecret()
+	assert.NoError(t, err)
+	code, err := TotpCode(secret, now)
+	assert.NoError(t, err)
+	_, ok = ValidateTotp(secret, code, 0, now)
+	assert.True(t, ok)
+
+	uri := TotpProvisioningUri("Shop", "email@mail.com", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Shop:email@mail.com?"))
+	assert.Contains(t, uri, "secret="+secret)
+}
@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Context(t *testing.T) {
+	t.Run("Default to the standard logger", func(t *testing.T) {
+		entry := FromContext(context.Background())
+
+		assert.Equal(t, log.StandardLogger(), entry.Logger)
+		assert.Empty(t, entry.Data)
+	})
+
+	t.Run("Keep fields of the request", func(t *testing.T) {
+		var out bytes.Buffer
+		logger := log.New()
+		logger.SetOutput(&out)
+		logger.SetFormatter(&log.JSONFormatter{})
+
+		ctx := WithEntry(context.Background(), logger.WithField("request_id", "req-1"))
+		ctx = WithFields(ctx, log.Fields{"user": "email@mail.com"})
+		FromContext(ctx).WithField("api", "PostLogin").Info("login")
+
+		var line map[string]interface{}
+		assert.NoError(t, json.Unmarshal(out.Bytes(), &line))
+		assert.Equal(t, "req-1", line["request_id"])
+		assert.Equal(t, "email@mail.com", line["user"])
+		assert.Equal(t, "PostLogin", line["api"])
+		assert.Equal(t, "login", line["msg"])
+	})
+}
+
+func Test_NewFormatter(t *testing.T) {
+	formatter, err := NewFormatter("json")
+	assert.NoError(t, err)
+	assert.IsType(t, &log.JSONFormatter{}, formatter)
+
+	formatter, err = NewFormatter("TEXT")
+	assert.NoError(t, err)
+	assert.IsType(t, &log.TextFormatter{}, formatter)
+
+	_, err = NewFormatter("xml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,27 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// NewFormatter returns the formatter of format, text for people reading a terminal and json for log collectors
+func NewFormatter(format string) (log.Formatter, error) {
+	switch strings.ToLower(format) {
+	case FormatText:
+		return &log.TextFormatter{
+			FullTimestamp: true,
+		}, nil
+	case FormatJSON:
+		return &log.JSONFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q, expected %s or %s", format, FormatText, FormatJSON)
+	}
+}
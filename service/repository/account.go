@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"reflect"
 	"strconv"
+	"time"
 
 	"github.com/avarian/online-shopping-cart/model"
 	"gorm.io/gorm"
@@ -24,6 +25,25 @@ func NewAccountRepository(db *gorm.DB) *AccountRepository {
 
 func (s *AccountRepository) FilterScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		email := q.Get("email")
+		if email != "" {
+			db = db.Where("LOWER(email) LIKE LOWER(?)", "%"+email+"%")
+		}
+		name := q.Get("name")
+		if name != "" {
+			db = db.Where("LOWER(name) LIKE LOWER(?)", "%"+name+"%")
+		}
+		accountType := q.Get("type")
+		if accountType != "" {
+			db = db.Where("type = ?", accountType)
+		}
+		switch q.Get("status") {
+		case "SUSPENDED":
+			db = db.Where("suspended_at IS NOT NULL")
+		case "ACTIVE":
+			db = db.Where("suspended_at IS NULL")
+		}
 		return db
 	}
 }
@@ -182,3 +202,129 @@ func (s *AccountRepository) OneByEmail(email string, preload ...string) (model.A
 
 	return table, query
 }
+
+// MarkEmailVerified only verifies the email the code was sent to, it may have changed since
+func (s *AccountRepository) MarkEmailVerified(id int, email string) *gorm.DB {
+	query := s.db.Model(&model.Account{}).Where("id = ? AND email = ?", id, email).Updates(map[string]interface{}{
+		"email_verified_at": time.Now(),
+	})
+	return query
+}
+
+// MarkPhoneVerified only verifies the phone number the code was sent to, it may have changed since
+func (s *AccountRepository) MarkPhoneVerified(id int, phoneNumber string) *gorm.DB {
+	query := s.db.Model(&model.Account{}).Where("id = ? AND phone_number = ?", id, phoneNumber).Updates(map[string]interface{}{
+		"phone_verified_at": time.Now(),
+	})
+	return query
+}
+
+func (s *AccountRepository) OneByPhoneNumber(phoneNumber string) (model.Account, *gorm.DB) {
+	var table model.Account
+	query := s.db.Where("phone_number = ?", phoneNumber).Find(&table)
+
+	return table, query
+}
+
+// UpdatePhoneNumber also drops the phone verification, the new number has to be verified again
+func (s *AccountRepository) UpdatePhoneNumber(id int, phoneNumber string, updatedBy string) *gorm.DB {
+	query := s.db.Model(&model.Account{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"phone_number":      phoneNumber,
+		"phone_verified_at": nil,
+		"updated_by":        updatedBy,
+	})
+	return query
+}
+
+func (s *AccountRepository) Suspend(id int, updatedBy string) *gorm.DB {
+	query := s.db.Model(&model.Account{}).Where("id = ? AND suspended_at IS NULL", id).Updates(map[string]interface{}{
+		"suspended_at": time.Now(),
+		"updated_by":   updatedBy,
+	})
+	return query
+}
+
+func (s *AccountRepository) Reactivate(id int, updatedBy string) *gorm.DB {
+	query := s.db.Model(&model.Account{}).Where("id = ? AND suspended_at IS NOT NULL", id).Updates(map[string]interface{}{
+		"suspended_at": nil,
+		"updated_by":   updatedBy,
+	})
+	return query
+}
+
+func (s *AccountRepository) UpdateType(id int, accountType string, updatedBy string) *gorm.DB {
+	query := s.db.Model(&model.Account{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"type":       accountType,
+		"updated_by": updatedBy,
+	})
+	return query
+}
+
+func (s *AccountRepository) UpdatePasswordResetRequired(id int, required bool, updatedBy string) *gorm.DB {
+	query := s.db.Model(&model.Account{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password_reset_required": required,
+		"updated_by":              updatedBy,
+	})
+	return query
+}
+
+// UpdateTotpSecret starts a new two factor enrollment, nil secret disables two factor authentication
+func (s *AccountRepository) UpdateTotpSecret(id int, secret *string, updatedBy string) *gorm.DB {
+	query := s.db.Model(&model.Account{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"totp_secret":     secret,
+		"totp_enabled_at": nil,
+		"updated_by":      updatedBy,
+	})
+	return query
+}
+
+func (s *AccountRepository) EnableTotp(id int, updatedBy string) *gorm.DB {
+	query := s.db.Model(&model.Account{}).Where("id = ? AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL", id).Updates(map[string]interface{}{
+		"totp_enabled_at": time.Now(),
+		"updated_by":      updatedBy,
+	})
+	return query
+}
+
+// UseTotpStep records the time step of an accepted code, it affects no row when the step or a later
+// one was used already so a code can not be replayed within its window
+func (s *AccountRepository) UseTotpStep(id int, step int64) *gorm.DB {
+	query := s.db.Model(&model.Account{}).Where("id = ? AND totp_last_step < ?", id, step).Update("totp_last_step", step)
+	return query
+}
+
+// Anonymize replaces the personal data of the account and soft deletes it, email and phone number
+// are replaced by unique placeholders and the account cannot login anymore
+func (s *AccountRepository) Anonymize(id int, email string, phoneNumber string, erasedBy string) *gorm.DB {
+	now := time.Now()
+	query := s.db.Unscoped().Model(&model.Account{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"name":                    "Deleted account",
+		"email":                   email,
+		"phone_number":            phoneNumber,
+		"password":                "",
+		"address":                 "",
+		"email_verified_at":       nil,
+		"phone_verified_at":       nil,
+		"totp_secret":             nil,
+		"totp_enabled_at":         nil,
+		"password_reset_required": false,
+		"suspended_at":            gorm.Expr("COALESCE(suspended_at, ?)", now),
+		"updated_by":              erasedBy,
+		"deleted_by":              erasedBy,
+		"deleted_at":              now,
+	})
+	return query
+}
+
+// ReplaceAuditEmail replaces the email in created_by, updated_by and deleted_by of the tables of models,
+// rows kept after an erasure then keep no trace of the account
+func (s *AccountRepository) ReplaceAuditEmail(email string, replacement string, models ...interface{}) error {
+	for _, m := range models {
+		for _, column := range []string{"created_by", "updated_by", "deleted_by"} {
+			if result := s.db.Unscoped().Model(m).Where(column+" = ?", email).UpdateColumn(column, replacement); result.Error != nil {
+				return result.Error
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"gorm.io/gorm"
+)
+
+type AccountAddressRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountAddressRepository(db *gorm.DB) *AccountAddressRepository {
+	return &AccountAddressRepository{
+		db: db,
+	}
+}
+
+func (s *AccountAddressRepository) FilterScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db
+	}
+}
+
+func (s *AccountAddressRepository) PaginateScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		page, _ := strconv.Atoi(q.Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		pageSize, _ := strconv.Atoi(q.Get("page_size"))
+		switch {
+		case pageSize > 100:
+			pageSize = 100
+		case pageSize <= 0:
+			pageSize = 10
+		}
+
+		sortBy := q.Get("sort_by")
+		if sortBy == "" {
+			sortBy = "id"
+		}
+
+		direction := q.Get("direction")
+		if direction == "" {
+			direction = "desc"
+		}
+
+		sort := sortBy + " " + direction
+
+		offset := (page - 1) * pageSize
+		return db.Offset(offset).Limit(pageSize).Order(sort)
+	}
+}
+
+func (s *AccountAddressRepository) MetaPaginate(r *http.Request) map[string]interface{} {
+	q := r.URL.Query()
+	var totalRows int64
+	s.db.Model(model.AccountAddress{}).Scopes(s.FilterScope(r)).Count(&totalRows)
+
+	pageSize, _ := strconv.Atoi(q.Get("page_size"))
+	switch {
+	case pageSize > 100:
+		pageSize = 100
+	case pageSize <= 0:
+		pageSize = 10
+	}
+	totalPages := int(math.Ceil(float64(totalRows) / float64(pageSize)))
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	meta := map[string]interface{}{
+		"page":        page,
+		"page_size":   pageSize,
+		"total_rows":  totalRows,
+		"total_pages": totalPages,
+	}
+	return meta
+}
+
+func (s *AccountAddressRepository) Index(r *http.Request, preload ...string) ([]model.AccountAddress, *gorm.DB) {
+	var table []model.AccountAddress
+	tx := s.db.Scopes(s.FilterScope(r), s.PaginateScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AccountAddressRepository) All(r *http.Request, preload ...string) ([]model.AccountAddress, *gorm.DB) {
+	var table []model.AccountAddress
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AccountAddressRepository) One(r *http.Request, preload ...string) (model.AccountAddress, *gorm.DB) {
+	var table model.AccountAddress
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AccountAddressRepository) OneById(id int, preload ...string) (model.AccountAddress, *gorm.DB) {
+	var table model.AccountAddress
+	tx := s.db.Where("id = ?", id)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AccountAddressRepository) Create(data model.AccountAddress) (model.AccountAddress, *gorm.DB) {
+	var table model.AccountAddress
+	s.AssignData(&table, data)
+	query := s.db.Create(&table)
+	return table, query
+}
+
+func (s *AccountAddressRepository) Update(id int, data model.AccountAddress) (model.AccountAddress, *gorm.DB) {
+	var table model.AccountAddress
+	table, result := s.OneById(id)
+	if result.RowsAffected == 0 {
+		result.Error = errors.New(fmt.Sprintf("data not found with id = %d", id))
+		return table, result
+	}
+	s.AssignData(&table, data)
+	query := s.db.Save(&table)
+	return table, query
+}
+
+func (s *AccountAddressRepository) Delete(id int, isHard bool) *gorm.DB {
+	tx := s.db
+	if isHard {
+		tx = tx.Unscoped()
+	}
+	query := tx.Delete(&model.AccountAddress{}, id)
+	return query
+}
+
+func (s *AccountAddressRepository) AssignData(table *model.AccountAddress, data model.AccountAddress) {
+	dataRV := reflect.ValueOf(data)
+	tableRV := reflect.ValueOf(table)
+	tableRVE := tableRV.Elem()
+
+	for i := 0; i < dataRV.NumField(); i++ {
+		if !dataRV.Field(i).IsZero() && (tableRVE.Field(i) != dataRV.Field(i)) {
+			fv := tableRVE.FieldByName(dataRV.Type().Field(i).Name)
+			fv.Set(dataRV.Field(i))
+		}
+	}
+}
+
+func (s *AccountAddressRepository) AllByAccountId(accountId int, preload ...string) ([]model.AccountAddress, *gorm.DB) {
+	var table []model.AccountAddress
+	tx := s.db.Where("account_id = ?", accountId).Order("is_default desc, id desc")
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AccountAddressRepository) OneByIdAndAccountId(id int, accountId int, preload ...string) (model.AccountAddress, *gorm.DB) {
+	var table model.AccountAddress
+	tx := s.db.Where("id = ? AND account_id = ?", id, accountId)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AccountAddressRepository) OneDefaultByAccountId(accountId int) (model.AccountAddress, *gorm.DB) {
+	var table model.AccountAddress
+	query := s.db.Where("account_id = ? AND is_default = ?", accountId, true).Find(&table)
+
+	return table, query
+}
+
+func (s *AccountAddressRepository) OneLatestByAccountId(accountId int) (model.AccountAddress, *gorm.DB) {
+	var table model.AccountAddress
+	query := s.db.Where("account_id = ?", accountId).Order("id desc").Limit(1).Find(&table)
+
+	return table, query
+}
+
+// SetDefault makes the address the only default one of the account
+func (s *AccountAddressRepository) SetDefault(id int, accountId int, updatedBy string) *gorm.DB {
+	if query := s.db.Model(&model.AccountAddress{}).Where("account_id = ? AND id <> ? AND is_default = ?", accountId, id, true).Updates(map[string]interface{}{
+		"is_default": false,
+		"updated_by": updatedBy,
+	}); query.Error != nil {
+		return query
+	}
+	query := s.db.Model(&model.AccountAddress{}).Where("id = ? AND account_id = ?", id, accountId).Updates(map[string]interface{}{
+		"is_default": true,
+		"updated_by": updatedBy,
+	})
+	return query
+}
+
+func (s *AccountAddressRepository) DeleteByAccountId(accountId int) *gorm.DB {
+	query := s.db.Unscoped().Where("account_id = ?", accountId).Delete(&model.AccountAddress{})
+	return query
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func AccountAddressNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_AccountAddressOneByIdAndAccountId(t *testing.T) {
+	tests := []struct {
+		name      string
+		accountId int
+		rows      *sqlmock.Rows
+		want      model.AccountAddress
+		wantFound bool
+	}{
+		{
+			name:      "Own address",
+			accountId: 1,
+			rows:      sqlmock.NewRows([]string{"id", "account_id", "label", "is_default"}).AddRow(2, 1, "Home", true),
+			want:      model.AccountAddress{ID: 2, AccountID: 1, Label: "Home", IsDefault: true},
+			wantFound: true,
+		},
+		{
+			// the id exists but belongs to another account, the query finds nothing
+			name:      "Address of another account",
+			accountId: 3,
+			rows:      sqlmock.NewRows([]string{"id", "account_id", "label", "is_default"}),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := AccountAddressNewMockDB()
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_addresses` WHERE (id = ? AND account_id = ?) AND `account_addresses`.`deleted_at` IS NULL")).
+				WithArgs(2, tt.accountId).
+				WillReturnRows(tt.rows)
+
+			got, result := NewAccountAddressRepository(db).OneByIdAndAccountId(2, tt.accountId)
+			assert.NoError(t, result.Error)
+			assert.Equal(t, tt.wantFound, result.RowsAffected > 0)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_AccountAddressAllByAccountId(t *testing.T) {
+	db, mock := AccountAddressNewMockDB()
+	// the default address comes first, then the latest added
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_addresses` WHERE account_id = ? AND `account_addresses`.`deleted_at` IS NULL ORDER BY is_default desc, id desc")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "is_default"}).AddRow(2, 1, true).AddRow(5, 1, false).AddRow(3, 1, false))
+
+	got, result := NewAccountAddressRepository(db).AllByAccountId(1)
+	assert.NoError(t, result.Error)
+	assert.Len(t, got, 3)
+	assert.True(t, got[0].IsDefault)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_AccountAddressOneDefaultByAccountId(t *testing.T) {
+	db, mock := AccountAddressNewMockDB()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_addresses` WHERE (account_id = ? AND is_default = ?) AND `account_addresses`.`deleted_at` IS NULL")).
+		WithArgs(1, true).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "is_default"}).AddRow(2, 1, true))
+
+	got, result := NewAccountAddressRepository(db).OneDefaultByAccountId(1)
+	assert.NoError(t, result.Error)
+	assert.Equal(t, uint(2), got.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_AccountAddressOneLatestByAccountId(t *testing.T) {
+	db, mock := AccountAddressNewMockDB()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_addresses` WHERE account_id = ? AND `account_addresses`.`deleted_at` IS NULL ORDER BY id desc LIMIT 1")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id"}).AddRow(5, 1))
+
+	got, result := NewAccountAddressRepository(db).OneLatestByAccountId(1)
+	assert.NoError(t, result.Error)
+	assert.Equal(t, uint(5), got.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_AccountAddressSetDefault(t *testing.T) {
+	clearOthers := "UPDATE `account_addresses` SET `is_default`=?,`updated_by`=?,`updated_at`=? WHERE (account_id = ? AND id <> ? AND is_default = ?) AND `account_addresses`.`deleted_at` IS NULL"
+	setDefault := "UPDATE `account_addresses` SET `is_default`=?,`updated_by`=?,`updated_at`=? WHERE (id = ? AND account_id = ?) AND `account_addresses`.`deleted_at` IS NULL"
+
+	tests := []struct {
+		name    string
+		mock    func(mock sqlmock.Sqlmock)
+		wantErr error
+	}{
+		{
+			name: "Switch the default",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(clearOthers)).
+					WithArgs(false, "email@mail.com", sqlmock.AnyArg(), 1, 5, true).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(setDefault)).
+					WithArgs(true, "email@mail.com", sqlmock.AnyArg(), 5, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			// the address stays a non default one when the old default could not be cleared
+			name: "Clearing the old default fails",
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(clearOthers)).WillReturnError(errors.New("lock wait timeout"))
+				mock.ExpectRollback()
+			},
+			wantErr: errors.New("lock wait timeout"),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := AccountAddressNewMockDB()
+			tt.mock(mock)
+
+			result := NewAccountAddressRepository(db).SetDefault(5, 1, "email@mail.com")
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"gorm.io/gorm"
+)
+
+type AccountErasureRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountErasureRepository(db *gorm.DB) *AccountErasureRepository {
+	return &AccountErasureRepository{
+		db: db,
+	}
+}
+
+func (s *AccountErasureRepository) FilterScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		accountId := q.Get("account_id")
+		status := q.Get("status")
+		if accountId != "" {
+			db = db.Where("account_id = ?", accountId)
+		}
+		if status != "" {
+			db = db.Where("status = ?", status)
+		}
+		return db
+	}
+}
+
+func (s *AccountErasureRepository) PaginateScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		page, _ := strconv.Atoi(q.Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		pageSize, _ := strconv.Atoi(q.Get("page_size"))
+		switch {
+		case pageSize > 100:
+			pageSize = 100
+		case pageSize <= 0:
+			pageSize = 10
+		}
+
+		sortBy := q.Get("sort_by")
+		if sortBy == "" {
+			sortBy = "id"
+		}
+
+		direction := q.Get("direction")
+		if direction == "" {
+			direction = "desc"
+		}
+
+		sort := sortBy + " " + direction
+
+		offset := (page - 1) * pageSize
+		return db.Offset(offset).Limit(pageSize).Order(sort)
+	}
+}
+
+func (s *AccountErasureRepository) MetaPaginate(r *http.Request) map[string]interface{} {
+	q := r.URL.Query()
+	var totalRows int64
+	s.db.Model(model.AccountErasure{}).Scopes(s.FilterScope(r)).Count(&totalRows)
+
+	pageSize, _ := strconv.Atoi(q.Get("page_size"))
+	switch {
+	case pageSize > 100:
+		pageSize = 100
+	case pageSize <= 0:
+		pageSize = 10
+	}
+	totalPages := int(math.Ceil(float64(totalRows) / float64(pageSize)))
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	meta := map[string]interface{}{
+		"page":        page,
+		"page_size":   pageSize,
+		"total_rows":  totalRows,
+		"total_pages": totalPages,
+	}
+	return meta
+}
+
+func (s *AccountErasureRepository) Index(r *http.Request, preload ...string) ([]model.AccountErasure, *gorm.DB) {
+	var table []model.AccountErasure
+	tx := s.db.Scopes(s.FilterScope(r), s.PaginateScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AccountErasureRepository) All(r *http.Request, preload ...string) ([]model.AccountErasure, *gorm.DB) {
+	var table []model.AccountErasure
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AccountErasureRepository) One(r *http.Request, preload ...string) (model.AccountErasure, *gorm.DB) {
+	var table model.AccountErasure
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AccountErasureRepository) OneById(id int, preload ...string) (model.AccountErasure, *gorm.DB) {
+	var table model.AccountErasure
+	tx := s.db.Where("id = ?", id)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AccountErasureRepository) Create(data model.AccountErasure) (model.AccountErasure, *gorm.DB) {
+	var table model.AccountErasure
+	s.AssignData(&table, data)
+	query := s.db.Create(&table)
+	return table, query
+}
+
+func (s *AccountErasureRepository) Update(id int, data model.AccountErasure) (model.AccountErasure, *gorm.DB) {
+	var table model.AccountErasure
+	table, result := s.OneById(id)
+	if result.RowsAffected == 0 {
+		result.Error = errors.New(fmt.Sprintf("data not found with id = %d", id))
+		return table, result
+	}
+	s.AssignData(&table, data)
+	query := s.db.Save(&table)
+	return table, query
+}
+
+func (s *AccountErasureRepository) Delete(id int, isHard bool) *gorm.DB {
+	tx := s.db
+	if isHard {
+		tx = tx.Unscoped()
+	}
+	query := tx.Delete(&model.AccountErasure{}, id)
+	return query
+}
+
+func (s *AccountErasureRepository) AssignData(table *model.AccountErasure, data model.AccountErasure) {
+	dataRV := reflect.ValueOf(data)
+	tableRV := reflect.ValueOf(table)
+	tableRVE := tableRV.Elem()
+
+	for i := 0; i < dataRV.NumField(); i++ {
+		if !dataRV.Field(i).IsZero() && (tableRVE.Field(i) != dataRV.Field(i)) {
+			fv := tableRVE.FieldByName(dataRV.Type().Field(i).Name)
+			fv.Set(dataRV.Field(i))
+		}
+	}
+}
+
+func (s *AccountErasureRepository) OnePendingByAccountId(accountId int) (model.AccountErasure, *gorm.DB) {
+	var table model.AccountErasure
+	query := s.db.Where("account_id = ? AND status = ?", accountId, "PENDING").Find(&table)
+
+	return table, query
+}
+
+func (s *AccountErasureRepository) MarkCompleted(id int) *gorm.DB {
+	query := s.db.Model(&model.AccountErasure{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       "COMPLETED",
+		"completed_at": time.Now(),
+		"error":        "",
+	})
+	return query
+}
+
+func (s *AccountErasureRepository) MarkFailed(id int, reason string) *gorm.DB {
+	query := s.db.Model(&model.AccountErasure{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status": "FAILED",
+		"error":  reason,
+	})
+	return query
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func AccountErasureNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_AccountErasureOnePendingByAccountId(t *testing.T) {
+	tests := []struct {
+		name      string
+		rows      *sqlmock.Rows
+		wantFound bool
+	}{
+		{
+			name:      "Erasure pending",
+			rows:      sqlmock.NewRows([]string{"id", "account_id", "status"}).AddRow(3, 1, "PENDING"),
+			wantFound: true,
+		},
+		{
+			// a completed or failed erasure does not block a new request
+			name: "No erasure pending",
+			rows: sqlmock.NewRows([]string{"id"}),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := AccountErasureNewMockDB()
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_erasures` WHERE (account_id = ? AND status = ?) AND `account_erasures`.`deleted_at` IS NULL")).
+				WithArgs(1, "PENDING").
+				WillReturnRows(tt.rows)
+
+			_, result := NewAccountErasureRepository(db).OnePendingByAccountId(1)
+			assert.NoError(t, result.Error)
+			assert.Equal(t, tt.wantFound, result.RowsAffected > 0)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_AccountErasureMarkCompleted(t *testing.T) {
+	t.Parallel()
+
+	// the error of an earlier failed attempt is cleared
+	db, mock := AccountErasureNewMockDB()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `account_erasures` SET `completed_at`=?,`error`=?,`status`=?,`updated_at`=? WHERE id = ? AND `account_erasures`.`deleted_at` IS NULL")).
+		WithArgs(sqlmock.AnyArg(), "", "COMPLETED", sqlmock.AnyArg(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	result := NewAccountErasureRepository(db).MarkCompleted(3)
+	assert.NoError(t, result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_AccountErasureMarkFailed(t *testing.T) {
+	t.Parallel()
+
+	db, mock := AccountErasureNewMockDB()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `account_erasures` SET `error`=?,`status`=?,`updated_at`=? WHERE id = ? AND `account_erasures`.`deleted_at` IS NULL")).
+		WithArgs("account not found", "FAILED", sqlmock.AnyArg(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	result := NewAccountErasureRepository(db).MarkFailed(3, "account not found")
+	assert.NoError(t, result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
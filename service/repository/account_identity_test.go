@@ -0,0 +1,418 @@
+package repository
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func AccountIdentityNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_AccountIdentityIndex(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.AccountIdentity
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.AccountIdentity{
+				{
+					ID:        1,
+					AccountID: 1,
+					Provider:  "google",
+					Subject:   "subject",
+				},
+				{
+					ID:        2,
+					AccountID: 2,
+					Provider:  "google",
+					Subject:   "subject2",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := AccountIdentityNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "provider", "subject"}).
+					AddRow(1, 1, "google", "subject").
+					AddRow(2, 2, "google", "subject2")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_identities` WHERE `account_identities`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewAccountIdentityRepository(dep.db)
+
+			got, result := p.Index(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_AccountIdentityAll(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.AccountIdentity
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.AccountIdentity{
+				{
+					ID:        1,
+					AccountID: 1,
+					Provider:  "google",
+					Subject:   "subject",
+				},
+				{
+					ID:        2,
+					AccountID: 2,
+					Provider:  "google",
+					Subject:   "subject2",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := AccountIdentityNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "provider", "subject"}).
+					AddRow(1, 1, "google", "subject").
+					AddRow(2, 2, "google", "subject2")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_identities` WHERE `account_identities`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewAccountIdentityRepository(dep.db)
+
+			got, result := p.All(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_AccountIdentityOne(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.AccountIdentity
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: model.AccountIdentity{
+				ID:        1,
+				AccountID: 1,
+				Provider:  "google",
+				Subject:   "subject",
+			},
+			mockFn: func(args) fields {
+				db, mock := AccountIdentityNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "provider", "subject"}).
+					AddRow(1, 1, "google", "subject")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_identities` WHERE `account_identities`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewAccountIdentityRepository(dep.db)
+
+			got, result := p.One(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_AccountIdentityOneById(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id int
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.AccountIdentity
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+			},
+			want: model.AccountIdentity{
+				ID:        1,
+				AccountID: 1,
+				Provider:  "google",
+				Subject:   "subject",
+			},
+			mockFn: func(args) fields {
+				db, mock := AccountIdentityNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "provider", "subject"}).
+					AddRow(1, 1, "google", "subject")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_identities` WHERE id = ? AND `account_identities`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewAccountIdentityRepository(dep.db)
+
+			got, result := p.OneById(tt.args.id)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_AccountIdentityCreate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		accountIdentity model.AccountIdentity
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.AccountIdentity
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				accountIdentity: model.AccountIdentity{
+					ID:        1,
+					AccountID: 1,
+					Provider:  "google",
+					Subject:   "subject",
+				},
+			},
+			want: model.AccountIdentity{
+				ID:        1,
+				AccountID: 1,
+				Provider:  "google",
+				Subject:   "subject",
+				CreatedBy: "SYSTEM",
+				UpdatedBy: "SYSTEM",
+			},
+			mockFn: func(args) fields {
+				db, mock := AccountIdentityNewMockDB()
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `account_identities` (`account_id`,`provider`,`subject`,`email`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewAccountIdentityRepository(dep.db)
+
+			got, result := p.Create(tt.args.accountIdentity)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_AccountIdentityUpdate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id              int
+		accountIdentity model.AccountIdentity
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.AccountIdentity
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+				accountIdentity: model.AccountIdentity{
+					ID:        1,
+					AccountID: 1,
+					Provider:  "google",
+					Subject:   "subject",
+				},
+			},
+			want: model.AccountIdentity{
+				ID:        1,
+				AccountID: 1,
+				Provider:  "google",
+				Subject:   "subject",
+			},
+			mockFn: func(args) fields {
+				db, mock := AccountIdentityNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "provider", "subject"}).
+					AddRow(1, 2, "google", "subject2")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_identities` WHERE id = ? AND `account_identities`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `account_identities` SET `account_id`=?,`provider`=?,`subject`=?,`email`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `account_identities`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewAccountIdentityRepository(dep.db)
+
+			got, result := p.Update(tt.args.id, tt.args.accountIdentity)
+			got.UpdatedAt = nil
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
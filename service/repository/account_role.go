@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"gorm.io/gorm"
+)
+
+type AccountRoleRepository struct {
+	db *gorm.DB
+}
+
+func NewAccountRoleRepository(db *gorm.DB) *AccountRoleRepository {
+	return &AccountRoleRepository{
+		db: db,
+	}
+}
+
+func (s *AccountRoleRepository) FilterScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db
+	}
+}
+
+func (s *AccountRoleRepository) PaginateScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		page, _ := strconv.Atoi(q.Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		pageSize, _ := strconv.Atoi(q.Get("page_size"))
+		switch {
+		case pageSize > 100:
+			pageSize = 100
+		case pageSize <= 0:
+			pageSize = 10
+		}
+
+		sortBy := q.Get("sort_by")
+		if sortBy == "" {
+			sortBy = "id"
+		}
+
+		direction := q.Get("direction")
+		if direction == "" {
+			direction = "desc"
+		}
+
+		sort := sortBy + " " + direction
+
+		offset := (page - 1) * pageSize
+		return db.Offset(offset).Limit(pageSize).Order(sort)
+	}
+}
+
+func (s *AccountRoleRepository) MetaPaginate(r *http.Request) map[string]interface{} {
+	q := r.URL.Query()
+	var totalRows int64
+	s.db.Model(model.AccountRole{}).Scopes(s.FilterScope(r)).Count(&totalRows)
+
+	pageSize, _ := strconv.Atoi(q.Get("page_size"))
+	switch {
+	case pageSize > 100:
+		pageSize = 100
+	case pageSize <= 0:
+		pageSize = 10
+	}
+	totalPages := int(math.Ceil(float64(totalRows) / float64(pageSize)))
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	meta := map[string]interface{}{
+		"page":        page,
+		"page_size":   pageSize,
+		"total_rows":  totalRows,
+		"total_pages": totalPages,
+	}
+	return meta
+}
+
+func (s *AccountRoleRepository) Index(r *http.Request, preload ...string) ([]model.AccountRole, *gorm.DB) {
+	var table []model.AccountRole
+	tx := s.db.Scopes(s.FilterScope(r), s.PaginateScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AccountRoleRepository) All(r *http.Request, preload ...string) ([]model.AccountRole, *gorm.DB) {
+	var table []model.AccountRole
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AccountRoleRepository) One(r *http.Request, preload ...string) (model.AccountRole, *gorm.DB) {
+	var table model.AccountRole
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AccountRoleRepository) OneById(id int, preload ...string) (model.AccountRole, *gorm.DB) {
+	var table model.AccountRole
+	tx := s.db.Where("id = ?", id)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AccountRoleRepository) Create(data model.AccountRole) (model.AccountRole, *gorm.DB) {
+	var table model.AccountRole
+	s.AssignData(&table, data)
+	query := s.db.Create(&table)
+	return table, query
+}
+
+func (s *AccountRoleRepository) Update(id int, data model.AccountRole) (model.AccountRole, *gorm.DB) {
+	var table model.AccountRole
+	table, result := s.OneById(id)
+	if result.RowsAffected == 0 {
+		result.Error = errors.New(fmt.Sprintf("data not found with id = %d", id))
+		return table, result
+	}
+	s.AssignData(&table, data)
+	query := s.db.Save(&table)
+	return table, query
+}
+
+func (s *AccountRoleRepository) Delete(id int, isHard bool) *gorm.DB {
+	tx := s.db
+	if isHard {
+		tx = tx.Unscoped()
+	}
+	query := tx.Delete(&model.AccountRole{}, id)
+	return query
+}
+
+func (s *AccountRoleRepository) AssignData(table *model.AccountRole, data model.AccountRole) {
+	dataRV := reflect.ValueOf(data)
+	tableRV := reflect.ValueOf(table)
+	tableRVE := tableRV.Elem()
+
+	for i := 0; i < dataRV.NumField(); i++ {
+		if !dataRV.Field(i).IsZero() && (tableRVE.Field(i) != dataRV.Field(i)) {
+			fv := tableRVE.FieldByName(dataRV.Type().Field(i).Name)
+			fv.Set(dataRV.Field(i))
+		}
+	}
+}
+
+func (s *AccountRoleRepository) OneByAccountIdAndRoleId(accountId int, roleId int) (model.AccountRole, *gorm.DB) {
+	var table model.AccountRole
+	query := s.db.Where("account_id = ? AND role_id = ?", accountId, roleId).Find(&table)
+
+	return table, query
+}
+
+func (s *AccountRoleRepository) DeleteByRoleId(roleId int) *gorm.DB {
+	query := s.db.Unscoped().Where("role_id = ?", roleId).Delete(&model.AccountRole{})
+	return query
+}
+
+// AllPermissionsByAccountId returns the distinct permissions granted by all roles of the account
+func (s *AccountRoleRepository) AllPermissionsByAccountId(accountId int) ([]string, *gorm.DB) {
+	var permissions []string
+	query := s.db.Model(&model.RolePermission{}).
+		Joins("JOIN account_roles ON account_roles.role_id = role_permissions.role_id AND account_roles.deleted_at IS NULL").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id AND roles.deleted_at IS NULL").
+		Where("account_roles.account_id = ?", accountId).
+		Distinct().
+		Pluck("role_permissions.permission", &permissions)
+
+	return permissions, query
+}
+
+func (s *AccountRoleRepository) DeleteByAccountId(accountId int) *gorm.DB {
+	query := s.db.Unscoped().Where("account_id = ?", accountId).Delete(&model.AccountRole{})
+	return query
+}
@@ -0,0 +1,408 @@
+package repository
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func AccountRoleNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_AccountRoleIndex(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.AccountRole
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.AccountRole{
+				{
+					ID:        1,
+					AccountID: 1,
+					RoleID:    1,
+				},
+				{
+					ID:        2,
+					AccountID: 2,
+					RoleID:    2,
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := AccountRoleNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "role_id"}).
+					AddRow(1, 1, 1).
+					AddRow(2, 2, 2)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_roles` WHERE `account_roles`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewAccountRoleRepository(dep.db)
+
+			got, result := p.Index(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_AccountRoleAll(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.AccountRole
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.AccountRole{
+				{
+					ID:        1,
+					AccountID: 1,
+					RoleID:    1,
+				},
+				{
+					ID:        2,
+					AccountID: 2,
+					RoleID:    2,
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := AccountRoleNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "role_id"}).
+					AddRow(1, 1, 1).
+					AddRow(2, 2, 2)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_roles` WHERE `account_roles`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewAccountRoleRepository(dep.db)
+
+			got, result := p.All(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_AccountRoleOne(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.AccountRole
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: model.AccountRole{
+				ID:        1,
+				AccountID: 1,
+				RoleID:    1,
+			},
+			mockFn: func(args) fields {
+				db, mock := AccountRoleNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "role_id"}).
+					AddRow(1, 1, 1)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_roles` WHERE `account_roles`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewAccountRoleRepository(dep.db)
+
+			got, result := p.One(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_AccountRoleOneById(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id int
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.AccountRole
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+			},
+			want: model.AccountRole{
+				ID:        1,
+				AccountID: 1,
+				RoleID:    1,
+			},
+			mockFn: func(args) fields {
+				db, mock := AccountRoleNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "role_id"}).
+					AddRow(1, 1, 1)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_roles` WHERE id = ? AND `account_roles`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewAccountRoleRepository(dep.db)
+
+			got, result := p.OneById(tt.args.id)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_AccountRoleCreate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		accountRole model.AccountRole
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.AccountRole
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				accountRole: model.AccountRole{
+					ID:        1,
+					AccountID: 1,
+					RoleID:    1,
+				},
+			},
+			want: model.AccountRole{
+				ID:        1,
+				AccountID: 1,
+				RoleID:    1,
+				CreatedBy: "SYSTEM",
+				UpdatedBy: "SYSTEM",
+			},
+			mockFn: func(args) fields {
+				db, mock := AccountRoleNewMockDB()
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `account_roles` (`account_id`,`role_id`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewAccountRoleRepository(dep.db)
+
+			got, result := p.Create(tt.args.accountRole)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_AccountRoleUpdate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id          int
+		accountRole model.AccountRole
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.AccountRole
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+				accountRole: model.AccountRole{
+					ID:        1,
+					AccountID: 1,
+					RoleID:    1,
+				},
+			},
+			want: model.AccountRole{
+				ID:        1,
+				AccountID: 1,
+				RoleID:    1,
+			},
+			mockFn: func(args) fields {
+				db, mock := AccountRoleNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "role_id"}).
+					AddRow(1, 2, 2)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `account_roles` WHERE id = ? AND `account_roles`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `account_roles` SET `account_id`=?,`role_id`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `account_roles`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewAccountRoleRepository(dep.db)
+
+			got, result := p.Update(tt.args.id, tt.args.accountRole)
+			got.UpdatedAt = nil
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -14,6 +14,8 @@ import (
 	"gorm.io/gorm"
 )
 
+var accountPhoneNumber, accountPhoneNumber2 = "088888888888", "088888888882"
+
 func AccountNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -60,7 +62,7 @@ func Test_AccountIndex(t *testing.T) {
 					ID:          1,
 					Name:        "Test",
 					Email:       "test@mail.com",
-					PhoneNumber: "088888888888",
+					PhoneNumber: &accountPhoneNumber,
 					Password:    "$2a$12$9ikh2RxE5tRPwWSMHJvEmOtZ1ISgJnkdFVkmmkGikw2DzXyCc3g5q",
 					Address:     "alamat",
 					Type:        "admin",
@@ -69,7 +71,7 @@ func Test_AccountIndex(t *testing.T) {
 					ID:          2,
 					Name:        "Test2",
 					Email:       "test2@mail.com",
-					PhoneNumber: "088888888882",
+					PhoneNumber: &accountPhoneNumber2,
 					Password:    "$2a$12$9ikh2RxE5tRPwWSMHJvEmOtZ1ISgJnkdFVkmmkGikw2DzXyCc3g5q",
 					Address:     "alamat2",
 					Type:        "admin",
@@ -124,13 +126,17 @@ func Test_AccountAll(t *testing.T) {
 	}{
 		{
 			name: "Success",
-			args: args{},
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
 			want: []model.Account{
 				{
 					ID:          1,
 					Name:        "Test",
 					Email:       "test@mail.com",
-					PhoneNumber: "088888888888",
+					PhoneNumber: &accountPhoneNumber,
 					Password:    "$2a$12$9ikh2RxE5tRPwWSMHJvEmOtZ1ISgJnkdFVkmmkGikw2DzXyCc3g5q",
 					Address:     "alamat",
 					Type:        "admin",
@@ -139,7 +145,7 @@ func Test_AccountAll(t *testing.T) {
 					ID:          2,
 					Name:        "Test2",
 					Email:       "test2@mail.com",
-					PhoneNumber: "088888888882",
+					PhoneNumber: &accountPhoneNumber2,
 					Password:    "$2a$12$9ikh2RxE5tRPwWSMHJvEmOtZ1ISgJnkdFVkmmkGikw2DzXyCc3g5q",
 					Address:     "alamat2",
 					Type:        "admin",
@@ -194,12 +200,16 @@ func Test_AccountOne(t *testing.T) {
 	}{
 		{
 			name: "Success",
-			args: args{},
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
 			want: model.Account{
 				ID:          1,
 				Name:        "Test",
 				Email:       "test@mail.com",
-				PhoneNumber: "088888888888",
+				PhoneNumber: &accountPhoneNumber,
 				Password:    "$2a$12$9ikh2RxE5tRPwWSMHJvEmOtZ1ISgJnkdFVkmmkGikw2DzXyCc3g5q",
 				Address:     "alamat",
 				Type:        "admin",
@@ -259,7 +269,7 @@ func Test_AccountOneById(t *testing.T) {
 				ID:          1,
 				Name:        "Test",
 				Email:       "test@mail.com",
-				PhoneNumber: "088888888888",
+				PhoneNumber: &accountPhoneNumber,
 				Password:    "$2a$12$9ikh2RxE5tRPwWSMHJvEmOtZ1ISgJnkdFVkmmkGikw2DzXyCc3g5q",
 				Address:     "alamat",
 				Type:        "admin",
@@ -317,7 +327,7 @@ func Test_AccountCreate(t *testing.T) {
 					ID:          1,
 					Name:        "Test",
 					Email:       "test@mail.com",
-					PhoneNumber: "088888888888",
+					PhoneNumber: &accountPhoneNumber,
 					Password:    "$2a$12$9ikh2RxE5tRPwWSMHJvEmOtZ1ISgJnkdFVkmmkGikw2DzXyCc3g5q",
 					Address:     "alamat",
 					Type:        "admin",
@@ -327,7 +337,7 @@ func Test_AccountCreate(t *testing.T) {
 				ID:          1,
 				Name:        "Test",
 				Email:       "test@mail.com",
-				PhoneNumber: "088888888888",
+				PhoneNumber: &accountPhoneNumber,
 				Password:    "$2a$12$9ikh2RxE5tRPwWSMHJvEmOtZ1ISgJnkdFVkmmkGikw2DzXyCc3g5q",
 				Address:     "alamat",
 				Type:        "admin",
@@ -338,7 +348,7 @@ func Test_AccountCreate(t *testing.T) {
 				db, mock := AccountNewMockDB()
 
 				mock.ExpectBegin()
-				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `accounts` (`name`,`email`,`phone_number`,`password`,`address`,`type`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `accounts` (`name`,`email`,`phone_number`,`password`,`address`,`type`,`email_verified_at`,`phone_verified_at`,`suspended_at`,`password_reset_required`,`totp_secret`,`totp_enabled_at`,`totp_last_step`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit()
 
 				return fields{
@@ -388,7 +398,7 @@ func Test_AccountUpdate(t *testing.T) {
 				account: model.Account{
 					Name:        "Test",
 					Email:       "test@mail.com",
-					PhoneNumber: "088888888888",
+					PhoneNumber: &accountPhoneNumber,
 					Password:    "$2a$12$9ikh2RxE5tRPwWSMHJvEmOtZ1ISgJnkdFVkmmkGikw2DzXyCc3g5q",
 					Address:     "alamat",
 					Type:        "admin",
@@ -398,7 +408,7 @@ func Test_AccountUpdate(t *testing.T) {
 				ID:          1,
 				Name:        "Test",
 				Email:       "test@mail.com",
-				PhoneNumber: "088888888888",
+				PhoneNumber: &accountPhoneNumber,
 				Password:    "$2a$12$9ikh2RxE5tRPwWSMHJvEmOtZ1ISgJnkdFVkmmkGikw2DzXyCc3g5q",
 				Address:     "alamat",
 				Type:        "admin",
@@ -411,7 +421,7 @@ func Test_AccountUpdate(t *testing.T) {
 				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE id = ? AND `accounts`.`deleted_at` IS NULL")).WillReturnRows(row)
 
 				mock.ExpectBegin()
-				mock.ExpectExec(regexp.QuoteMeta("UPDATE `accounts` SET `name`=?,`email`=?,`phone_number`=?,`password`=?,`address`=?,`type`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `accounts`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `accounts` SET `name`=?,`email`=?,`phone_number`=?,`password`=?,`address`=?,`type`=?,`email_verified_at`=?,`phone_verified_at`=?,`suspended_at`=?,`password_reset_required`=?,`totp_secret`=?,`totp_enabled_at`=?,`totp_last_step`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `accounts`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit()
 
 				return fields{
@@ -437,3 +447,50 @@ func Test_AccountUpdate(t *testing.T) {
 		})
 	}
 }
+
+func Test_AccountUseTotpStep(t *testing.T) {
+	tests := []struct {
+		name         string
+		affected     int64
+		wantAffected int64
+	}{
+		{name: "Newer step", affected: 1, wantAffected: 1},
+		// the step or a later one was used already
+		{name: "Replayed step", affected: 0, wantAffected: 0},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := AccountNewMockDB()
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE `accounts` SET `totp_last_step`=?,`updated_at`=? WHERE (id = ? AND totp_last_step < ?) AND `accounts`.`deleted_at` IS NULL")).
+				WithArgs(int64(37037037), sqlmock.AnyArg(), 1, int64(37037037)).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+			mock.ExpectCommit()
+
+			result := NewAccountRepository(db).UseTotpStep(1, 37037037)
+			assert.NoError(t, result.Error)
+			assert.Equal(t, tt.wantAffected, result.RowsAffected)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// the personal data is replaced and the account is soft deleted, a suspension already set is kept
+func Test_AccountAnonymize(t *testing.T) {
+	t.Parallel()
+
+	db, mock := AccountNewMockDB()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `accounts` SET `address`=?,`deleted_at`=?,`deleted_by`=?,`email`=?,`email_verified_at`=?,`name`=?,`password`=?,`password_reset_required`=?,`phone_number`=?,`phone_verified_at`=?,`suspended_at`=COALESCE(suspended_at, ?),`totp_enabled_at`=?,`totp_secret`=?,`updated_by`=?,`updated_at`=? WHERE id = ?")).
+		WithArgs("", sqlmock.AnyArg(), "admin@mail.com", "erased-7@erased.invalid", nil, "Deleted account", "", false, "erased-7", nil, sqlmock.AnyArg(), nil, nil, "admin@mail.com", sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	result := NewAccountRepository(db).Anonymize(7, "erased-7@erased.invalid", "erased-7", "admin@mail.com")
+	assert.NoError(t, result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"gorm.io/gorm"
+)
+
+type AdminActionRepository struct {
+	db *gorm.DB
+}
+
+func NewAdminActionRepository(db *gorm.DB) *AdminActionRepository {
+	return &AdminActionRepository{
+		db: db,
+	}
+}
+
+func (s *AdminActionRepository) FilterScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		accountId := q.Get("account_id")
+		action := q.Get("action")
+		if accountId != "" {
+			db = db.Where("account_id = ?", accountId)
+		}
+		if action != "" {
+			db = db.Where("action = ?", action)
+		}
+		return db
+	}
+}
+
+func (s *AdminActionRepository) PaginateScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		page, _ := strconv.Atoi(q.Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		pageSize, _ := strconv.Atoi(q.Get("page_size"))
+		switch {
+		case pageSize > 100:
+			pageSize = 100
+		case pageSize <= 0:
+			pageSize = 10
+		}
+
+		sortBy := q.Get("sort_by")
+		if sortBy == "" {
+			sortBy = "id"
+		}
+
+		direction := q.Get("direction")
+		if direction == "" {
+			direction = "desc"
+		}
+
+		sort := sortBy + " " + direction
+
+		offset := (page - 1) * pageSize
+		return db.Offset(offset).Limit(pageSize).Order(sort)
+	}
+}
+
+func (s *AdminActionRepository) MetaPaginate(r *http.Request) map[string]interface{} {
+	q := r.URL.Query()
+	var totalRows int64
+	s.db.Model(model.AdminAction{}).Scopes(s.FilterScope(r)).Count(&totalRows)
+
+	pageSize, _ := strconv.Atoi(q.Get("page_size"))
+	switch {
+	case pageSize > 100:
+		pageSize = 100
+	case pageSize <= 0:
+		pageSize = 10
+	}
+	totalPages := int(math.Ceil(float64(totalRows) / float64(pageSize)))
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	meta := map[string]interface{}{
+		"page":        page,
+		"page_size":   pageSize,
+		"total_rows":  totalRows,
+		"total_pages": totalPages,
+	}
+	return meta
+}
+
+func (s *AdminActionRepository) Index(r *http.Request, preload ...string) ([]model.AdminAction, *gorm.DB) {
+	var table []model.AdminAction
+	tx := s.db.Scopes(s.FilterScope(r), s.PaginateScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AdminActionRepository) All(r *http.Request, preload ...string) ([]model.AdminAction, *gorm.DB) {
+	var table []model.AdminAction
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AdminActionRepository) One(r *http.Request, preload ...string) (model.AdminAction, *gorm.DB) {
+	var table model.AdminAction
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AdminActionRepository) OneById(id int, preload ...string) (model.AdminAction, *gorm.DB) {
+	var table model.AdminAction
+	tx := s.db.Where("id = ?", id)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AdminActionRepository) Create(data model.AdminAction) (model.AdminAction, *gorm.DB) {
+	var table model.AdminAction
+	s.AssignData(&table, data)
+	query := s.db.Create(&table)
+	return table, query
+}
+
+func (s *AdminActionRepository) Update(id int, data model.AdminAction) (model.AdminAction, *gorm.DB) {
+	var table model.AdminAction
+	table, result := s.OneById(id)
+	if result.RowsAffected == 0 {
+		result.Error = errors.New(fmt.Sprintf("data not found with id = %d", id))
+		return table, result
+	}
+	s.AssignData(&table, data)
+	query := s.db.Save(&table)
+	return table, query
+}
+
+func (s *AdminActionRepository) Delete(id int, isHard bool) *gorm.DB {
+	tx := s.db
+	if isHard {
+		tx = tx.Unscoped()
+	}
+	query := tx.Delete(&model.AdminAction{}, id)
+	return query
+}
+
+func (s *AdminActionRepository) AssignData(table *model.AdminAction, data model.AdminAction) {
+	dataRV := reflect.ValueOf(data)
+	tableRV := reflect.ValueOf(table)
+	tableRVE := tableRV.Elem()
+
+	for i := 0; i < dataRV.NumField(); i++ {
+		if !dataRV.Field(i).IsZero() && (tableRVE.Field(i) != dataRV.Field(i)) {
+			fv := tableRVE.FieldByName(dataRV.Type().Field(i).Name)
+			fv.Set(dataRV.Field(i))
+		}
+	}
+}
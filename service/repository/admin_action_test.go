@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func AdminActionNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_AdminActionIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawQuery string
+		query    string
+		args     []driver.Value
+	}{
+		{
+			name:  "Newest first",
+			query: "SELECT * FROM `admin_actions` WHERE `admin_actions`.`deleted_at` IS NULL ORDER BY id desc LIMIT 10",
+		},
+		{
+			name:     "Actions of one account",
+			rawQuery: "account_id=2&action=SUSPEND",
+			query:    "SELECT * FROM `admin_actions` WHERE account_id = ? AND action = ? AND `admin_actions`.`deleted_at` IS NULL ORDER BY id desc LIMIT 10",
+			args:     []driver.Value{"2", "SUSPEND"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := AdminActionNewMockDB()
+			mock.ExpectQuery(regexp.QuoteMeta(tt.query)).
+				WithArgs(tt.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "action", "reason", "created_by"}).
+					AddRow(3, 2, "SUSPEND", "chargeback fraud", "admin@mail.com"))
+
+			got, result := NewAdminActionRepository(db).Index(&http.Request{URL: &url.URL{RawQuery: tt.rawQuery}})
+			assert.NoError(t, result.Error)
+			assert.Equal(t, []model.AdminAction{
+				{ID: 3, AccountID: 2, Action: "SUSPEND", Reason: "chargeback fraud", CreatedBy: "admin@mail.com"},
+			}, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"gorm.io/gorm"
+)
+
+type ApiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewApiKeyRepository(db *gorm.DB) *ApiKeyRepository {
+	return &ApiKeyRepository{
+		db: db,
+	}
+}
+
+func (s *ApiKeyRepository) FilterScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		accountId := q.Get("account_id")
+		if accountId != "" {
+			db = db.Where("account_id = ?", accountId)
+		}
+		switch q.Get("status") {
+		case "ACTIVE":
+			db = db.Where("revoked_at IS NULL AND (expired_at IS NULL OR expired_at > ?)", time.Now())
+		case "EXPIRED":
+			db = db.Where("revoked_at IS NULL AND expired_at <= ?", time.Now())
+		case "REVOKED":
+			db = db.Where("revoked_at IS NOT NULL")
+		}
+		return db
+	}
+}
+
+func (s *ApiKeyRepository) PaginateScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		page, _ := strconv.Atoi(q.Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		pageSize, _ := strconv.Atoi(q.Get("page_size"))
+		switch {
+		case pageSize > 100:
+			pageSize = 100
+		case pageSize <= 0:
+			pageSize = 10
+		}
+
+		sortBy := q.Get("sort_by")
+		if sortBy == "" {
+			sortBy = "id"
+		}
+
+		direction := q.Get("direction")
+		if direction == "" {
+			direction = "desc"
+		}
+
+		sort := sortBy + " " + direction
+
+		offset := (page - 1) * pageSize
+		return db.Offset(offset).Limit(pageSize).Order(sort)
+	}
+}
+
+func (s *ApiKeyRepository) MetaPaginate(r *http.Request) map[string]interface{} {
+	q := r.URL.Query()
+	var totalRows int64
+	s.db.Model(model.ApiKey{}).Scopes(s.FilterScope(r)).Count(&totalRows)
+
+	pageSize, _ := strconv.Atoi(q.Get("page_size"))
+	switch {
+	case pageSize > 100:
+		pageSize = 100
+	case pageSize <= 0:
+		pageSize = 10
+	}
+	totalPages := int(math.Ceil(float64(totalRows) / float64(pageSize)))
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	meta := map[string]interface{}{
+		"page":        page,
+		"page_size":   pageSize,
+		"total_rows":  totalRows,
+		"total_pages": totalPages,
+	}
+	return meta
+}
+
+func (s *ApiKeyRepository) Index(r *http.Request, preload ...string) ([]model.ApiKey, *gorm.DB) {
+	var table []model.ApiKey
+	tx := s.db.Scopes(s.FilterScope(r), s.PaginateScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *ApiKeyRepository) All(r *http.Request, preload ...string) ([]model.ApiKey, *gorm.DB) {
+	var table []model.ApiKey
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *ApiKeyRepository) One(r *http.Request, preload ...string) (model.ApiKey, *gorm.DB) {
+	var table model.ApiKey
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *ApiKeyRepository) OneById(id int, preload ...string) (model.ApiKey, *gorm.DB) {
+	var table model.ApiKey
+	tx := s.db.Where("id = ?", id)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *ApiKeyRepository) Create(data model.ApiKey) (model.ApiKey, *gorm.DB) {
+	var table model.ApiKey
+	s.AssignData(&table, data)
+	query := s.db.Create(&table)
+	return table, query
+}
+
+func (s *ApiKeyRepository) Update(id int, data model.ApiKey) (model.ApiKey, *gorm.DB) {
+	var table model.ApiKey
+	table, result := s.OneById(id)
+	if result.RowsAffected == 0 {
+		result.Error = errors.New(fmt.Sprintf("data not found with id = %d", id))
+		return table, result
+	}
+	s.AssignData(&table, data)
+	query := s.db.Save(&table)
+	return table, query
+}
+
+func (s *ApiKeyRepository) Delete(id int, isHard bool) *gorm.DB {
+	tx := s.db
+	if isHard {
+		tx = tx.Unscoped()
+	}
+	query := tx.Delete(&model.ApiKey{}, id)
+	return query
+}
+
+func (s *ApiKeyRepository) AssignData(table *model.ApiKey, data model.ApiKey) {
+	dataRV := reflect.ValueOf(data)
+	tableRV := reflect.ValueOf(table)
+	tableRVE := tableRV.Elem()
+
+	for i := 0; i < dataRV.NumField(); i++ {
+		if !dataRV.Field(i).IsZero() && (tableRVE.Field(i) != dataRV.Field(i)) {
+			fv := tableRVE.FieldByName(dataRV.Type().Field(i).Name)
+			fv.Set(dataRV.Field(i))
+		}
+	}
+}
+
+func (s *ApiKeyRepository) OneByKeyHash(keyHash string, preload ...string) (model.ApiKey, *gorm.DB) {
+	var table model.ApiKey
+	tx := s.db.Where("key_hash = ?", keyHash)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *ApiKeyRepository) Revoke(id int, updatedBy string) *gorm.DB {
+	query := s.db.Model(&model.ApiKey{}).Where("id = ? AND revoked_at IS NULL", id).Updates(map[string]interface{}{
+		"revoked_at": time.Now(),
+		"updated_by": updatedBy,
+	})
+	return query
+}
+
+// MarkUsed only writes once per interval so busy keys do not update their row on every request
+func (s *ApiKeyRepository) MarkUsed(id int, ip string, interval time.Duration) *gorm.DB {
+	now := time.Now()
+	query := s.db.Model(&model.ApiKey{}).Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-interval)).Updates(map[string]interface{}{
+		"last_used_at": now,
+		"last_used_ip": ip,
+	})
+	return query
+}
+
+// RevokeByAccountId revokes every key of the account
+func (s *ApiKeyRepository) RevokeByAccountId(accountId int, updatedBy string) *gorm.DB {
+	query := s.db.Model(&model.ApiKey{}).Where("account_id = ? AND revoked_at IS NULL", accountId).Updates(map[string]interface{}{
+		"revoked_at": time.Now(),
+		"updated_by": updatedBy,
+	})
+	return query
+}
@@ -0,0 +1,408 @@
+package repository
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func ApiKeyPermissionNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_ApiKeyPermissionIndex(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.ApiKeyPermission
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.ApiKeyPermission{
+				{
+					ID:         1,
+					ApiKeyID:   1,
+					Permission: "item:write",
+				},
+				{
+					ID:         2,
+					ApiKeyID:   2,
+					Permission: "voucher:write",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := ApiKeyPermissionNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "api_key_id", "permission"}).
+					AddRow(1, 1, "item:write").
+					AddRow(2, 2, "voucher:write")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_key_permissions` WHERE `api_key_permissions`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewApiKeyPermissionRepository(dep.db)
+
+			got, result := p.Index(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ApiKeyPermissionAll(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.ApiKeyPermission
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.ApiKeyPermission{
+				{
+					ID:         1,
+					ApiKeyID:   1,
+					Permission: "item:write",
+				},
+				{
+					ID:         2,
+					ApiKeyID:   2,
+					Permission: "voucher:write",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := ApiKeyPermissionNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "api_key_id", "permission"}).
+					AddRow(1, 1, "item:write").
+					AddRow(2, 2, "voucher:write")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_key_permissions` WHERE `api_key_permissions`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewApiKeyPermissionRepository(dep.db)
+
+			got, result := p.All(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ApiKeyPermissionOne(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.ApiKeyPermission
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: model.ApiKeyPermission{
+				ID:         1,
+				ApiKeyID:   1,
+				Permission: "item:write",
+			},
+			mockFn: func(args) fields {
+				db, mock := ApiKeyPermissionNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "api_key_id", "permission"}).
+					AddRow(1, 1, "item:write")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_key_permissions` WHERE `api_key_permissions`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewApiKeyPermissionRepository(dep.db)
+
+			got, result := p.One(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ApiKeyPermissionOneById(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id int
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.ApiKeyPermission
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+			},
+			want: model.ApiKeyPermission{
+				ID:         1,
+				ApiKeyID:   1,
+				Permission: "item:write",
+			},
+			mockFn: func(args) fields {
+				db, mock := ApiKeyPermissionNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "api_key_id", "permission"}).
+					AddRow(1, 1, "item:write")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_key_permissions` WHERE id = ? AND `api_key_permissions`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewApiKeyPermissionRepository(dep.db)
+
+			got, result := p.OneById(tt.args.id)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ApiKeyPermissionCreate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		apiKeyPermission model.ApiKeyPermission
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.ApiKeyPermission
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				apiKeyPermission: model.ApiKeyPermission{
+					ID:         1,
+					ApiKeyID:   1,
+					Permission: "item:write",
+				},
+			},
+			want: model.ApiKeyPermission{
+				ID:         1,
+				ApiKeyID:   1,
+				Permission: "item:write",
+				CreatedBy:  "SYSTEM",
+				UpdatedBy:  "SYSTEM",
+			},
+			mockFn: func(args) fields {
+				db, mock := ApiKeyPermissionNewMockDB()
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `api_key_permissions` (`api_key_id`,`permission`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewApiKeyPermissionRepository(dep.db)
+
+			got, result := p.Create(tt.args.apiKeyPermission)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ApiKeyPermissionUpdate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id               int
+		apiKeyPermission model.ApiKeyPermission
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.ApiKeyPermission
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+				apiKeyPermission: model.ApiKeyPermission{
+					ID:         1,
+					ApiKeyID:   1,
+					Permission: "item:write",
+				},
+			},
+			want: model.ApiKeyPermission{
+				ID:         1,
+				ApiKeyID:   1,
+				Permission: "item:write",
+			},
+			mockFn: func(args) fields {
+				db, mock := ApiKeyPermissionNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "api_key_id", "permission"}).
+					AddRow(1, 2, "voucher:write")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_key_permissions` WHERE id = ? AND `api_key_permissions`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `api_key_permissions` SET `api_key_id`=?,`permission`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `api_key_permissions`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewApiKeyPermissionRepository(dep.db)
+
+			got, result := p.Update(tt.args.id, tt.args.apiKeyPermission)
+			got.UpdatedAt = nil
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
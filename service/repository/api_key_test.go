@@ -0,0 +1,418 @@
+package repository
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func ApiKeyNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_ApiKeyIndex(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.ApiKey
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.ApiKey{
+				{
+					ID:        1,
+					AccountID: 1,
+					Name:      "erp",
+					Prefix:    "osc_1234abcd",
+				},
+				{
+					ID:        2,
+					AccountID: 2,
+					Name:      "warehouse",
+					Prefix:    "osc_5678abcd",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := ApiKeyNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "name", "prefix"}).
+					AddRow(1, 1, "erp", "osc_1234abcd").
+					AddRow(2, 2, "warehouse", "osc_5678abcd")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_keys` WHERE `api_keys`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewApiKeyRepository(dep.db)
+
+			got, result := p.Index(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ApiKeyAll(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.ApiKey
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.ApiKey{
+				{
+					ID:        1,
+					AccountID: 1,
+					Name:      "erp",
+					Prefix:    "osc_1234abcd",
+				},
+				{
+					ID:        2,
+					AccountID: 2,
+					Name:      "warehouse",
+					Prefix:    "osc_5678abcd",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := ApiKeyNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "name", "prefix"}).
+					AddRow(1, 1, "erp", "osc_1234abcd").
+					AddRow(2, 2, "warehouse", "osc_5678abcd")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_keys` WHERE `api_keys`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewApiKeyRepository(dep.db)
+
+			got, result := p.All(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ApiKeyOne(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.ApiKey
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: model.ApiKey{
+				ID:        1,
+				AccountID: 1,
+				Name:      "erp",
+				Prefix:    "osc_1234abcd",
+			},
+			mockFn: func(args) fields {
+				db, mock := ApiKeyNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "name", "prefix"}).
+					AddRow(1, 1, "erp", "osc_1234abcd")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_keys` WHERE `api_keys`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewApiKeyRepository(dep.db)
+
+			got, result := p.One(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ApiKeyOneById(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id int
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.ApiKey
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+			},
+			want: model.ApiKey{
+				ID:        1,
+				AccountID: 1,
+				Name:      "erp",
+				Prefix:    "osc_1234abcd",
+			},
+			mockFn: func(args) fields {
+				db, mock := ApiKeyNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "name", "prefix"}).
+					AddRow(1, 1, "erp", "osc_1234abcd")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_keys` WHERE id = ? AND `api_keys`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewApiKeyRepository(dep.db)
+
+			got, result := p.OneById(tt.args.id)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ApiKeyCreate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		apiKey model.ApiKey
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.ApiKey
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				apiKey: model.ApiKey{
+					ID:        1,
+					AccountID: 1,
+					Name:      "erp",
+					Prefix:    "osc_1234abcd",
+				},
+			},
+			want: model.ApiKey{
+				ID:        1,
+				AccountID: 1,
+				Name:      "erp",
+				Prefix:    "osc_1234abcd",
+				CreatedBy: "SYSTEM",
+				UpdatedBy: "SYSTEM",
+			},
+			mockFn: func(args) fields {
+				db, mock := ApiKeyNewMockDB()
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `api_keys` (`account_id`,`name`,`prefix`,`key_hash`,`expired_at`,`last_used_at`,`last_used_ip`,`revoked_at`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewApiKeyRepository(dep.db)
+
+			got, result := p.Create(tt.args.apiKey)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_ApiKeyUpdate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id     int
+		apiKey model.ApiKey
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.ApiKey
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+				apiKey: model.ApiKey{
+					ID:        1,
+					AccountID: 1,
+					Name:      "erp",
+					Prefix:    "osc_1234abcd",
+				},
+			},
+			want: model.ApiKey{
+				ID:        1,
+				AccountID: 1,
+				Name:      "erp",
+				Prefix:    "osc_1234abcd",
+			},
+			mockFn: func(args) fields {
+				db, mock := ApiKeyNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "name", "prefix"}).
+					AddRow(1, 2, "warehouse", "osc_5678abcd")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `api_keys` WHERE id = ? AND `api_keys`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `api_keys` SET `account_id`=?,`name`=?,`prefix`=?,`key_hash`=?,`expired_at`=?,`last_used_at`=?,`last_used_ip`=?,`revoked_at`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `api_keys`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewApiKeyRepository(dep.db)
+
+			got, result := p.Update(tt.args.id, tt.args.apiKey)
+			got.UpdatedAt = nil
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"gorm.io/gorm"
+)
+
+type AuditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) *AuditLogRepository {
+	return &AuditLogRepository{
+		db: db,
+	}
+}
+
+func (s *AuditLogRepository) FilterScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		for _, column := range []string{"actor", "action", "entity", "entity_id", "request_id", "client_ip"} {
+			if value := q.Get(column); value != "" {
+				db = db.Where(column+" = ?", value)
+			}
+		}
+		if from, err := time.Parse(time.RFC3339, q.Get("from")); err == nil {
+			db = db.Where("created_at >= ?", from)
+		}
+		if to, err := time.Parse(time.RFC3339, q.Get("to")); err == nil {
+			db = db.Where("created_at < ?", to)
+		}
+		return db
+	}
+}
+
+func (s *AuditLogRepository) PaginateScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		page, _ := strconv.Atoi(q.Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		pageSize, _ := strconv.Atoi(q.Get("page_size"))
+		switch {
+		case pageSize > 100:
+			pageSize = 100
+		case pageSize <= 0:
+			pageSize = 10
+		}
+
+		sortBy := q.Get("sort_by")
+		if sortBy == "" {
+			sortBy = "id"
+		}
+
+		direction := q.Get("direction")
+		if direction == "" {
+			direction = "desc"
+		}
+
+		sort := sortBy + " " + direction
+
+		offset := (page - 1) * pageSize
+		return db.Offset(offset).Limit(pageSize).Order(sort)
+	}
+}
+
+func (s *AuditLogRepository) MetaPaginate(r *http.Request) map[string]interface{} {
+	q := r.URL.Query()
+	var totalRows int64
+	s.db.Model(model.AuditLog{}).Scopes(s.FilterScope(r)).Count(&totalRows)
+
+	pageSize, _ := strconv.Atoi(q.Get("page_size"))
+	switch {
+	case pageSize > 100:
+		pageSize = 100
+	case pageSize <= 0:
+		pageSize = 10
+	}
+	totalPages := int(math.Ceil(float64(totalRows) / float64(pageSize)))
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	meta := map[string]interface{}{
+		"page":        page,
+		"page_size":   pageSize,
+		"total_rows":  totalRows,
+		"total_pages": totalPages,
+	}
+	return meta
+}
+
+func (s *AuditLogRepository) Index(r *http.Request, preload ...string) ([]model.AuditLog, *gorm.DB) {
+	var table []model.AuditLog
+	tx := s.db.Scopes(s.FilterScope(r), s.PaginateScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AuditLogRepository) All(r *http.Request, preload ...string) ([]model.AuditLog, *gorm.DB) {
+	var table []model.AuditLog
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AuditLogRepository) One(r *http.Request, preload ...string) (model.AuditLog, *gorm.DB) {
+	var table model.AuditLog
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AuditLogRepository) OneById(id int, preload ...string) (model.AuditLog, *gorm.DB) {
+	var table model.AuditLog
+	tx := s.db.Where("id = ?", id)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *AuditLogRepository) Create(data model.AuditLog) (model.AuditLog, *gorm.DB) {
+	var table model.AuditLog
+	s.AssignData(&table, data)
+	query := s.db.Create(&table)
+	return table, query
+}
+
+func (s *AuditLogRepository) Update(id int, data model.AuditLog) (model.AuditLog, *gorm.DB) {
+	var table model.AuditLog
+	table, result := s.OneById(id)
+	if result.RowsAffected == 0 {
+		result.Error = errors.New(fmt.Sprintf("data not found with id = %d", id))
+		return table, result
+	}
+	s.AssignData(&table, data)
+	query := s.db.Save(&table)
+	return table, query
+}
+
+func (s *AuditLogRepository) Delete(id int, isHard bool) *gorm.DB {
+	tx := s.db
+	if isHard {
+		tx = tx.Unscoped()
+	}
+	query := tx.Delete(&model.AuditLog{}, id)
+	return query
+}
+
+func (s *AuditLogRepository) AssignData(table *model.AuditLog, data model.AuditLog) {
+	dataRV := reflect.ValueOf(data)
+	tableRV := reflect.ValueOf(table)
+	tableRVE := tableRV.Elem()
+
+	for i := 0; i < dataRV.NumField(); i++ {
+		if !dataRV.Field(i).IsZero() && (tableRVE.Field(i) != dataRV.Field(i)) {
+			fv := tableRVE.FieldByName(dataRV.Type().Field(i).Name)
+			fv.Set(dataRV.Field(i))
+		}
+	}
+}
+
+// Latest returns at most limit filtered logs, newest first
+func (s *AuditLogRepository) Latest(r *http.Request, limit int) ([]model.AuditLog, *gorm.DB) {
+	var table []model.AuditLog
+	query := s.db.Scopes(s.FilterScope(r)).Order("id desc").Limit(limit).Find(&table)
+
+	return table, query
+}
+
+func (s *AuditLogRepository) ReplaceActor(actor string, replacement string) *gorm.DB {
+	query := s.db.Model(&model.AuditLog{}).Where("actor = ?", actor).UpdateColumns(map[string]interface{}{
+		"actor":      replacement,
+		"created_by": replacement,
+		"updated_by": replacement,
+	})
+	return query
+}
+
+// ClearValuesByEntity drops before and after of the logs of a row, the actions stay logged
+func (s *AuditLogRepository) ClearValuesByEntity(entity string, entityId string) *gorm.DB {
+	query := s.db.Model(&model.AuditLog{}).Where("entity = ? AND entity_id = ?", entity, entityId).UpdateColumns(map[string]interface{}{
+		"before": "",
+		"after":  "",
+	})
+	return query
+}
+
+// ClearValuesByAccountId drops before and after of the logs of every row of the models owned by the account,
+// it has to run before the rows are deleted
+func (s *AuditLogRepository) ClearValuesByAccountId(accountId int, models ...interface{}) error {
+	for _, m := range models {
+		stmt := &gorm.Statement{DB: s.db}
+		if err := stmt.Parse(m); err != nil {
+			return err
+		}
+		ids := s.db.Unscoped().Model(m).Select("CAST(id AS CHAR)").Where("account_id = ?", accountId)
+		if result := s.db.Model(&model.AuditLog{}).Where("entity = ? AND entity_id IN (?)", stmt.Schema.Table, ids).UpdateColumns(map[string]interface{}{
+			"before": "",
+			"after":  "",
+		}); result.Error != nil {
+			return result.Error
+		}
+	}
+	return nil
+}
@@ -0,0 +1,418 @@
+package repository
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func AuditLogNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_AuditLogIndex(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.AuditLog
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.AuditLog{
+				{
+					ID:     1,
+					Actor:  "admin@mail.com",
+					Action: "CREATE",
+					Entity: "items",
+				},
+				{
+					ID:     2,
+					Actor:  "SYSTEM",
+					Action: "UPDATE",
+					Entity: "vouchers",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := AuditLogNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "actor", "action", "entity"}).
+					AddRow(1, "admin@mail.com", "CREATE", "items").
+					AddRow(2, "SYSTEM", "UPDATE", "vouchers")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `audit_logs` WHERE `audit_logs`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewAuditLogRepository(dep.db)
+
+			got, result := p.Index(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_AuditLogAll(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.AuditLog
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.AuditLog{
+				{
+					ID:     1,
+					Actor:  "admin@mail.com",
+					Action: "CREATE",
+					Entity: "items",
+				},
+				{
+					ID:     2,
+					Actor:  "SYSTEM",
+					Action: "UPDATE",
+					Entity: "vouchers",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := AuditLogNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "actor", "action", "entity"}).
+					AddRow(1, "admin@mail.com", "CREATE", "items").
+					AddRow(2, "SYSTEM", "UPDATE", "vouchers")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `audit_logs` WHERE `audit_logs`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewAuditLogRepository(dep.db)
+
+			got, result := p.All(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_AuditLogOne(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.AuditLog
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: model.AuditLog{
+				ID:     1,
+				Actor:  "admin@mail.com",
+				Action: "CREATE",
+				Entity: "items",
+			},
+			mockFn: func(args) fields {
+				db, mock := AuditLogNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "actor", "action", "entity"}).
+					AddRow(1, "admin@mail.com", "CREATE", "items")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `audit_logs` WHERE `audit_logs`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewAuditLogRepository(dep.db)
+
+			got, result := p.One(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_AuditLogOneById(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id int
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.AuditLog
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+			},
+			want: model.AuditLog{
+				ID:     1,
+				Actor:  "admin@mail.com",
+				Action: "CREATE",
+				Entity: "items",
+			},
+			mockFn: func(args) fields {
+				db, mock := AuditLogNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "actor", "action", "entity"}).
+					AddRow(1, "admin@mail.com", "CREATE", "items")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `audit_logs` WHERE id = ? AND `audit_logs`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewAuditLogRepository(dep.db)
+
+			got, result := p.OneById(tt.args.id)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_AuditLogCreate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		auditLog model.AuditLog
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.AuditLog
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				auditLog: model.AuditLog{
+					ID:     1,
+					Actor:  "admin@mail.com",
+					Action: "CREATE",
+					Entity: "items",
+				},
+			},
+			want: model.AuditLog{
+				ID:        1,
+				Actor:     "admin@mail.com",
+				Action:    "CREATE",
+				Entity:    "items",
+				CreatedBy: "SYSTEM",
+				UpdatedBy: "SYSTEM",
+			},
+			mockFn: func(args) fields {
+				db, mock := AuditLogNewMockDB()
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `audit_logs` (`actor`,`action`,`entity`,`entity_id`,`before`,`after`,`request_id`,`client_ip`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewAuditLogRepository(dep.db)
+
+			got, result := p.Create(tt.args.auditLog)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_AuditLogUpdate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id       int
+		auditLog model.AuditLog
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.AuditLog
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+				auditLog: model.AuditLog{
+					ID:     1,
+					Actor:  "admin@mail.com",
+					Action: "CREATE",
+					Entity: "items",
+				},
+			},
+			want: model.AuditLog{
+				ID:     1,
+				Actor:  "admin@mail.com",
+				Action: "CREATE",
+				Entity: "items",
+			},
+			mockFn: func(args) fields {
+				db, mock := AuditLogNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "actor", "action", "entity"}).
+					AddRow(1, "SYSTEM", "UPDATE", "vouchers")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `audit_logs` WHERE id = ? AND `audit_logs`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `audit_logs` SET `actor`=?,`action`=?,`entity`=?,`entity_id`=?,`before`=?,`after`=?,`request_id`=?,`client_ip`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `audit_logs`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewAuditLogRepository(dep.db)
+
+			got, result := p.Update(tt.args.id, tt.args.auditLog)
+			got.UpdatedAt = nil
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
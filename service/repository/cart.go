@@ -7,9 +7,11 @@ import (
 	"net/http"
 	"reflect"
 	"strconv"
+	"time"
 
 	"github.com/avarian/online-shopping-cart/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CartRepository struct {
@@ -111,7 +113,7 @@ func (s *CartRepository) All(r *http.Request, preload ...string) ([]model.Cart,
 
 func (s *CartRepository) AllByAccountId(accountId int, preload ...string) ([]model.Cart, *gorm.DB) {
 	var table []model.Cart
-	tx := s.db.Where("account_id = ?", accountId)
+	tx := s.db.Where("account_id = ? AND saved_for_later = ?", accountId, false)
 	for _, v := range preload {
 		tx = tx.Preload(v)
 	}
@@ -153,6 +155,58 @@ func (s *CartRepository) OneByIdAndAccountId(id int, accountId int, preload ...s
 	return table, query
 }
 
+func (s *CartRepository) OwnerScope(accountId int, guestId string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if accountId != 0 {
+			return db.Where("account_id = ?", accountId)
+		}
+		return db.Where("guest_id = ?", guestId)
+	}
+}
+
+func (s *CartRepository) AllByOwner(accountId int, guestId string, preload ...string) ([]model.Cart, *gorm.DB) {
+	var table []model.Cart
+	tx := s.db.Scopes(s.OwnerScope(accountId, guestId))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *CartRepository) OneByIdAndOwner(id int, accountId int, guestId string, preload ...string) (model.Cart, *gorm.DB) {
+	var table model.Cart
+	tx := s.db.Scopes(s.OwnerScope(accountId, guestId)).Where("id = ?", id)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *CartRepository) OneUnscopedByOwnerAndItemId(accountId int, guestId string, itemId int, preload ...string) (model.Cart, *gorm.DB) {
+	var table model.Cart
+	tx := s.db.Unscoped().Scopes(s.OwnerScope(accountId, guestId)).Where("item_id = ?", itemId)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+// OneUnscopedByOwnerAndItemIdForUpdate locks the line until the transaction ends. Only lock lines known to exist,
+// locking a missing one takes a gap lock that deadlocks concurrent inserts of the same line
+func (s *CartRepository) OneUnscopedByOwnerAndItemIdForUpdate(accountId int, guestId string, itemId int) (model.Cart, *gorm.DB) {
+	var table model.Cart
+	query := s.db.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Scopes(s.OwnerScope(accountId, guestId)).Where("item_id = ?", itemId).Find(&table)
+
+	return table, query
+}
+
 func (s *CartRepository) Create(data model.Cart) (model.Cart, *gorm.DB) {
 	var table model.Cart
 	s.AssignData(&table, data)
@@ -193,3 +247,58 @@ func (s *CartRepository) AssignData(table *model.Cart, data model.Cart) {
 		}
 	}
 }
+
+func (s *CartRepository) Restore(id int, qty int, price float64, updatedBy string) *gorm.DB {
+	query := s.db.Unscoped().Model(&model.Cart{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"qty":             qty,
+		"price":           price,
+		"saved_for_later": false,
+		"updated_by":      updatedBy,
+		"deleted_by":      nil,
+		"deleted_at":      nil,
+	})
+	return query
+}
+
+func (s *CartRepository) MoveToAccount(id int, accountId int, qty int, updatedBy string) *gorm.DB {
+	query := s.db.Model(&model.Cart{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"account_id": accountId,
+		"guest_id":   nil,
+		"qty":        qty,
+		"updated_by": updatedBy,
+	})
+	return query
+}
+
+// AbandonedCart is an account cart whose latest line change is CartUpdatedAt
+type AbandonedCart struct {
+	AccountID     uint
+	CartUpdatedAt time.Time
+}
+
+// AllAbandoned leaves out suspended accounts, accounts waiting for their erasure are suspended too
+func (s *CartRepository) AllAbandoned(before time.Time) ([]AbandonedCart, *gorm.DB) {
+	var table []AbandonedCart
+	query := s.db.Model(&model.Cart{}).
+		Select("carts.account_id, MAX(carts.updated_at) AS cart_updated_at").
+		Joins("JOIN accounts ON accounts.id = carts.account_id AND accounts.suspended_at IS NULL AND accounts.deleted_at IS NULL").
+		Where("carts.saved_for_later = ?", false).
+		Group("carts.account_id").
+		Having("MAX(carts.updated_at) < ?", before).
+		Find(&table)
+
+	return table, query
+}
+
+func (s *CartRepository) UpdateSavedForLater(id int, saved bool, updatedBy string) *gorm.DB {
+	query := s.db.Model(&model.Cart{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"saved_for_later": saved,
+		"updated_by":      updatedBy,
+	})
+	return query
+}
+
+func (s *CartRepository) DeleteByAccountId(accountId int) *gorm.DB {
+	query := s.db.Unscoped().Where("account_id = ?", accountId).Delete(&model.Cart{})
+	return query
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"gorm.io/gorm"
+)
+
+type CartReminderRepository struct {
+	db *gorm.DB
+}
+
+func NewCartReminderRepository(db *gorm.DB) *CartReminderRepository {
+	return &CartReminderRepository{
+		db: db,
+	}
+}
+
+func (s *CartReminderRepository) FilterScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db
+	}
+}
+
+func (s *CartReminderRepository) PaginateScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		page, _ := strconv.Atoi(q.Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		pageSize, _ := strconv.Atoi(q.Get("page_size"))
+		switch {
+		case pageSize > 100:
+			pageSize = 100
+		case pageSize <= 0:
+			pageSize = 10
+		}
+
+		sortBy := q.Get("sort_by")
+		if sortBy == "" {
+			sortBy = "id"
+		}
+
+		direction := q.Get("direction")
+		if direction == "" {
+			direction = "desc"
+		}
+
+		sort := sortBy + " " + direction
+
+		offset := (page - 1) * pageSize
+		return db.Offset(offset).Limit(pageSize).Order(sort)
+	}
+}
+
+func (s *CartReminderRepository) MetaPaginate(r *http.Request) map[string]interface{} {
+	q := r.URL.Query()
+	var totalRows int64
+	s.db.Model(model.CartReminder{}).Scopes(s.FilterScope(r)).Count(&totalRows)
+
+	pageSize, _ := strconv.Atoi(q.Get("page_size"))
+	switch {
+	case pageSize > 100:
+		pageSize = 100
+	case pageSize <= 0:
+		pageSize = 10
+	}
+	totalPages := int(math.Ceil(float64(totalRows) / float64(pageSize)))
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	meta := map[string]interface{}{
+		"page":        page,
+		"page_size":   pageSize,
+		"total_rows":  totalRows,
+		"total_pages": totalPages,
+	}
+	return meta
+}
+
+func (s *CartReminderRepository) Index(r *http.Request, preload ...string) ([]model.CartReminder, *gorm.DB) {
+	var table []model.CartReminder
+	tx := s.db.Scopes(s.FilterScope(r), s.PaginateScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *CartReminderRepository) All(r *http.Request, preload ...string) ([]model.CartReminder, *gorm.DB) {
+	var table []model.CartReminder
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *CartReminderRepository) One(r *http.Request, preload ...string) (model.CartReminder, *gorm.DB) {
+	var table model.CartReminder
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *CartReminderRepository) OneById(id int, preload ...string) (model.CartReminder, *gorm.DB) {
+	var table model.CartReminder
+	tx := s.db.Where("id = ?", id)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *CartReminderRepository) Create(data model.CartReminder) (model.CartReminder, *gorm.DB) {
+	var table model.CartReminder
+	s.AssignData(&table, data)
+	query := s.db.Create(&table)
+	return table, query
+}
+
+func (s *CartReminderRepository) Update(id int, data model.CartReminder) (model.CartReminder, *gorm.DB) {
+	var table model.CartReminder
+	table, result := s.OneById(id)
+	if result.RowsAffected == 0 {
+		result.Error = errors.New(fmt.Sprintf("data not found with id = %d", id))
+		return table, result
+	}
+	s.AssignData(&table, data)
+	query := s.db.Save(&table)
+	return table, query
+}
+
+func (s *CartReminderRepository) Delete(id int, isHard bool) *gorm.DB {
+	tx := s.db
+	if isHard {
+		tx = tx.Unscoped()
+	}
+	query := tx.Delete(&model.CartReminder{}, id)
+	return query
+}
+
+func (s *CartReminderRepository) AssignData(table *model.CartReminder, data model.CartReminder) {
+	dataRV := reflect.ValueOf(data)
+	tableRV := reflect.ValueOf(table)
+	tableRVE := tableRV.Elem()
+
+	for i := 0; i < dataRV.NumField(); i++ {
+		if !dataRV.Field(i).IsZero() && (tableRVE.Field(i) != dataRV.Field(i)) {
+			fv := tableRVE.FieldByName(dataRV.Type().Field(i).Name)
+			fv.Set(dataRV.Field(i))
+		}
+	}
+}
+
+func (s *CartReminderRepository) OneLatestByAccountId(accountId int, preload ...string) (model.CartReminder, *gorm.DB) {
+	var table model.CartReminder
+	tx := s.db.Where("account_id = ?", accountId).Order("cart_updated_at desc").Limit(1)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+// ClaimSend marks the reminder sent, only the caller that changes the row sends its mail
+func (s *CartReminderRepository) ClaimSend(id int) *gorm.DB {
+	query := s.db.Model(&model.CartReminder{}).Where("id = ? AND sent_at IS NULL", id).Updates(map[string]interface{}{
+		"sent_at": time.Now(),
+	})
+	return query
+}
+
+// ReleaseSend gives up the claim of a reminder whose mail could not be queued, a later run sends it
+func (s *CartReminderRepository) ReleaseSend(id int) *gorm.DB {
+	query := s.db.Model(&model.CartReminder{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"sent_at": nil,
+	})
+	return query
+}
+
+func (s *CartReminderRepository) DeleteByAccountId(accountId int) *gorm.DB {
+	query := s.db.Unscoped().Where("account_id = ?", accountId).Delete(&model.CartReminder{})
+	return query
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func CartReminderNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_CartReminderOneLatestByAccountId(t *testing.T) {
+	t.Parallel()
+
+	db, mock := CartReminderNewMockDB()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `cart_reminders` WHERE account_id = ? AND `cart_reminders`.`deleted_at` IS NULL ORDER BY cart_updated_at desc LIMIT 1")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "voucher_id"}).AddRow(5, 1, 7))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `vouchers` WHERE `vouchers`.`id` = ?")).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "code"}).AddRow(7, "CART-ABCDE"))
+
+	got, result := NewCartReminderRepository(db).OneLatestByAccountId(1, "Voucher")
+	assert.NoError(t, result.Error)
+	assert.Equal(t, uint(5), got.ID)
+	assert.Equal(t, "CART-ABCDE", got.Voucher.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_CartReminderClaimSend(t *testing.T) {
+	tests := []struct {
+		name        string
+		affected    int64
+		wantClaimed bool
+	}{
+		{name: "Unsent reminder", affected: 1, wantClaimed: true},
+		// sent_at is already set, another run sends the mail
+		{name: "Reminder claimed by another run", affected: 0},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := CartReminderNewMockDB()
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE `cart_reminders` SET `sent_at`=?,`updated_at`=? WHERE (id = ? AND sent_at IS NULL) AND `cart_reminders`.`deleted_at` IS NULL")).
+				WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 5).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+			mock.ExpectCommit()
+
+			result := NewCartReminderRepository(db).ClaimSend(5)
+			assert.NoError(t, result.Error)
+			assert.Equal(t, tt.wantClaimed, result.RowsAffected > 0)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_CartReminderReleaseSend(t *testing.T) {
+	t.Parallel()
+
+	db, mock := CartReminderNewMockDB()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `cart_reminders` SET `sent_at`=?,`updated_at`=? WHERE id = ? AND `cart_reminders`.`deleted_at` IS NULL")).
+		WithArgs(nil, sqlmock.AnyArg(), 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	result := NewCartReminderRepository(db).ReleaseSend(5)
+	assert.NoError(t, result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
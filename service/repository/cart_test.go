@@ -5,9 +5,11 @@ import (
 	"net/url"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/avarian/online-shopping-cart/model"
+	"github.com/avarian/online-shopping-cart/util"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/mysql"
@@ -58,13 +60,13 @@ func Test_CartIndex(t *testing.T) {
 			want: []model.Cart{
 				{
 					ID:        1,
-					AccountID: 1,
+					AccountID: util.ToPointerUint(1),
 					ItemID:    1,
 					Qty:       1,
 				},
 				{
 					ID:        2,
-					AccountID: 2,
+					AccountID: util.ToPointerUint(2),
 					ItemID:    2,
 					Qty:       2,
 				},
@@ -122,13 +124,13 @@ func Test_CartAll(t *testing.T) {
 			want: []model.Cart{
 				{
 					ID:        1,
-					AccountID: 1,
+					AccountID: util.ToPointerUint(1),
 					ItemID:    1,
 					Qty:       1,
 				},
 				{
 					ID:        2,
-					AccountID: 2,
+					AccountID: util.ToPointerUint(2),
 					ItemID:    2,
 					Qty:       2,
 				},
@@ -185,7 +187,7 @@ func Test_CartOne(t *testing.T) {
 			args: args{},
 			want: model.Cart{
 				ID:        1,
-				AccountID: 1,
+				AccountID: util.ToPointerUint(1),
 				ItemID:    1,
 				Qty:       1,
 			},
@@ -242,7 +244,7 @@ func Test_CartOneById(t *testing.T) {
 			},
 			want: model.Cart{
 				ID:        1,
-				AccountID: 1,
+				AccountID: util.ToPointerUint(1),
 				ItemID:    1,
 				Qty:       1,
 			},
@@ -297,14 +299,14 @@ func Test_CartCreate(t *testing.T) {
 			args: args{
 				cart: model.Cart{
 					ID:        1,
-					AccountID: 1,
+					AccountID: util.ToPointerUint(1),
 					ItemID:    1,
 					Qty:       1,
 				},
 			},
 			want: model.Cart{
 				ID:        1,
-				AccountID: 1,
+				AccountID: util.ToPointerUint(1),
 				ItemID:    1,
 				Qty:       1,
 				CreatedBy: "SYSTEM",
@@ -314,7 +316,7 @@ func Test_CartCreate(t *testing.T) {
 				db, mock := CartNewMockDB()
 
 				mock.ExpectBegin()
-				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `carts` (`account_id`,`item_id`,`qty`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `carts` (`account_id`,`guest_id`,`item_id`,`qty`,`price`,`saved_for_later`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit()
 
 				return fields{
@@ -363,14 +365,14 @@ func Test_CartUpdate(t *testing.T) {
 				id: 1,
 				cart: model.Cart{
 					ID:        1,
-					AccountID: 1,
+					AccountID: util.ToPointerUint(1),
 					ItemID:    1,
 					Qty:       1,
 				},
 			},
 			want: model.Cart{
 				ID:        1,
-				AccountID: 1,
+				AccountID: util.ToPointerUint(1),
 				ItemID:    1,
 				Qty:       1,
 			},
@@ -382,7 +384,7 @@ func Test_CartUpdate(t *testing.T) {
 				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE id = ? AND `carts`.`deleted_at` IS NULL")).WillReturnRows(row)
 
 				mock.ExpectBegin()
-				mock.ExpectExec(regexp.QuoteMeta("UPDATE `carts` SET `account_id`=?,`item_id`=?,`qty`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `carts`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `carts` SET `account_id`=?,`guest_id`=?,`item_id`=?,`qty`=?,`price`=?,`saved_for_later`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `carts`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit()
 
 				return fields{
@@ -408,3 +410,124 @@ func Test_CartUpdate(t *testing.T) {
 		})
 	}
 }
+
+func Test_CartOneUnscopedByOwnerAndItemIdForUpdate(t *testing.T) {
+	tests := []struct {
+		name      string
+		accountId int
+		guestId   string
+		query     string
+	}{
+		{
+			name:      "Account line",
+			accountId: 1,
+			query:     "SELECT * FROM `carts` WHERE item_id = ? AND account_id = ? FOR UPDATE",
+		},
+		{
+			name:    "Guest line",
+			guestId: "guest",
+			query:   "SELECT * FROM `carts` WHERE item_id = ? AND guest_id = ? FOR UPDATE",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := CartNewMockDB()
+			row := sqlmock.NewRows([]string{"id", "item_id", "qty"}).AddRow(1, 2, 3)
+			mock.ExpectQuery(regexp.QuoteMeta(tt.query)).WillReturnRows(row)
+
+			got, result := NewCartRepository(db).OneUnscopedByOwnerAndItemIdForUpdate(tt.accountId, tt.guestId, 2)
+			assert.NoError(t, result.Error)
+			assert.Equal(t, model.Cart{ID: 1, ItemID: 2, Qty: 3}, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_CartRestore(t *testing.T) {
+	db, mock := CartNewMockDB()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `carts` SET `deleted_at`=?,`deleted_by`=?,`price`=?,`qty`=?,`saved_for_later`=?,`updated_by`=?,`updated_at`=? WHERE id = ?")).
+		WithArgs(nil, nil, 10.5, 2, false, "email@mail.com", sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	result := NewCartRepository(db).Restore(1, 2, 10.5, "email@mail.com")
+	assert.NoError(t, result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_CartMoveToAccount(t *testing.T) {
+	db, mock := CartNewMockDB()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `carts` SET `account_id`=?,`guest_id`=?,`qty`=?,`updated_by`=?,`updated_at`=? WHERE id = ? AND `carts`.`deleted_at` IS NULL")).
+		WithArgs(1, nil, 2, "email@mail.com", sqlmock.AnyArg(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	result := NewCartRepository(db).MoveToAccount(3, 1, 2, "email@mail.com")
+	assert.NoError(t, result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_CartAllAbandoned(t *testing.T) {
+	t.Parallel()
+
+	// saved for later lines do not count as activity, suspended and erased accounts are left out
+	before := time.Now().Add(-24 * time.Hour)
+	db, mock := CartNewMockDB()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT carts.account_id, MAX(carts.updated_at) AS cart_updated_at FROM `carts` JOIN accounts ON accounts.id = carts.account_id AND accounts.suspended_at IS NULL AND accounts.deleted_at IS NULL WHERE carts.saved_for_later = ? AND `carts`.`deleted_at` IS NULL GROUP BY `carts`.`account_id` HAVING MAX(carts.updated_at) < ?")).
+		WithArgs(false, before).
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "cart_updated_at"}).AddRow(1, before.Add(-time.Hour)))
+
+	got, result := NewCartRepository(db).AllAbandoned(before)
+	assert.NoError(t, result.Error)
+	assert.Equal(t, []AbandonedCart{{AccountID: 1, CartUpdatedAt: before.Add(-time.Hour)}}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_CartAllByAccountId(t *testing.T) {
+	t.Parallel()
+
+	// checkout reads the cart through it, saved for later lines are left out
+	db, mock := CartNewMockDB()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `carts` WHERE (account_id = ? AND saved_for_later = ?) AND `carts`.`deleted_at` IS NULL")).
+		WithArgs(1, false).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "item_id", "qty"}).AddRow(3, 1, 2, 1))
+
+	got, result := NewCartRepository(db).AllByAccountId(1)
+	assert.NoError(t, result.Error)
+	assert.Equal(t, []model.Cart{{ID: 3, AccountID: util.ToPointerUint(1), ItemID: 2, Qty: 1}}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_CartUpdateSavedForLater(t *testing.T) {
+	tests := []struct {
+		name  string
+		saved bool
+	}{
+		{name: "Save for later", saved: true},
+		{name: "Move back to cart", saved: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := CartNewMockDB()
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE `carts` SET `saved_for_later`=?,`updated_by`=?,`updated_at`=? WHERE id = ? AND `carts`.`deleted_at` IS NULL")).
+				WithArgs(tt.saved, "email@mail.com", sqlmock.AnyArg(), 3).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			result := NewCartRepository(db).UpdateSavedForLater(3, tt.saved, "email@mail.com")
+			assert.NoError(t, result.Error)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlDuplicateEntry is the mysql error number of an insert or update violating a unique index
+const mysqlDuplicateEntry = 1062
+
+// IsDuplicateKey reports whether err is a unique index violation, e.g. of a row inserted concurrently
+func IsDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+}
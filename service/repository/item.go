@@ -191,3 +191,11 @@ func (s *ItemRepository) AssignData(table *model.Item, data model.Item) {
 		}
 	}
 }
+
+func (s *ItemRepository) UpdateRating(id int, average float64, count int) *gorm.DB {
+	query := s.db.Model(&model.Item{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"rating_average": average,
+		"rating_count":   count,
+	})
+	return query
+}
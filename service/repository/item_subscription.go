@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"gorm.io/gorm"
+)
+
+type ItemSubscriptionRepository struct {
+	db *gorm.DB
+}
+
+func NewItemSubscriptionRepository(db *gorm.DB) *ItemSubscriptionRepository {
+	return &ItemSubscriptionRepository{
+		db: db,
+	}
+}
+
+func (s *ItemSubscriptionRepository) FilterScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db
+	}
+}
+
+func (s *ItemSubscriptionRepository) PaginateScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		page, _ := strconv.Atoi(q.Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		pageSize, _ := strconv.Atoi(q.Get("page_size"))
+		switch {
+		case pageSize > 100:
+			pageSize = 100
+		case pageSize <= 0:
+			pageSize = 10
+		}
+
+		sortBy := q.Get("sort_by")
+		if sortBy == "" {
+			sortBy = "id"
+		}
+
+		direction := q.Get("direction")
+		if direction == "" {
+			direction = "desc"
+		}
+
+		sort := sortBy + " " + direction
+
+		offset := (page - 1) * pageSize
+		return db.Offset(offset).Limit(pageSize).Order(sort)
+	}
+}
+
+func (s *ItemSubscriptionRepository) MetaPaginate(r *http.Request) map[string]interface{} {
+	q := r.URL.Query()
+	var totalRows int64
+	s.db.Model(model.ItemSubscription{}).Scopes(s.FilterScope(r)).Count(&totalRows)
+
+	pageSize, _ := strconv.Atoi(q.Get("page_size"))
+	switch {
+	case pageSize > 100:
+		pageSize = 100
+	case pageSize <= 0:
+		pageSize = 10
+	}
+	totalPages := int(math.Ceil(float64(totalRows) / float64(pageSize)))
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	meta := map[string]interface{}{
+		"page":        page,
+		"page_size":   pageSize,
+		"total_rows":  totalRows,
+		"total_pages": totalPages,
+	}
+	return meta
+}
+
+func (s *ItemSubscriptionRepository) Index(r *http.Request, preload ...string) ([]model.ItemSubscription, *gorm.DB) {
+	var table []model.ItemSubscription
+	tx := s.db.Scopes(s.FilterScope(r), s.PaginateScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *ItemSubscriptionRepository) All(r *http.Request, preload ...string) ([]model.ItemSubscription, *gorm.DB) {
+	var table []model.ItemSubscription
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *ItemSubscriptionRepository) One(r *http.Request, preload ...string) (model.ItemSubscription, *gorm.DB) {
+	var table model.ItemSubscription
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *ItemSubscriptionRepository) OneById(id int, preload ...string) (model.ItemSubscription, *gorm.DB) {
+	var table model.ItemSubscription
+	tx := s.db.Where("id = ?", id)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *ItemSubscriptionRepository) Create(data model.ItemSubscription) (model.ItemSubscription, *gorm.DB) {
+	var table model.ItemSubscription
+	s.AssignData(&table, data)
+	query := s.db.Create(&table)
+	return table, query
+}
+
+func (s *ItemSubscriptionRepository) Update(id int, data model.ItemSubscription) (model.ItemSubscription, *gorm.DB) {
+	var table model.ItemSubscription
+	table, result := s.OneById(id)
+	if result.RowsAffected == 0 {
+		result.Error = errors.New(fmt.Sprintf("data not found with id = %d", id))
+		return table, result
+	}
+	s.AssignData(&table, data)
+	query := s.db.Save(&table)
+	return table, query
+}
+
+func (s *ItemSubscriptionRepository) Delete(id int, isHard bool) *gorm.DB {
+	tx := s.db
+	if isHard {
+		tx = tx.Unscoped()
+	}
+	query := tx.Delete(&model.ItemSubscription{}, id)
+	return query
+}
+
+func (s *ItemSubscriptionRepository) AssignData(table *model.ItemSubscription, data model.ItemSubscription) {
+	dataRV := reflect.ValueOf(data)
+	tableRV := reflect.ValueOf(table)
+	tableRVE := tableRV.Elem()
+
+	for i := 0; i < dataRV.NumField(); i++ {
+		if !dataRV.Field(i).IsZero() && (tableRVE.Field(i) != dataRV.Field(i)) {
+			fv := tableRVE.FieldByName(dataRV.Type().Field(i).Name)
+			fv.Set(dataRV.Field(i))
+		}
+	}
+}
+
+func (s *ItemSubscriptionRepository) AllByAccountId(accountId int, preload ...string) ([]model.ItemSubscription, *gorm.DB) {
+	var table []model.ItemSubscription
+	tx := s.db.Where("account_id = ? AND notified_at IS NULL", accountId)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *ItemSubscriptionRepository) OneByIdAndAccountId(id int, accountId int, preload ...string) (model.ItemSubscription, *gorm.DB) {
+	var table model.ItemSubscription
+	tx := s.db.Where("id = ? AND account_id = ?", id, accountId)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *ItemSubscriptionRepository) OneActiveByAccountIdAndItemIdAndType(accountId int, itemId int, subscriptionType string) (model.ItemSubscription, *gorm.DB) {
+	var table model.ItemSubscription
+	query := s.db.Where("account_id = ? AND item_id = ? AND type = ? AND notified_at IS NULL", accountId, itemId, subscriptionType).Find(&table)
+
+	return table, query
+}
+
+func (s *ItemSubscriptionRepository) AllActiveByItemIdAndType(itemId int, subscriptionType string, preload ...string) ([]model.ItemSubscription, *gorm.DB) {
+	var table []model.ItemSubscription
+	tx := s.db.Where("item_id = ? AND type = ? AND notified_at IS NULL", itemId, subscriptionType)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *ItemSubscriptionRepository) MarkNotified(id int) *gorm.DB {
+	query := s.db.Model(&model.ItemSubscription{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"notified_at": time.Now(),
+	})
+	return query
+}
+
+func (s *ItemSubscriptionRepository) DeleteByAccountId(accountId int) *gorm.DB {
+	query := s.db.Unscoped().Where("account_id = ?", accountId).Delete(&model.ItemSubscription{})
+	return query
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func ItemSubscriptionNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_ItemSubscriptionAllByAccountId(t *testing.T) {
+	t.Parallel()
+
+	// alerted subscriptions are done, the account only lists the ones still waiting
+	db, mock := ItemSubscriptionNewMockDB()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `item_subscriptions` WHERE (account_id = ? AND notified_at IS NULL) AND `item_subscriptions`.`deleted_at` IS NULL")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "item_id", "type"}).AddRow(3, 1, 2, "BACK_IN_STOCK"))
+
+	got, result := NewItemSubscriptionRepository(db).AllByAccountId(1)
+	assert.NoError(t, result.Error)
+	assert.Equal(t, []model.ItemSubscription{{ID: 3, AccountID: 1, ItemID: 2, Type: "BACK_IN_STOCK"}}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_ItemSubscriptionOneActiveByAccountIdAndItemIdAndType(t *testing.T) {
+	tests := []struct {
+		name      string
+		rows      *sqlmock.Rows
+		wantFound bool
+	}{
+		{
+			name:      "Already subscribed",
+			rows:      sqlmock.NewRows([]string{"id", "account_id", "item_id", "type"}).AddRow(3, 1, 2, "PRICE_DROP"),
+			wantFound: true,
+		},
+		{
+			// a notified subscription does not block subscribing again
+			name: "Not subscribed or already alerted",
+			rows: sqlmock.NewRows([]string{"id"}),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := ItemSubscriptionNewMockDB()
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `item_subscriptions` WHERE (account_id = ? AND item_id = ? AND type = ? AND notified_at IS NULL) AND `item_subscriptions`.`deleted_at` IS NULL")).
+				WithArgs(1, 2, "PRICE_DROP").
+				WillReturnRows(tt.rows)
+
+			_, result := NewItemSubscriptionRepository(db).OneActiveByAccountIdAndItemIdAndType(1, 2, "PRICE_DROP")
+			assert.NoError(t, result.Error)
+			assert.Equal(t, tt.wantFound, result.RowsAffected > 0)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_ItemSubscriptionAllActiveByItemIdAndType(t *testing.T) {
+	t.Parallel()
+
+	db, mock := ItemSubscriptionNewMockDB()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `item_subscriptions` WHERE (item_id = ? AND type = ? AND notified_at IS NULL) AND `item_subscriptions`.`deleted_at` IS NULL")).
+		WithArgs(2, "BACK_IN_STOCK").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "item_id", "type"}).AddRow(3, 1, 2, "BACK_IN_STOCK"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE `accounts`.`id` = ?")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "email@mail.com"))
+
+	got, result := NewItemSubscriptionRepository(db).AllActiveByItemIdAndType(2, "BACK_IN_STOCK", "Account")
+	assert.NoError(t, result.Error)
+	assert.Len(t, got, 1)
+	assert.Equal(t, "email@mail.com", got[0].Account.Email)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func Test_ItemSubscriptionMarkNotified(t *testing.T) {
+	t.Parallel()
+
+	db, mock := ItemSubscriptionNewMockDB()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `item_subscriptions` SET `notified_at`=?,`updated_at`=? WHERE id = ? AND `item_subscriptions`.`deleted_at` IS NULL")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	result := NewItemSubscriptionRepository(db).MarkNotified(3)
+	assert.NoError(t, result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -331,7 +331,7 @@ func Test_ItemCreate(t *testing.T) {
 				db, mock := ItemNewMockDB()
 
 				mock.ExpectBegin()
-				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `items` (`name`,`description`,`price`,`qty`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `items` (`name`,`description`,`price`,`qty`,`rating_average`,`rating_count`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit()
 
 				return fields{
@@ -401,7 +401,7 @@ func Test_ItemUpdate(t *testing.T) {
 				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE id = ? AND `items`.`deleted_at` IS NULL")).WillReturnRows(row)
 
 				mock.ExpectBegin()
-				mock.ExpectExec(regexp.QuoteMeta("UPDATE `items` SET `name`=?,`description`=?,`price`=?,`qty`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `items`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `items` SET `name`=?,`description`=?,`price`=?,`qty`=?,`rating_average`=?,`rating_count`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `items`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit()
 
 				return fields{
@@ -427,3 +427,18 @@ func Test_ItemUpdate(t *testing.T) {
 		})
 	}
 }
+
+func Test_ItemUpdateRating(t *testing.T) {
+	t.Parallel()
+
+	db, mock := ItemNewMockDB()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `items` SET `rating_average`=?,`rating_count`=?,`updated_at`=? WHERE id = ? AND `items`.`deleted_at` IS NULL")).
+		WithArgs(4.5, 2, sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	result := NewItemRepository(db).UpdateRating(2, 4.5, 2)
+	assert.NoError(t, result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
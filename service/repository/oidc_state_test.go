@@ -0,0 +1,408 @@
+package repository
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func OidcStateNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_OidcStateIndex(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.OidcState
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.OidcState{
+				{
+					ID:        1,
+					Provider:  "google",
+					StateHash: "hash",
+				},
+				{
+					ID:        2,
+					Provider:  "google",
+					StateHash: "hash2",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := OidcStateNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "provider", "state_hash"}).
+					AddRow(1, "google", "hash").
+					AddRow(2, "google", "hash2")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `oidc_states` WHERE `oidc_states`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewOidcStateRepository(dep.db)
+
+			got, result := p.Index(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_OidcStateAll(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.OidcState
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.OidcState{
+				{
+					ID:        1,
+					Provider:  "google",
+					StateHash: "hash",
+				},
+				{
+					ID:        2,
+					Provider:  "google",
+					StateHash: "hash2",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := OidcStateNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "provider", "state_hash"}).
+					AddRow(1, "google", "hash").
+					AddRow(2, "google", "hash2")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `oidc_states` WHERE `oidc_states`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewOidcStateRepository(dep.db)
+
+			got, result := p.All(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_OidcStateOne(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.OidcState
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: model.OidcState{
+				ID:        1,
+				Provider:  "google",
+				StateHash: "hash",
+			},
+			mockFn: func(args) fields {
+				db, mock := OidcStateNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "provider", "state_hash"}).
+					AddRow(1, "google", "hash")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `oidc_states` WHERE `oidc_states`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewOidcStateRepository(dep.db)
+
+			got, result := p.One(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_OidcStateOneById(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id int
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.OidcState
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+			},
+			want: model.OidcState{
+				ID:        1,
+				Provider:  "google",
+				StateHash: "hash",
+			},
+			mockFn: func(args) fields {
+				db, mock := OidcStateNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "provider", "state_hash"}).
+					AddRow(1, "google", "hash")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `oidc_states` WHERE id = ? AND `oidc_states`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewOidcStateRepository(dep.db)
+
+			got, result := p.OneById(tt.args.id)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_OidcStateCreate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		oidcState model.OidcState
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.OidcState
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				oidcState: model.OidcState{
+					ID:        1,
+					Provider:  "google",
+					StateHash: "hash",
+				},
+			},
+			want: model.OidcState{
+				ID:        1,
+				Provider:  "google",
+				StateHash: "hash",
+				CreatedBy: "SYSTEM",
+				UpdatedBy: "SYSTEM",
+			},
+			mockFn: func(args) fields {
+				db, mock := OidcStateNewMockDB()
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `oidc_states` (`provider`,`state_hash`,`nonce`,`code_verifier`,`expired_at`,`used_at`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewOidcStateRepository(dep.db)
+
+			got, result := p.Create(tt.args.oidcState)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_OidcStateUpdate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id        int
+		oidcState model.OidcState
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.OidcState
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+				oidcState: model.OidcState{
+					ID:        1,
+					Provider:  "google",
+					StateHash: "hash",
+				},
+			},
+			want: model.OidcState{
+				ID:        1,
+				Provider:  "google",
+				StateHash: "hash",
+			},
+			mockFn: func(args) fields {
+				db, mock := OidcStateNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "provider", "state_hash"}).
+					AddRow(1, "google", "hash2")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `oidc_states` WHERE id = ? AND `oidc_states`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `oidc_states` SET `provider`=?,`state_hash`=?,`nonce`=?,`code_verifier`=?,`expired_at`=?,`used_at`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `oidc_states`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewOidcStateRepository(dep.db)
+
+			got, result := p.Update(tt.args.id, tt.args.oidcState)
+			got.UpdatedAt = nil
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"reflect"
 	"strconv"
+	"time"
 
 	"github.com/avarian/online-shopping-cart/model"
 	"gorm.io/gorm"
@@ -193,3 +194,20 @@ func (s *OrderRepository) AssignData(table *model.Order, data model.Order) {
 		}
 	}
 }
+
+func (s *OrderRepository) CountByAccountIdSince(accountId int, since time.Time) (int64, *gorm.DB) {
+	var count int64
+	query := s.db.Model(model.Order{}).Where("account_id = ? AND created_at >= ?", accountId, since).Count(&count)
+
+	return count, query
+}
+
+// AnonymizeByAccountId replaces the address and phone number snapshots of the account orders,
+// totals and items stay for the books
+func (s *OrderRepository) AnonymizeByAccountId(accountId int, replacement string) *gorm.DB {
+	query := s.db.Unscoped().Model(&model.Order{}).Where("account_id = ?", accountId).UpdateColumns(map[string]interface{}{
+		"address":      replacement,
+		"phone_number": replacement,
+	})
+	return query
+}
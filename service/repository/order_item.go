@@ -109,6 +109,17 @@ func (s *OrderItemRepository) All(r *http.Request, preload ...string) ([]model.O
 	return table, query
 }
 
+func (s *OrderItemRepository) AllByOrderId(orderId int, preload ...string) ([]model.OrderItem, *gorm.DB) {
+	var table []model.OrderItem
+	tx := s.db.Where("order_id = ?", orderId)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
 func (s *OrderItemRepository) One(r *http.Request, preload ...string) (model.OrderItem, *gorm.DB) {
 	var table model.OrderItem
 	tx := s.db.Scopes(s.FilterScope(r))
@@ -171,3 +182,13 @@ func (s *OrderItemRepository) AssignData(table *model.OrderItem, data model.Orde
 		}
 	}
 }
+
+func (s *OrderItemRepository) CountPurchasedByAccountIdAndItemId(accountId int, itemId int) (int64, *gorm.DB) {
+	var count int64
+	query := s.db.Model(model.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Where("orders.account_id = ? AND orders.status <> ? AND order_items.item_id = ?", accountId, "CANCELLED", itemId).
+		Count(&count)
+
+	return count, query
+}
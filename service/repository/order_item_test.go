@@ -459,3 +459,45 @@ func Test_OrderItemUpdate(t *testing.T) {
 		})
 	}
 }
+
+func Test_OrderItemCountPurchasedByAccountIdAndItemId(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+	}{
+		{name: "Bought the item", count: 2},
+		// cancelled orders are not counted, the buyer never received the item
+		{name: "Never bought the item", count: 0},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := OrderItemNewMockDB()
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `order_items` JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL WHERE (orders.account_id = ? AND orders.status <> ? AND order_items.item_id = ?)")).
+				WithArgs(1, "CANCELLED", 2).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.count))
+
+			got, result := NewOrderItemRepository(db).CountPurchasedByAccountIdAndItemId(1, 2)
+			assert.NoError(t, result.Error)
+			assert.Equal(t, int64(tt.count), got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_OrderItemAllByOrderId(t *testing.T) {
+	t.Parallel()
+
+	db, mock := OrderItemNewMockDB()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `order_items` WHERE order_id = ? AND `order_items`.`deleted_at` IS NULL")).
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "item_id", "qty"}).AddRow(1, 9, 2, 3))
+
+	got, result := NewOrderItemRepository(db).AllByOrderId(9)
+	assert.NoError(t, result.Error)
+	assert.Equal(t, []model.OrderItem{{ID: 1, OrderID: 9, ItemID: 2, Qty: 3}}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
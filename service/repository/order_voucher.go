@@ -171,3 +171,21 @@ func (s *OrderVoucherRepository) AssignData(table *model.OrderVoucher, data mode
 		}
 	}
 }
+
+func (s *OrderVoucherRepository) CountByVoucherId(voucherId int) (int64, *gorm.DB) {
+	var count int64
+	query := s.db.Model(model.OrderVoucher{}).Where("voucher_id = ?", voucherId).Count(&count)
+
+	return count, query
+}
+
+func (s *OrderVoucherRepository) AllByOrderId(orderId int, preload ...string) ([]model.OrderVoucher, *gorm.DB) {
+	var table []model.OrderVoucher
+	tx := s.db.Where("order_id = ?", orderId)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
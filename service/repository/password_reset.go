@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"gorm.io/gorm"
+)
+
+type PasswordResetRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetRepository(db *gorm.DB) *PasswordResetRepository {
+	return &PasswordResetRepository{
+		db: db,
+	}
+}
+
+func (s *PasswordResetRepository) FilterScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db
+	}
+}
+
+func (s *PasswordResetRepository) PaginateScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		page, _ := strconv.Atoi(q.Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		pageSize, _ := strconv.Atoi(q.Get("page_size"))
+		switch {
+		case pageSize > 100:
+			pageSize = 100
+		case pageSize <= 0:
+			pageSize = 10
+		}
+
+		sortBy := q.Get("sort_by")
+		if sortBy == "" {
+			sortBy = "id"
+		}
+
+		direction := q.Get("direction")
+		if direction == "" {
+			direction = "desc"
+		}
+
+		sort := sortBy + " " + direction
+
+		offset := (page - 1) * pageSize
+		return db.Offset(offset).Limit(pageSize).Order(sort)
+	}
+}
+
+func (s *PasswordResetRepository) MetaPaginate(r *http.Request) map[string]interface{} {
+	q := r.URL.Query()
+	var totalRows int64
+	s.db.Model(model.PasswordReset{}).Scopes(s.FilterScope(r)).Count(&totalRows)
+
+	pageSize, _ := strconv.Atoi(q.Get("page_size"))
+	switch {
+	case pageSize > 100:
+		pageSize = 100
+	case pageSize <= 0:
+		pageSize = 10
+	}
+	totalPages := int(math.Ceil(float64(totalRows) / float64(pageSize)))
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	meta := map[string]interface{}{
+		"page":        page,
+		"page_size":   pageSize,
+		"total_rows":  totalRows,
+		"total_pages": totalPages,
+	}
+	return meta
+}
+
+func (s *PasswordResetRepository) Index(r *http.Request, preload ...string) ([]model.PasswordReset, *gorm.DB) {
+	var table []model.PasswordReset
+	tx := s.db.Scopes(s.FilterScope(r), s.PaginateScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *PasswordResetRepository) All(r *http.Request, preload ...string) ([]model.PasswordReset, *gorm.DB) {
+	var table []model.PasswordReset
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *PasswordResetRepository) One(r *http.Request, preload ...string) (model.PasswordReset, *gorm.DB) {
+	var table model.PasswordReset
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *PasswordResetRepository) OneById(id int, preload ...string) (model.PasswordReset, *gorm.DB) {
+	var table model.PasswordReset
+	tx := s.db.Where("id = ?", id)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *PasswordResetRepository) OneByTokenHash(tokenHash string, preload ...string) (model.PasswordReset, *gorm.DB) {
+	var table model.PasswordReset
+	tx := s.db.Where("token_hash = ?", tokenHash)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *PasswordResetRepository) Create(data model.PasswordReset) (model.PasswordReset, *gorm.DB) {
+	var table model.PasswordReset
+	s.AssignData(&table, data)
+	query := s.db.Create(&table)
+	return table, query
+}
+
+func (s *PasswordResetRepository) Update(id int, data model.PasswordReset) (model.PasswordReset, *gorm.DB) {
+	var table model.PasswordReset
+	table, result := s.OneById(id)
+	if result.RowsAffected == 0 {
+		result.Error = errors.New(fmt.Sprintf("data not found with id = %d", id))
+		return table, result
+	}
+	s.AssignData(&table, data)
+	query := s.db.Save(&table)
+	return table, query
+}
+
+func (s *PasswordResetRepository) Delete(id int, isHard bool) *gorm.DB {
+	tx := s.db
+	if isHard {
+		tx = tx.Unscoped()
+	}
+	query := tx.Delete(&model.PasswordReset{}, id)
+	return query
+}
+
+func (s *PasswordResetRepository) AssignData(table *model.PasswordReset, data model.PasswordReset) {
+	dataRV := reflect.ValueOf(data)
+	tableRV := reflect.ValueOf(table)
+	tableRVE := tableRV.Elem()
+
+	for i := 0; i < dataRV.NumField(); i++ {
+		if !dataRV.Field(i).IsZero() && (tableRVE.Field(i) != dataRV.Field(i)) {
+			fv := tableRVE.FieldByName(dataRV.Type().Field(i).Name)
+			fv.Set(dataRV.Field(i))
+		}
+	}
+}
+
+// MarkUsed only affects a token not used yet, so RowsAffected 0 means it was already used
+func (s *PasswordResetRepository) MarkUsed(id int) *gorm.DB {
+	query := s.db.Model(&model.PasswordReset{}).Where("id = ? AND used_at IS NULL", id).Updates(map[string]interface{}{
+		"used_at": time.Now(),
+	})
+	return query
+}
+
+// MarkUsedByAccountId invalidates every outstanding reset token of the account
+func (s *PasswordResetRepository) MarkUsedByAccountId(accountId int) *gorm.DB {
+	query := s.db.Model(&model.PasswordReset{}).Where("account_id = ? AND used_at IS NULL", accountId).Updates(map[string]interface{}{
+		"used_at": time.Now(),
+	})
+	return query
+}
+
+func (s *PasswordResetRepository) DeleteByAccountId(accountId int) *gorm.DB {
+	query := s.db.Unscoped().Where("account_id = ?", accountId).Delete(&model.PasswordReset{})
+	return query
+}
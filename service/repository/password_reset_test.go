@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func PasswordResetNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_PasswordResetOneByTokenHash(t *testing.T) {
+	tests := []struct {
+		name      string
+		rows      *sqlmock.Rows
+		wantFound bool
+	}{
+		{
+			name:      "Known token",
+			rows:      sqlmock.NewRows([]string{"id", "account_id", "token_hash"}).AddRow(3, 1, "hash"),
+			wantFound: true,
+		},
+		{
+			// only the hash is stored, a token is looked up by hashing it
+			name: "Unknown token",
+			rows: sqlmock.NewRows([]string{"id"}),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := PasswordResetNewMockDB()
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `password_resets` WHERE token_hash = ? AND `password_resets`.`deleted_at` IS NULL")).
+				WithArgs("hash").
+				WillReturnRows(tt.rows)
+			if tt.wantFound {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `accounts` WHERE `accounts`.`id` = ?")).
+					WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "email@mail.com"))
+			}
+
+			got, result := NewPasswordResetRepository(db).OneByTokenHash("hash", "Account")
+			assert.NoError(t, result.Error)
+			assert.Equal(t, tt.wantFound, result.RowsAffected > 0)
+			if tt.wantFound {
+				assert.Equal(t, "email@mail.com", got.Account.Email)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_PasswordResetMarkUsed(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		wantUsed bool
+	}{
+		{name: "Unused token", affected: 1, wantUsed: true},
+		// a concurrent reset used the token first
+		{name: "Token already used", affected: 0},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := PasswordResetNewMockDB()
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE `password_resets` SET `used_at`=?,`updated_at`=? WHERE (id = ? AND used_at IS NULL) AND `password_resets`.`deleted_at` IS NULL")).
+				WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 3).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+			mock.ExpectCommit()
+
+			result := NewPasswordResetRepository(db).MarkUsed(3)
+			assert.NoError(t, result.Error)
+			assert.Equal(t, tt.wantUsed, result.RowsAffected > 0)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_PasswordResetMarkUsedByAccountId(t *testing.T) {
+	t.Parallel()
+
+	// the other links of the account stop working once one of them is used
+	db, mock := PasswordResetNewMockDB()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE `password_resets` SET `used_at`=?,`updated_at`=? WHERE (account_id = ? AND used_at IS NULL) AND `password_resets`.`deleted_at` IS NULL")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	result := NewPasswordResetRepository(db).MarkUsedByAccountId(1)
+	assert.NoError(t, result.Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) *RecoveryCodeRepository {
+	return &RecoveryCodeRepository{
+		db: db,
+	}
+}
+
+func (s *RecoveryCodeRepository) FilterScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db
+	}
+}
+
+func (s *RecoveryCodeRepository) PaginateScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		page, _ := strconv.Atoi(q.Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		pageSize, _ := strconv.Atoi(q.Get("page_size"))
+		switch {
+		case pageSize > 100:
+			pageSize = 100
+		case pageSize <= 0:
+			pageSize = 10
+		}
+
+		sortBy := q.Get("sort_by")
+		if sortBy == "" {
+			sortBy = "id"
+		}
+
+		direction := q.Get("direction")
+		if direction == "" {
+			direction = "desc"
+		}
+
+		sort := sortBy + " " + direction
+
+		offset := (page - 1) * pageSize
+		return db.Offset(offset).Limit(pageSize).Order(sort)
+	}
+}
+
+func (s *RecoveryCodeRepository) MetaPaginate(r *http.Request) map[string]interface{} {
+	q := r.URL.Query()
+	var totalRows int64
+	s.db.Model(model.RecoveryCode{}).Scopes(s.FilterScope(r)).Count(&totalRows)
+
+	pageSize, _ := strconv.Atoi(q.Get("page_size"))
+	switch {
+	case pageSize > 100:
+		pageSize = 100
+	case pageSize <= 0:
+		pageSize = 10
+	}
+	totalPages := int(math.Ceil(float64(totalRows) / float64(pageSize)))
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	meta := map[string]interface{}{
+		"page":        page,
+		"page_size":   pageSize,
+		"total_rows":  totalRows,
+		"total_pages": totalPages,
+	}
+	return meta
+}
+
+func (s *RecoveryCodeRepository) Index(r *http.Request, preload ...string) ([]model.RecoveryCode, *gorm.DB) {
+	var table []model.RecoveryCode
+	tx := s.db.Scopes(s.FilterScope(r), s.PaginateScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *RecoveryCodeRepository) All(r *http.Request, preload ...string) ([]model.RecoveryCode, *gorm.DB) {
+	var table []model.RecoveryCode
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *RecoveryCodeRepository) One(r *http.Request, preload ...string) (model.RecoveryCode, *gorm.DB) {
+	var table model.RecoveryCode
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *RecoveryCodeRepository) OneById(id int, preload ...string) (model.RecoveryCode, *gorm.DB) {
+	var table model.RecoveryCode
+	tx := s.db.Where("id = ?", id)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *RecoveryCodeRepository) Create(data model.RecoveryCode) (model.RecoveryCode, *gorm.DB) {
+	var table model.RecoveryCode
+	s.AssignData(&table, data)
+	query := s.db.Create(&table)
+	return table, query
+}
+
+func (s *RecoveryCodeRepository) Update(id int, data model.RecoveryCode) (model.RecoveryCode, *gorm.DB) {
+	var table model.RecoveryCode
+	table, result := s.OneById(id)
+	if result.RowsAffected == 0 {
+		result.Error = errors.New(fmt.Sprintf("data not found with id = %d", id))
+		return table, result
+	}
+	s.AssignData(&table, data)
+	query := s.db.Save(&table)
+	return table, query
+}
+
+func (s *RecoveryCodeRepository) Delete(id int, isHard bool) *gorm.DB {
+	tx := s.db
+	if isHard {
+		tx = tx.Unscoped()
+	}
+	query := tx.Delete(&model.RecoveryCode{}, id)
+	return query
+}
+
+func (s *RecoveryCodeRepository) AssignData(table *model.RecoveryCode, data model.RecoveryCode) {
+	dataRV := reflect.ValueOf(data)
+	tableRV := reflect.ValueOf(table)
+	tableRVE := tableRV.Elem()
+
+	for i := 0; i < dataRV.NumField(); i++ {
+		if !dataRV.Field(i).IsZero() && (tableRVE.Field(i) != dataRV.Field(i)) {
+			fv := tableRVE.FieldByName(dataRV.Type().Field(i).Name)
+			fv.Set(dataRV.Field(i))
+		}
+	}
+}
+
+func (s *RecoveryCodeRepository) OneActiveByAccountIdAndCodeHash(accountId int, codeHash string) (model.RecoveryCode, *gorm.DB) {
+	var table model.RecoveryCode
+	query := s.db.Where("account_id = ? AND code_hash = ? AND used_at IS NULL", accountId, codeHash).Find(&table)
+
+	return table, query
+}
+
+func (s *RecoveryCodeRepository) CountActiveByAccountId(accountId int) (int64, *gorm.DB) {
+	var count int64
+	query := s.db.Model(&model.RecoveryCode{}).Where("account_id = ? AND used_at IS NULL", accountId).Count(&count)
+
+	return count, query
+}
+
+// MarkUsed only affects a code not used yet, so RowsAffected 0 means it was already used
+func (s *RecoveryCodeRepository) MarkUsed(id int) *gorm.DB {
+	query := s.db.Model(&model.RecoveryCode{}).Where("id = ? AND used_at IS NULL", id).Updates(map[string]interface{}{
+		"used_at": time.Now(),
+	})
+	return query
+}
+
+func (s *RecoveryCodeRepository) DeleteByAccountId(accountId int) *gorm.DB {
+	query := s.db.Unscoped().Where("account_id = ?", accountId).Delete(&model.RecoveryCode{})
+	return query
+}
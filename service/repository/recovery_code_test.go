@@ -0,0 +1,408 @@
+package repository
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func RecoveryCodeNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_RecoveryCodeIndex(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.RecoveryCode
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.RecoveryCode{
+				{
+					ID:        1,
+					AccountID: 1,
+					CodeHash:  "hash",
+				},
+				{
+					ID:        2,
+					AccountID: 2,
+					CodeHash:  "hash2",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := RecoveryCodeNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "code_hash"}).
+					AddRow(1, 1, "hash").
+					AddRow(2, 2, "hash2")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `recovery_codes` WHERE `recovery_codes`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRecoveryCodeRepository(dep.db)
+
+			got, result := p.Index(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RecoveryCodeAll(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.RecoveryCode
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.RecoveryCode{
+				{
+					ID:        1,
+					AccountID: 1,
+					CodeHash:  "hash",
+				},
+				{
+					ID:        2,
+					AccountID: 2,
+					CodeHash:  "hash2",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := RecoveryCodeNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "code_hash"}).
+					AddRow(1, 1, "hash").
+					AddRow(2, 2, "hash2")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `recovery_codes` WHERE `recovery_codes`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRecoveryCodeRepository(dep.db)
+
+			got, result := p.All(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RecoveryCodeOne(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.RecoveryCode
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: model.RecoveryCode{
+				ID:        1,
+				AccountID: 1,
+				CodeHash:  "hash",
+			},
+			mockFn: func(args) fields {
+				db, mock := RecoveryCodeNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "code_hash"}).
+					AddRow(1, 1, "hash")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `recovery_codes` WHERE `recovery_codes`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRecoveryCodeRepository(dep.db)
+
+			got, result := p.One(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RecoveryCodeOneById(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id int
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.RecoveryCode
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+			},
+			want: model.RecoveryCode{
+				ID:        1,
+				AccountID: 1,
+				CodeHash:  "hash",
+			},
+			mockFn: func(args) fields {
+				db, mock := RecoveryCodeNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "code_hash"}).
+					AddRow(1, 1, "hash")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `recovery_codes` WHERE id = ? AND `recovery_codes`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRecoveryCodeRepository(dep.db)
+
+			got, result := p.OneById(tt.args.id)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RecoveryCodeCreate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		recoveryCode model.RecoveryCode
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.RecoveryCode
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				recoveryCode: model.RecoveryCode{
+					ID:        1,
+					AccountID: 1,
+					CodeHash:  "hash",
+				},
+			},
+			want: model.RecoveryCode{
+				ID:        1,
+				AccountID: 1,
+				CodeHash:  "hash",
+				CreatedBy: "SYSTEM",
+				UpdatedBy: "SYSTEM",
+			},
+			mockFn: func(args) fields {
+				db, mock := RecoveryCodeNewMockDB()
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `recovery_codes` (`account_id`,`code_hash`,`used_at`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRecoveryCodeRepository(dep.db)
+
+			got, result := p.Create(tt.args.recoveryCode)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RecoveryCodeUpdate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id           int
+		recoveryCode model.RecoveryCode
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.RecoveryCode
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+				recoveryCode: model.RecoveryCode{
+					ID:        1,
+					AccountID: 1,
+					CodeHash:  "hash",
+				},
+			},
+			want: model.RecoveryCode{
+				ID:        1,
+				AccountID: 1,
+				CodeHash:  "hash",
+			},
+			mockFn: func(args) fields {
+				db, mock := RecoveryCodeNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "code_hash"}).
+					AddRow(1, 2, "hash2")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `recovery_codes` WHERE id = ? AND `recovery_codes`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `recovery_codes` SET `account_id`=?,`code_hash`=?,`used_at`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `recovery_codes`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRecoveryCodeRepository(dep.db)
+
+			got, result := p.Update(tt.args.id, tt.args.recoveryCode)
+			got.UpdatedAt = nil
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,418 @@
+package repository
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func RefreshTokenNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_RefreshTokenIndex(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.RefreshToken
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.RefreshToken{
+				{
+					ID:        1,
+					AccountID: 1,
+					FamilyID:  "family",
+					TokenHash: "hash",
+				},
+				{
+					ID:        2,
+					AccountID: 2,
+					FamilyID:  "family2",
+					TokenHash: "hash2",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := RefreshTokenNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "family_id", "token_hash"}).
+					AddRow(1, 1, "family", "hash").
+					AddRow(2, 2, "family2", "hash2")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `refresh_tokens` WHERE `refresh_tokens`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRefreshTokenRepository(dep.db)
+
+			got, result := p.Index(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RefreshTokenAll(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.RefreshToken
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.RefreshToken{
+				{
+					ID:        1,
+					AccountID: 1,
+					FamilyID:  "family",
+					TokenHash: "hash",
+				},
+				{
+					ID:        2,
+					AccountID: 2,
+					FamilyID:  "family2",
+					TokenHash: "hash2",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := RefreshTokenNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "family_id", "token_hash"}).
+					AddRow(1, 1, "family", "hash").
+					AddRow(2, 2, "family2", "hash2")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `refresh_tokens` WHERE `refresh_tokens`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRefreshTokenRepository(dep.db)
+
+			got, result := p.All(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RefreshTokenOne(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.RefreshToken
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: model.RefreshToken{
+				ID:        1,
+				AccountID: 1,
+				FamilyID:  "family",
+				TokenHash: "hash",
+			},
+			mockFn: func(args) fields {
+				db, mock := RefreshTokenNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "family_id", "token_hash"}).
+					AddRow(1, 1, "family", "hash")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `refresh_tokens` WHERE `refresh_tokens`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRefreshTokenRepository(dep.db)
+
+			got, result := p.One(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RefreshTokenOneById(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id int
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.RefreshToken
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+			},
+			want: model.RefreshToken{
+				ID:        1,
+				AccountID: 1,
+				FamilyID:  "family",
+				TokenHash: "hash",
+			},
+			mockFn: func(args) fields {
+				db, mock := RefreshTokenNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "family_id", "token_hash"}).
+					AddRow(1, 1, "family", "hash")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `refresh_tokens` WHERE id = ? AND `refresh_tokens`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRefreshTokenRepository(dep.db)
+
+			got, result := p.OneById(tt.args.id)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RefreshTokenCreate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		refreshToken model.RefreshToken
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.RefreshToken
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				refreshToken: model.RefreshToken{
+					ID:        1,
+					AccountID: 1,
+					FamilyID:  "family",
+					TokenHash: "hash",
+				},
+			},
+			want: model.RefreshToken{
+				ID:        1,
+				AccountID: 1,
+				FamilyID:  "family",
+				TokenHash: "hash",
+				CreatedBy: "SYSTEM",
+				UpdatedBy: "SYSTEM",
+			},
+			mockFn: func(args) fields {
+				db, mock := RefreshTokenNewMockDB()
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `refresh_tokens` (`account_id`,`family_id`,`token_hash`,`expired_at`,`used_at`,`revoked_at`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRefreshTokenRepository(dep.db)
+
+			got, result := p.Create(tt.args.refreshToken)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RefreshTokenUpdate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id           int
+		refreshToken model.RefreshToken
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.RefreshToken
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+				refreshToken: model.RefreshToken{
+					ID:        1,
+					AccountID: 1,
+					FamilyID:  "family",
+					TokenHash: "hash",
+				},
+			},
+			want: model.RefreshToken{
+				ID:        1,
+				AccountID: 1,
+				FamilyID:  "family",
+				TokenHash: "hash",
+			},
+			mockFn: func(args) fields {
+				db, mock := RefreshTokenNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "family_id", "token_hash"}).
+					AddRow(1, 2, "family2", "hash2")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `refresh_tokens` WHERE id = ? AND `refresh_tokens`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `refresh_tokens` SET `account_id`=?,`family_id`=?,`token_hash`=?,`expired_at`=?,`used_at`=?,`revoked_at`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `refresh_tokens`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRefreshTokenRepository(dep.db)
+
+			got, result := p.Update(tt.args.id, tt.args.refreshToken)
+			got.UpdatedAt = nil
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"gorm.io/gorm"
+)
+
+type ReviewRepository struct {
+	db *gorm.DB
+}
+
+func NewReviewRepository(db *gorm.DB) *ReviewRepository {
+	return &ReviewRepository{
+		db: db,
+	}
+}
+
+func (s *ReviewRepository) FilterScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		itemId := q.Get("item_id")
+		if itemId != "" {
+			db = db.Where("item_id = ?", itemId)
+		}
+		status := q.Get("status")
+		if status != "" {
+			db = db.Where("status = ?", status)
+		}
+		rating := q.Get("rating")
+		if rating != "" {
+			db = db.Where("rating = ?", rating)
+		}
+		return db
+	}
+}
+
+func (s *ReviewRepository) PaginateScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		page, _ := strconv.Atoi(q.Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		pageSize, _ := strconv.Atoi(q.Get("page_size"))
+		switch {
+		case pageSize > 100:
+			pageSize = 100
+		case pageSize <= 0:
+			pageSize = 10
+		}
+
+		sortBy := q.Get("sort_by")
+		switch sortBy {
+		case "rating", "created_at":
+		default:
+			sortBy = "id"
+		}
+
+		direction := q.Get("direction")
+		if direction != "asc" {
+			direction = "desc"
+		}
+
+		sort := sortBy + " " + direction
+
+		offset := (page - 1) * pageSize
+		return db.Offset(offset).Limit(pageSize).Order(sort)
+	}
+}
+
+func (s *ReviewRepository) MetaPaginate(r *http.Request) map[string]interface{} {
+	q := r.URL.Query()
+	var totalRows int64
+	s.db.Model(model.Review{}).Scopes(s.FilterScope(r)).Count(&totalRows)
+
+	pageSize, _ := strconv.Atoi(q.Get("page_size"))
+	switch {
+	case pageSize > 100:
+		pageSize = 100
+	case pageSize <= 0:
+		pageSize = 10
+	}
+	totalPages := int(math.Ceil(float64(totalRows) / float64(pageSize)))
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	meta := map[string]interface{}{
+		"page":        page,
+		"page_size":   pageSize,
+		"total_rows":  totalRows,
+		"total_pages": totalPages,
+	}
+	return meta
+}
+
+func (s *ReviewRepository) Index(r *http.Request, preload ...string) ([]model.Review, *gorm.DB) {
+	var table []model.Review
+	tx := s.db.Scopes(s.FilterScope(r), s.PaginateScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *ReviewRepository) All(r *http.Request, preload ...string) ([]model.Review, *gorm.DB) {
+	var table []model.Review
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *ReviewRepository) One(r *http.Request, preload ...string) (model.Review, *gorm.DB) {
+	var table model.Review
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *ReviewRepository) OneById(id int, preload ...string) (model.Review, *gorm.DB) {
+	var table model.Review
+	tx := s.db.Where("id = ?", id)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *ReviewRepository) Create(data model.Review) (model.Review, *gorm.DB) {
+	var table model.Review
+	s.AssignData(&table, data)
+	query := s.db.Create(&table)
+	return table, query
+}
+
+func (s *ReviewRepository) Update(id int, data model.Review) (model.Review, *gorm.DB) {
+	var table model.Review
+	table, result := s.OneById(id)
+	if result.RowsAffected == 0 {
+		result.Error = errors.New(fmt.Sprintf("data not found with id = %d", id))
+		return table, result
+	}
+	s.AssignData(&table, data)
+	query := s.db.Save(&table)
+	return table, query
+}
+
+func (s *ReviewRepository) Delete(id int, isHard bool) *gorm.DB {
+	tx := s.db
+	if isHard {
+		tx = tx.Unscoped()
+	}
+	query := tx.Delete(&model.Review{}, id)
+	return query
+}
+
+func (s *ReviewRepository) AssignData(table *model.Review, data model.Review) {
+	dataRV := reflect.ValueOf(data)
+	tableRV := reflect.ValueOf(table)
+	tableRVE := tableRV.Elem()
+
+	for i := 0; i < dataRV.NumField(); i++ {
+		if !dataRV.Field(i).IsZero() && (tableRVE.Field(i) != dataRV.Field(i)) {
+			fv := tableRVE.FieldByName(dataRV.Type().Field(i).Name)
+			fv.Set(dataRV.Field(i))
+		}
+	}
+}
+
+func (s *ReviewRepository) OneByItemIdAndAccountId(itemId int, accountId int, preload ...string) (model.Review, *gorm.DB) {
+	var table model.Review
+	tx := s.db.Where("item_id = ? AND account_id = ?", itemId, accountId)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *ReviewRepository) SummaryByItemId(itemId int) (float64, int, *gorm.DB) {
+	var summary struct {
+		Average float64
+		Count   int
+	}
+	query := s.db.Model(model.Review{}).
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").
+		Where("item_id = ? AND status = ?", itemId, "APPROVED").
+		Scan(&summary)
+
+	return summary.Average, summary.Count, query
+}
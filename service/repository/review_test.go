@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func ReviewNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_ReviewIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawQuery string
+		query    string
+		args     []driver.Value
+	}{
+		{
+			name:     "Approved reviews of an item",
+			rawQuery: "item_id=2&status=APPROVED",
+			query:    "SELECT * FROM `reviews` WHERE item_id = ? AND status = ? AND `reviews`.`deleted_at` IS NULL ORDER BY id desc LIMIT 10",
+			args:     []driver.Value{"2", "APPROVED"},
+		},
+		{
+			name:     "Best rated first",
+			rawQuery: "item_id=2&status=APPROVED&sort_by=rating",
+			query:    "SELECT * FROM `reviews` WHERE item_id = ? AND status = ? AND `reviews`.`deleted_at` IS NULL ORDER BY rating desc LIMIT 10",
+			args:     []driver.Value{"2", "APPROVED"},
+		},
+		{
+			// only rating and created_at are sortable, anything else falls back to the id
+			name:     "Unknown sort column",
+			rawQuery: "sort_by=account_id&direction=asc",
+			query:    "SELECT * FROM `reviews` WHERE `reviews`.`deleted_at` IS NULL ORDER BY id asc LIMIT 10",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := ReviewNewMockDB()
+			mock.ExpectQuery(regexp.QuoteMeta(tt.query)).
+				WithArgs(tt.args...).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "item_id", "account_id", "rating", "status"}).AddRow(3, 2, 1, 5, "APPROVED"))
+
+			got, result := NewReviewRepository(db).Index(&http.Request{URL: &url.URL{RawQuery: tt.rawQuery}})
+			assert.NoError(t, result.Error)
+			assert.Equal(t, []model.Review{{ID: 3, ItemID: 2, AccountID: 1, Rating: 5, Status: "APPROVED"}}, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_ReviewOneByItemIdAndAccountId(t *testing.T) {
+	tests := []struct {
+		name      string
+		rows      *sqlmock.Rows
+		wantFound bool
+	}{
+		{
+			name:      "Item reviewed by the account",
+			rows:      sqlmock.NewRows([]string{"id", "item_id", "account_id"}).AddRow(3, 2, 1),
+			wantFound: true,
+		},
+		{
+			name: "Item not reviewed yet",
+			rows: sqlmock.NewRows([]string{"id"}),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := ReviewNewMockDB()
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `reviews` WHERE (item_id = ? AND account_id = ?) AND `reviews`.`deleted_at` IS NULL")).
+				WithArgs(2, 1).
+				WillReturnRows(tt.rows)
+
+			_, result := NewReviewRepository(db).OneByItemIdAndAccountId(2, 1)
+			assert.NoError(t, result.Error)
+			assert.Equal(t, tt.wantFound, result.RowsAffected > 0)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_ReviewSummaryByItemId(t *testing.T) {
+	tests := []struct {
+		name        string
+		rows        *sqlmock.Rows
+		wantAverage float64
+		wantCount   int
+	}{
+		{
+			name:        "Approved reviews",
+			rows:        sqlmock.NewRows([]string{"average", "count"}).AddRow(4.5, 2),
+			wantAverage: 4.5,
+			wantCount:   2,
+		},
+		{
+			// the last approved review was hidden, the rating goes back to zero
+			name: "No approved review",
+			rows: sqlmock.NewRows([]string{"average", "count"}).AddRow(0, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := ReviewNewMockDB()
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count FROM `reviews` WHERE (item_id = ? AND status = ?) AND `reviews`.`deleted_at` IS NULL")).
+				WithArgs(2, "APPROVED").
+				WillReturnRows(tt.rows)
+
+			average, count, result := NewReviewRepository(db).SummaryByItemId(2)
+			assert.NoError(t, result.Error)
+			assert.Equal(t, tt.wantAverage, average)
+			assert.Equal(t, tt.wantCount, count)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
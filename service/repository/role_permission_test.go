@@ -0,0 +1,408 @@
+package repository
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func RolePermissionNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_RolePermissionIndex(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.RolePermission
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.RolePermission{
+				{
+					ID:         1,
+					RoleID:     1,
+					Permission: "item:write",
+				},
+				{
+					ID:         2,
+					RoleID:     2,
+					Permission: "voucher:write",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := RolePermissionNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "role_id", "permission"}).
+					AddRow(1, 1, "item:write").
+					AddRow(2, 2, "voucher:write")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `role_permissions` WHERE `role_permissions`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRolePermissionRepository(dep.db)
+
+			got, result := p.Index(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RolePermissionAll(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.RolePermission
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.RolePermission{
+				{
+					ID:         1,
+					RoleID:     1,
+					Permission: "item:write",
+				},
+				{
+					ID:         2,
+					RoleID:     2,
+					Permission: "voucher:write",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := RolePermissionNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "role_id", "permission"}).
+					AddRow(1, 1, "item:write").
+					AddRow(2, 2, "voucher:write")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `role_permissions` WHERE `role_permissions`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRolePermissionRepository(dep.db)
+
+			got, result := p.All(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RolePermissionOne(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.RolePermission
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: model.RolePermission{
+				ID:         1,
+				RoleID:     1,
+				Permission: "item:write",
+			},
+			mockFn: func(args) fields {
+				db, mock := RolePermissionNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "role_id", "permission"}).
+					AddRow(1, 1, "item:write")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `role_permissions` WHERE `role_permissions`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRolePermissionRepository(dep.db)
+
+			got, result := p.One(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RolePermissionOneById(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id int
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.RolePermission
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+			},
+			want: model.RolePermission{
+				ID:         1,
+				RoleID:     1,
+				Permission: "item:write",
+			},
+			mockFn: func(args) fields {
+				db, mock := RolePermissionNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "role_id", "permission"}).
+					AddRow(1, 1, "item:write")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `role_permissions` WHERE id = ? AND `role_permissions`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRolePermissionRepository(dep.db)
+
+			got, result := p.OneById(tt.args.id)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RolePermissionCreate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		rolePermission model.RolePermission
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.RolePermission
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				rolePermission: model.RolePermission{
+					ID:         1,
+					RoleID:     1,
+					Permission: "item:write",
+				},
+			},
+			want: model.RolePermission{
+				ID:         1,
+				RoleID:     1,
+				Permission: "item:write",
+				CreatedBy:  "SYSTEM",
+				UpdatedBy:  "SYSTEM",
+			},
+			mockFn: func(args) fields {
+				db, mock := RolePermissionNewMockDB()
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `role_permissions` (`role_id`,`permission`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRolePermissionRepository(dep.db)
+
+			got, result := p.Create(tt.args.rolePermission)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RolePermissionUpdate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id             int
+		rolePermission model.RolePermission
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.RolePermission
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+				rolePermission: model.RolePermission{
+					ID:         1,
+					RoleID:     1,
+					Permission: "item:write",
+				},
+			},
+			want: model.RolePermission{
+				ID:         1,
+				RoleID:     1,
+				Permission: "item:write",
+			},
+			mockFn: func(args) fields {
+				db, mock := RolePermissionNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "role_id", "permission"}).
+					AddRow(1, 2, "voucher:write")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `role_permissions` WHERE id = ? AND `role_permissions`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `role_permissions` SET `role_id`=?,`permission`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `role_permissions`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRolePermissionRepository(dep.db)
+
+			got, result := p.Update(tt.args.id, tt.args.rolePermission)
+			got.UpdatedAt = nil
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,408 @@
+package repository
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func RoleNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_RoleIndex(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.Role
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.Role{
+				{
+					ID:          1,
+					Name:        "name",
+					Description: "desc",
+				},
+				{
+					ID:          2,
+					Name:        "name2",
+					Description: "desc2",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := RoleNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "name", "description"}).
+					AddRow(1, "name", "desc").
+					AddRow(2, "name2", "desc2")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `roles` WHERE `roles`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRoleRepository(dep.db)
+
+			got, result := p.Index(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RoleAll(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.Role
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.Role{
+				{
+					ID:          1,
+					Name:        "name",
+					Description: "desc",
+				},
+				{
+					ID:          2,
+					Name:        "name2",
+					Description: "desc2",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := RoleNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "name", "description"}).
+					AddRow(1, "name", "desc").
+					AddRow(2, "name2", "desc2")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `roles` WHERE `roles`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRoleRepository(dep.db)
+
+			got, result := p.All(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RoleOne(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.Role
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: model.Role{
+				ID:          1,
+				Name:        "name",
+				Description: "desc",
+			},
+			mockFn: func(args) fields {
+				db, mock := RoleNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "name", "description"}).
+					AddRow(1, "name", "desc")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `roles` WHERE `roles`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRoleRepository(dep.db)
+
+			got, result := p.One(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RoleOneById(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id int
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.Role
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+			},
+			want: model.Role{
+				ID:          1,
+				Name:        "name",
+				Description: "desc",
+			},
+			mockFn: func(args) fields {
+				db, mock := RoleNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "name", "description"}).
+					AddRow(1, "name", "desc")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `roles` WHERE id = ? AND `roles`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRoleRepository(dep.db)
+
+			got, result := p.OneById(tt.args.id)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RoleCreate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		role model.Role
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.Role
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				role: model.Role{
+					ID:          1,
+					Name:        "name",
+					Description: "desc",
+				},
+			},
+			want: model.Role{
+				ID:          1,
+				Name:        "name",
+				Description: "desc",
+				CreatedBy:   "SYSTEM",
+				UpdatedBy:   "SYSTEM",
+			},
+			mockFn: func(args) fields {
+				db, mock := RoleNewMockDB()
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `roles` (`name`,`description`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRoleRepository(dep.db)
+
+			got, result := p.Create(tt.args.role)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_RoleUpdate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id   int
+		role model.Role
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.Role
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+				role: model.Role{
+					ID:          1,
+					Name:        "name",
+					Description: "desc",
+				},
+			},
+			want: model.Role{
+				ID:          1,
+				Name:        "name",
+				Description: "desc",
+			},
+			mockFn: func(args) fields {
+				db, mock := RoleNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "name", "description"}).
+					AddRow(1, "name2", "desc2")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `roles` WHERE id = ? AND `roles`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `roles` SET `name`=?,`description`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `roles`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewRoleRepository(dep.db)
+
+			got, result := p.Update(tt.args.id, tt.args.role)
+			got.UpdatedAt = nil
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"gorm.io/gorm"
+)
+
+type SigningKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepository(db *gorm.DB) *SigningKeyRepository {
+	return &SigningKeyRepository{
+		db: db,
+	}
+}
+
+func (s *SigningKeyRepository) FilterScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db
+	}
+}
+
+func (s *SigningKeyRepository) PaginateScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		page, _ := strconv.Atoi(q.Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		pageSize, _ := strconv.Atoi(q.Get("page_size"))
+		switch {
+		case pageSize > 100:
+			pageSize = 100
+		case pageSize <= 0:
+			pageSize = 10
+		}
+
+		sortBy := q.Get("sort_by")
+		if sortBy == "" {
+			sortBy = "id"
+		}
+
+		direction := q.Get("direction")
+		if direction == "" {
+			direction = "desc"
+		}
+
+		sort := sortBy + " " + direction
+
+		offset := (page - 1) * pageSize
+		return db.Offset(offset).Limit(pageSize).Order(sort)
+	}
+}
+
+func (s *SigningKeyRepository) MetaPaginate(r *http.Request) map[string]interface{} {
+	q := r.URL.Query()
+	var totalRows int64
+	s.db.Model(model.SigningKey{}).Scopes(s.FilterScope(r)).Count(&totalRows)
+
+	pageSize, _ := strconv.Atoi(q.Get("page_size"))
+	switch {
+	case pageSize > 100:
+		pageSize = 100
+	case pageSize <= 0:
+		pageSize = 10
+	}
+	totalPages := int(math.Ceil(float64(totalRows) / float64(pageSize)))
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	meta := map[string]interface{}{
+		"page":        page,
+		"page_size":   pageSize,
+		"total_rows":  totalRows,
+		"total_pages": totalPages,
+	}
+	return meta
+}
+
+func (s *SigningKeyRepository) Index(r *http.Request, preload ...string) ([]model.SigningKey, *gorm.DB) {
+	var table []model.SigningKey
+	tx := s.db.Scopes(s.FilterScope(r), s.PaginateScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *SigningKeyRepository) All(r *http.Request, preload ...string) ([]model.SigningKey, *gorm.DB) {
+	var table []model.SigningKey
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *SigningKeyRepository) One(r *http.Request, preload ...string) (model.SigningKey, *gorm.DB) {
+	var table model.SigningKey
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *SigningKeyRepository) OneById(id int, preload ...string) (model.SigningKey, *gorm.DB) {
+	var table model.SigningKey
+	tx := s.db.Where("id = ?", id)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+// AllActive returns keys not retired yet, newest first
+func (s *SigningKeyRepository) AllActive() ([]model.SigningKey, *gorm.DB) {
+	var table []model.SigningKey
+	query := s.db.Where("status = ?", "ACTIVE").Order("id desc").Find(&table)
+
+	return table, query
+}
+
+func (s *SigningKeyRepository) OneByKid(kid string) (model.SigningKey, *gorm.DB) {
+	var table model.SigningKey
+	query := s.db.Where("kid = ?", kid).Find(&table)
+
+	return table, query
+}
+
+func (s *SigningKeyRepository) Create(data model.SigningKey) (model.SigningKey, *gorm.DB) {
+	var table model.SigningKey
+	s.AssignData(&table, data)
+	query := s.db.Create(&table)
+	return table, query
+}
+
+func (s *SigningKeyRepository) Update(id int, data model.SigningKey) (model.SigningKey, *gorm.DB) {
+	var table model.SigningKey
+	table, result := s.OneById(id)
+	if result.RowsAffected == 0 {
+		result.Error = errors.New(fmt.Sprintf("data not found with id = %d", id))
+		return table, result
+	}
+	s.AssignData(&table, data)
+	query := s.db.Save(&table)
+	return table, query
+}
+
+func (s *SigningKeyRepository) Delete(id int, isHard bool) *gorm.DB {
+	tx := s.db
+	if isHard {
+		tx = tx.Unscoped()
+	}
+	query := tx.Delete(&model.SigningKey{}, id)
+	return query
+}
+
+func (s *SigningKeyRepository) AssignData(table *model.SigningKey, data model.SigningKey) {
+	dataRV := reflect.ValueOf(data)
+	tableRV := reflect.ValueOf(table)
+	tableRVE := tableRV.Elem()
+
+	for i := 0; i < dataRV.NumField(); i++ {
+		if !dataRV.Field(i).IsZero() && (tableRVE.Field(i) != dataRV.Field(i)) {
+			fv := tableRVE.FieldByName(dataRV.Type().Field(i).Name)
+			fv.Set(dataRV.Field(i))
+		}
+	}
+}
+
+func (s *SigningKeyRepository) Retire(id int, updatedBy string) *gorm.DB {
+	query := s.db.Model(&model.SigningKey{}).Where("id = ? AND status = ?", id, "ACTIVE").Updates(map[string]interface{}{
+		"status":     "RETIRED",
+		"retired_at": time.Now(),
+		"updated_by": updatedBy,
+	})
+	return query
+}
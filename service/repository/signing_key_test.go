@@ -0,0 +1,418 @@
+package repository
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func SigningKeyNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_SigningKeyIndex(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.SigningKey
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.SigningKey{
+				{
+					ID:        1,
+					Kid:       "kid",
+					Algorithm: "RS256",
+					Status:    "ACTIVE",
+				},
+				{
+					ID:        2,
+					Kid:       "kid2",
+					Algorithm: "EdDSA",
+					Status:    "RETIRED",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := SigningKeyNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "kid", "algorithm", "status"}).
+					AddRow(1, "kid", "RS256", "ACTIVE").
+					AddRow(2, "kid2", "EdDSA", "RETIRED")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `signing_keys` WHERE `signing_keys`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewSigningKeyRepository(dep.db)
+
+			got, result := p.Index(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_SigningKeyAll(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.SigningKey
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.SigningKey{
+				{
+					ID:        1,
+					Kid:       "kid",
+					Algorithm: "RS256",
+					Status:    "ACTIVE",
+				},
+				{
+					ID:        2,
+					Kid:       "kid2",
+					Algorithm: "EdDSA",
+					Status:    "RETIRED",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := SigningKeyNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "kid", "algorithm", "status"}).
+					AddRow(1, "kid", "RS256", "ACTIVE").
+					AddRow(2, "kid2", "EdDSA", "RETIRED")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `signing_keys` WHERE `signing_keys`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewSigningKeyRepository(dep.db)
+
+			got, result := p.All(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_SigningKeyOne(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.SigningKey
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: model.SigningKey{
+				ID:        1,
+				Kid:       "kid",
+				Algorithm: "RS256",
+				Status:    "ACTIVE",
+			},
+			mockFn: func(args) fields {
+				db, mock := SigningKeyNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "kid", "algorithm", "status"}).
+					AddRow(1, "kid", "RS256", "ACTIVE")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `signing_keys` WHERE `signing_keys`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewSigningKeyRepository(dep.db)
+
+			got, result := p.One(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_SigningKeyOneById(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id int
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.SigningKey
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+			},
+			want: model.SigningKey{
+				ID:        1,
+				Kid:       "kid",
+				Algorithm: "RS256",
+				Status:    "ACTIVE",
+			},
+			mockFn: func(args) fields {
+				db, mock := SigningKeyNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "kid", "algorithm", "status"}).
+					AddRow(1, "kid", "RS256", "ACTIVE")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `signing_keys` WHERE id = ? AND `signing_keys`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewSigningKeyRepository(dep.db)
+
+			got, result := p.OneById(tt.args.id)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_SigningKeyCreate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		signingKey model.SigningKey
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.SigningKey
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				signingKey: model.SigningKey{
+					ID:        1,
+					Kid:       "kid",
+					Algorithm: "RS256",
+					Status:    "ACTIVE",
+				},
+			},
+			want: model.SigningKey{
+				ID:        1,
+				Kid:       "kid",
+				Algorithm: "RS256",
+				Status:    "ACTIVE",
+				CreatedBy: "SYSTEM",
+				UpdatedBy: "SYSTEM",
+			},
+			mockFn: func(args) fields {
+				db, mock := SigningKeyNewMockDB()
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `signing_keys` (`kid`,`algorithm`,`private_key`,`public_key`,`status`,`retired_at`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewSigningKeyRepository(dep.db)
+
+			got, result := p.Create(tt.args.signingKey)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_SigningKeyUpdate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id         int
+		signingKey model.SigningKey
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.SigningKey
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+				signingKey: model.SigningKey{
+					ID:        1,
+					Kid:       "kid",
+					Algorithm: "RS256",
+					Status:    "ACTIVE",
+				},
+			},
+			want: model.SigningKey{
+				ID:        1,
+				Kid:       "kid",
+				Algorithm: "RS256",
+				Status:    "ACTIVE",
+			},
+			mockFn: func(args) fields {
+				db, mock := SigningKeyNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "kid", "algorithm", "status"}).
+					AddRow(1, "kid2", "EdDSA", "RETIRED")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `signing_keys` WHERE id = ? AND `signing_keys`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `signing_keys` SET `kid`=?,`algorithm`=?,`private_key`=?,`public_key`=?,`status`=?,`retired_at`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `signing_keys`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewSigningKeyRepository(dep.db)
+
+			got, result := p.Update(tt.args.id, tt.args.signingKey)
+			got.UpdatedAt = nil
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"gorm.io/gorm"
+)
+
+type VerificationRepository struct {
+	db *gorm.DB
+}
+
+func NewVerificationRepository(db *gorm.DB) *VerificationRepository {
+	return &VerificationRepository{
+		db: db,
+	}
+}
+
+func (s *VerificationRepository) FilterScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db
+	}
+}
+
+func (s *VerificationRepository) PaginateScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		page, _ := strconv.Atoi(q.Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		pageSize, _ := strconv.Atoi(q.Get("page_size"))
+		switch {
+		case pageSize > 100:
+			pageSize = 100
+		case pageSize <= 0:
+			pageSize = 10
+		}
+
+		sortBy := q.Get("sort_by")
+		if sortBy == "" {
+			sortBy = "id"
+		}
+
+		direction := q.Get("direction")
+		if direction == "" {
+			direction = "desc"
+		}
+
+		sort := sortBy + " " + direction
+
+		offset := (page - 1) * pageSize
+		return db.Offset(offset).Limit(pageSize).Order(sort)
+	}
+}
+
+func (s *VerificationRepository) MetaPaginate(r *http.Request) map[string]interface{} {
+	q := r.URL.Query()
+	var totalRows int64
+	s.db.Model(model.Verification{}).Scopes(s.FilterScope(r)).Count(&totalRows)
+
+	pageSize, _ := strconv.Atoi(q.Get("page_size"))
+	switch {
+	case pageSize > 100:
+		pageSize = 100
+	case pageSize <= 0:
+		pageSize = 10
+	}
+	totalPages := int(math.Ceil(float64(totalRows) / float64(pageSize)))
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	meta := map[string]interface{}{
+		"page":        page,
+		"page_size":   pageSize,
+		"total_rows":  totalRows,
+		"total_pages": totalPages,
+	}
+	return meta
+}
+
+func (s *VerificationRepository) Index(r *http.Request, preload ...string) ([]model.Verification, *gorm.DB) {
+	var table []model.Verification
+	tx := s.db.Scopes(s.FilterScope(r), s.PaginateScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *VerificationRepository) All(r *http.Request, preload ...string) ([]model.Verification, *gorm.DB) {
+	var table []model.Verification
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *VerificationRepository) One(r *http.Request, preload ...string) (model.Verification, *gorm.DB) {
+	var table model.Verification
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *VerificationRepository) OneById(id int, preload ...string) (model.Verification, *gorm.DB) {
+	var table model.Verification
+	tx := s.db.Where("id = ?", id)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *VerificationRepository) OneActiveByCodeHashAndChannel(codeHash string, channel string, preload ...string) (model.Verification, *gorm.DB) {
+	var table model.Verification
+	tx := s.db.Where("code_hash = ? AND channel = ? AND used_at IS NULL", codeHash, channel)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *VerificationRepository) OneLatestActiveByAccountIdAndChannel(accountId int, channel string) (model.Verification, *gorm.DB) {
+	var table model.Verification
+	query := s.db.Where("account_id = ? AND channel = ? AND used_at IS NULL", accountId, channel).Order("id desc").Limit(1).Find(&table)
+
+	return table, query
+}
+
+// VerificationUsage is how many codes an account was sent on a channel and how many wrong codes it tried
+type VerificationUsage struct {
+	Sent       int
+	Attempts   int
+	LastSentAt *time.Time
+}
+
+// UsageByAccountIdAndChannelSince counts the codes sent since, used ones included, so limits hold across resends
+func (s *VerificationRepository) UsageByAccountIdAndChannelSince(accountId int, channel string, since time.Time) (VerificationUsage, *gorm.DB) {
+	var usage VerificationUsage
+	query := s.db.Model(&model.Verification{}).
+		Select("COUNT(*) AS sent, COALESCE(SUM(attempts), 0) AS attempts, MAX(created_at) AS last_sent_at").
+		Where("account_id = ? AND channel = ? AND created_at >= ?", accountId, channel, since).
+		Scan(&usage)
+
+	return usage, query
+}
+
+func (s *VerificationRepository) Create(data model.Verification) (model.Verification, *gorm.DB) {
+	var table model.Verification
+	s.AssignData(&table, data)
+	query := s.db.Create(&table)
+	return table, query
+}
+
+func (s *VerificationRepository) Update(id int, data model.Verification) (model.Verification, *gorm.DB) {
+	var table model.Verification
+	table, result := s.OneById(id)
+	if result.RowsAffected == 0 {
+		result.Error = errors.New(fmt.Sprintf("data not found with id = %d", id))
+		return table, result
+	}
+	s.AssignData(&table, data)
+	query := s.db.Save(&table)
+	return table, query
+}
+
+func (s *VerificationRepository) Delete(id int, isHard bool) *gorm.DB {
+	tx := s.db
+	if isHard {
+		tx = tx.Unscoped()
+	}
+	query := tx.Delete(&model.Verification{}, id)
+	return query
+}
+
+func (s *VerificationRepository) AssignData(table *model.Verification, data model.Verification) {
+	dataRV := reflect.ValueOf(data)
+	tableRV := reflect.ValueOf(table)
+	tableRVE := tableRV.Elem()
+
+	for i := 0; i < dataRV.NumField(); i++ {
+		if !dataRV.Field(i).IsZero() && (tableRVE.Field(i) != dataRV.Field(i)) {
+			fv := tableRVE.FieldByName(dataRV.Type().Field(i).Name)
+			fv.Set(dataRV.Field(i))
+		}
+	}
+}
+
+func (s *VerificationRepository) IncrementAttempts(id int) *gorm.DB {
+	query := s.db.Model(&model.Verification{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"attempts": gorm.Expr("attempts + ?", 1),
+	})
+	return query
+}
+
+// MarkUsed only affects a code not used yet, so RowsAffected 0 means it was already used
+func (s *VerificationRepository) MarkUsed(id int) *gorm.DB {
+	query := s.db.Model(&model.Verification{}).Where("id = ? AND used_at IS NULL", id).Updates(map[string]interface{}{
+		"used_at": time.Now(),
+	})
+	return query
+}
+
+// MarkUsedByAccountIdAndChannel invalidates every outstanding code of the account on the channel
+func (s *VerificationRepository) MarkUsedByAccountIdAndChannel(accountId int, channel string) *gorm.DB {
+	query := s.db.Model(&model.Verification{}).Where("account_id = ? AND channel = ? AND used_at IS NULL", accountId, channel).Updates(map[string]interface{}{
+		"used_at": time.Now(),
+	})
+	return query
+}
+
+func (s *VerificationRepository) DeleteByAccountId(accountId int) *gorm.DB {
+	query := s.db.Unscoped().Where("account_id = ?", accountId).Delete(&model.Verification{})
+	return query
+}
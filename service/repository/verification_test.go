@@ -0,0 +1,435 @@
+package repository
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func VerificationNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_VerificationIndex(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.Verification
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.Verification{
+				{
+					ID:        1,
+					AccountID: 1,
+					Channel:   "EMAIL",
+					Target:    "email@mail.com",
+				},
+				{
+					ID:        2,
+					AccountID: 2,
+					Channel:   "PHONE",
+					Target:    "08123",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := VerificationNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "channel", "target"}).
+					AddRow(1, 1, "EMAIL", "email@mail.com").
+					AddRow(2, 2, "PHONE", "08123")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `verifications` WHERE `verifications`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewVerificationRepository(dep.db)
+
+			got, result := p.Index(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_VerificationAll(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    []model.Verification
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: []model.Verification{
+				{
+					ID:        1,
+					AccountID: 1,
+					Channel:   "EMAIL",
+					Target:    "email@mail.com",
+				},
+				{
+					ID:        2,
+					AccountID: 2,
+					Channel:   "PHONE",
+					Target:    "08123",
+				},
+			},
+			mockFn: func(args) fields {
+				db, mock := VerificationNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "channel", "target"}).
+					AddRow(1, 1, "EMAIL", "email@mail.com").
+					AddRow(2, 2, "PHONE", "08123")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `verifications` WHERE `verifications`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewVerificationRepository(dep.db)
+
+			got, result := p.All(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_VerificationOne(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		r *http.Request
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.Verification
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				&http.Request{
+					URL: &url.URL{RawQuery: ""},
+				},
+			},
+			want: model.Verification{
+				ID:        1,
+				AccountID: 1,
+				Channel:   "EMAIL",
+				Target:    "email@mail.com",
+			},
+			mockFn: func(args) fields {
+				db, mock := VerificationNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "channel", "target"}).
+					AddRow(1, 1, "EMAIL", "email@mail.com")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `verifications` WHERE `verifications`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewVerificationRepository(dep.db)
+
+			got, result := p.One(tt.args.r)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_VerificationOneById(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id int
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.Verification
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+			},
+			want: model.Verification{
+				ID:        1,
+				AccountID: 1,
+				Channel:   "EMAIL",
+				Target:    "email@mail.com",
+			},
+			mockFn: func(args) fields {
+				db, mock := VerificationNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "channel", "target"}).
+					AddRow(1, 1, "EMAIL", "email@mail.com")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `verifications` WHERE id = ? AND `verifications`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewVerificationRepository(dep.db)
+
+			got, result := p.OneById(tt.args.id)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_VerificationCreate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		verification model.Verification
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.Verification
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				verification: model.Verification{
+					ID:        1,
+					AccountID: 1,
+					Channel:   "EMAIL",
+					Target:    "email@mail.com",
+				},
+			},
+			want: model.Verification{
+				ID:        1,
+				AccountID: 1,
+				Channel:   "EMAIL",
+				Target:    "email@mail.com",
+				CreatedBy: "SYSTEM",
+				UpdatedBy: "SYSTEM",
+			},
+			mockFn: func(args) fields {
+				db, mock := VerificationNewMockDB()
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `verifications` (`account_id`,`channel`,`target`,`code_hash`,`attempts`,`expired_at`,`used_at`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewVerificationRepository(dep.db)
+
+			got, result := p.Create(tt.args.verification)
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_VerificationUpdate(t *testing.T) {
+	type fields struct {
+		db *gorm.DB
+	}
+
+	type args struct {
+		id           int
+		verification model.Verification
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+		want    model.Verification
+		mockFn  func(a args) fields
+	}{
+		{
+			name: "Success",
+			args: args{
+				id: 1,
+				verification: model.Verification{
+					ID:        1,
+					AccountID: 1,
+					Channel:   "EMAIL",
+					Target:    "email@mail.com",
+				},
+			},
+			want: model.Verification{
+				ID:        1,
+				AccountID: 1,
+				Channel:   "EMAIL",
+				Target:    "email@mail.com",
+			},
+			mockFn: func(args) fields {
+				db, mock := VerificationNewMockDB()
+
+				row := sqlmock.NewRows([]string{"id", "account_id", "channel", "target"}).
+					AddRow(1, 2, "PHONE", "08123")
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `verifications` WHERE id = ? AND `verifications`.`deleted_at` IS NULL")).WillReturnRows(row)
+
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `verifications` SET `account_id`=?,`channel`=?,`target`=?,`code_hash`=?,`attempts`=?,`expired_at`=?,`used_at`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `verifications`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+
+				return fields{
+					db: db,
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dep := tt.mockFn(tt.args)
+
+			p := NewVerificationRepository(dep.db)
+
+			got, result := p.Update(tt.args.id, tt.args.verification)
+			got.UpdatedAt = nil
+			assert.Equal(t, tt.wantErr, result.Error)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_VerificationUsageByAccountIdAndChannelSince(t *testing.T) {
+	db, mock := VerificationNewMockDB()
+	since := time.Now().Add(-24 * time.Hour)
+	lastSentAt := time.Now().Add(-time.Minute)
+
+	row := sqlmock.NewRows([]string{"sent", "attempts", "last_sent_at"}).AddRow(3, 4, lastSentAt)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) AS sent, COALESCE(SUM(attempts), 0) AS attempts, MAX(created_at) AS last_sent_at FROM `verifications` WHERE (account_id = ? AND channel = ? AND created_at >= ?) AND `verifications`.`deleted_at` IS NULL")).
+		WithArgs(1, "PHONE", since).
+		WillReturnRows(row)
+
+	got, result := NewVerificationRepository(db).UsageByAccountIdAndChannelSince(1, "PHONE", since)
+	assert.NoError(t, result.Error)
+	assert.Equal(t, VerificationUsage{Sent: 3, Attempts: 4, LastSentAt: &lastSentAt}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
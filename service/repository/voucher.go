@@ -31,6 +31,9 @@ func (s *VoucherRepository) FilterScope(r *http.Request) func(db *gorm.DB) *gorm
 				db = db.Where("LOWER(code) = LOWER(?)", code)
 			}
 		}
+		if q.Get("public") == "true" {
+			db = db.Where("account_id IS NULL")
+		}
 		return db
 	}
 }
@@ -189,3 +192,11 @@ func (s *VoucherRepository) AssignData(table *model.Voucher, data model.Voucher)
 		}
 	}
 }
+
+// AnonymizeByAccountId clears the description of vouchers issued to the account, it may name the account
+func (s *VoucherRepository) AnonymizeByAccountId(accountId int) *gorm.DB {
+	query := s.db.Unscoped().Model(&model.Voucher{}).Where("account_id = ?", accountId).Updates(map[string]interface{}{
+		"description": "",
+	})
+	return query
+}
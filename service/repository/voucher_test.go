@@ -340,7 +340,7 @@ func Test_VoucherCreate(t *testing.T) {
 				db, mock := VoucherNewMockDB()
 
 				mock.ExpectBegin()
-				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `vouchers` (`code`,`name`,`description`,`percentage`,`max`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `vouchers` (`code`,`name`,`description`,`percentage`,`max`,`account_id`,`single_use`,`expired_at`,`created_by`,`updated_by`,`deleted_by`,`deleted_at`,`id`) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?)")).WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit()
 
 				return fields{
@@ -413,7 +413,7 @@ func Test_VoucherUpdate(t *testing.T) {
 				mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `vouchers` WHERE id = ? AND `vouchers`.`deleted_at` IS NULL")).WillReturnRows(row)
 
 				mock.ExpectBegin()
-				mock.ExpectExec(regexp.QuoteMeta("UPDATE `vouchers` SET `code`=?,`name`=?,`description`=?,`percentage`=?,`max`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `vouchers`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(regexp.QuoteMeta("UPDATE `vouchers` SET `code`=?,`name`=?,`description`=?,`percentage`=?,`max`=?,`account_id`=?,`single_use`=?,`expired_at`=?,`created_by`=?,`updated_by`=?,`deleted_by`=?,`created_at`=?,`updated_at`=?,`deleted_at`=? WHERE `vouchers`.`deleted_at` IS NULL AND `id` = ?")).WillReturnResult(sqlmock.NewResult(1, 1))
 				mock.ExpectCommit()
 
 				return fields{
@@ -439,3 +439,17 @@ func Test_VoucherUpdate(t *testing.T) {
 		})
 	}
 }
+
+func Test_VoucherIndexPublic(t *testing.T) {
+	t.Parallel()
+
+	// vouchers bound to an account, e.g. of a cart reminder, are not listed to customers
+	db, mock := VoucherNewMockDB()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `vouchers` WHERE account_id IS NULL AND `vouchers`.`deleted_at` IS NULL")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "code"}).AddRow(1, "PROMO"))
+
+	got, result := NewVoucherRepository(db).Index(&http.Request{URL: &url.URL{RawQuery: "public=true"}})
+	assert.NoError(t, result.Error)
+	assert.Equal(t, []model.Voucher{{ID: 1, Code: "PROMO"}}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
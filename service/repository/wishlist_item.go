@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"github.com/avarian/online-shopping-cart/model"
+	"gorm.io/gorm"
+)
+
+type WishlistItemRepository struct {
+	db *gorm.DB
+}
+
+func NewWishlistItemRepository(db *gorm.DB) *WishlistItemRepository {
+	return &WishlistItemRepository{
+		db: db,
+	}
+}
+
+func (s *WishlistItemRepository) FilterScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db
+	}
+}
+
+func (s *WishlistItemRepository) PaginateScope(r *http.Request) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		q := r.URL.Query()
+		page, _ := strconv.Atoi(q.Get("page"))
+		if page == 0 {
+			page = 1
+		}
+
+		pageSize, _ := strconv.Atoi(q.Get("page_size"))
+		switch {
+		case pageSize > 100:
+			pageSize = 100
+		case pageSize <= 0:
+			pageSize = 10
+		}
+
+		sortBy := q.Get("sort_by")
+		if sortBy == "" {
+			sortBy = "id"
+		}
+
+		direction := q.Get("direction")
+		if direction == "" {
+			direction = "desc"
+		}
+
+		sort := sortBy + " " + direction
+
+		offset := (page - 1) * pageSize
+		return db.Offset(offset).Limit(pageSize).Order(sort)
+	}
+}
+
+func (s *WishlistItemRepository) MetaPaginate(r *http.Request) map[string]interface{} {
+	q := r.URL.Query()
+	var totalRows int64
+	s.db.Model(model.WishlistItem{}).Scopes(s.FilterScope(r)).Count(&totalRows)
+
+	pageSize, _ := strconv.Atoi(q.Get("page_size"))
+	switch {
+	case pageSize > 100:
+		pageSize = 100
+	case pageSize <= 0:
+		pageSize = 10
+	}
+	totalPages := int(math.Ceil(float64(totalRows) / float64(pageSize)))
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	meta := map[string]interface{}{
+		"page":        page,
+		"page_size":   pageSize,
+		"total_rows":  totalRows,
+		"total_pages": totalPages,
+	}
+	return meta
+}
+
+func (s *WishlistItemRepository) Index(r *http.Request, preload ...string) ([]model.WishlistItem, *gorm.DB) {
+	var table []model.WishlistItem
+	tx := s.db.Scopes(s.FilterScope(r), s.PaginateScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *WishlistItemRepository) All(r *http.Request, preload ...string) ([]model.WishlistItem, *gorm.DB) {
+	var table []model.WishlistItem
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *WishlistItemRepository) One(r *http.Request, preload ...string) (model.WishlistItem, *gorm.DB) {
+	var table model.WishlistItem
+	tx := s.db.Scopes(s.FilterScope(r))
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *WishlistItemRepository) OneById(id int, preload ...string) (model.WishlistItem, *gorm.DB) {
+	var table model.WishlistItem
+	tx := s.db.Where("id = ?", id)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *WishlistItemRepository) Create(data model.WishlistItem) (model.WishlistItem, *gorm.DB) {
+	var table model.WishlistItem
+	s.AssignData(&table, data)
+	query := s.db.Create(&table)
+	return table, query
+}
+
+func (s *WishlistItemRepository) Update(id int, data model.WishlistItem) (model.WishlistItem, *gorm.DB) {
+	var table model.WishlistItem
+	table, result := s.OneById(id)
+	if result.RowsAffected == 0 {
+		result.Error = errors.New(fmt.Sprintf("data not found with id = %d", id))
+		return table, result
+	}
+	s.AssignData(&table, data)
+	query := s.db.Save(&table)
+	return table, query
+}
+
+func (s *WishlistItemRepository) Delete(id int, isHard bool) *gorm.DB {
+	tx := s.db
+	if isHard {
+		tx = tx.Unscoped()
+	}
+	query := tx.Delete(&model.WishlistItem{}, id)
+	return query
+}
+
+func (s *WishlistItemRepository) AssignData(table *model.WishlistItem, data model.WishlistItem) {
+	dataRV := reflect.ValueOf(data)
+	tableRV := reflect.ValueOf(table)
+	tableRVE := tableRV.Elem()
+
+	for i := 0; i < dataRV.NumField(); i++ {
+		if !dataRV.Field(i).IsZero() && (tableRVE.Field(i) != dataRV.Field(i)) {
+			fv := tableRVE.FieldByName(dataRV.Type().Field(i).Name)
+			fv.Set(dataRV.Field(i))
+		}
+	}
+}
+
+func (s *WishlistItemRepository) OneByWishlistIdAndItemId(wishlistId int, itemId int, preload ...string) (model.WishlistItem, *gorm.DB) {
+	var table model.WishlistItem
+	tx := s.db.Where("wishlist_id = ? AND item_id = ?", wishlistId, itemId)
+	for _, v := range preload {
+		tx = tx.Preload(v)
+	}
+	query := tx.Find(&table)
+
+	return table, query
+}
+
+func (s *WishlistItemRepository) DeleteByWishlistId(wishlistId int, isHard bool) *gorm.DB {
+	tx := s.db
+	if isHard {
+		tx = tx.Unscoped()
+	}
+	query := tx.Where("wishlist_id = ?", wishlistId).Delete(&model.WishlistItem{})
+	return query
+}
+
+// DeleteByAccountId removes the items of every wishlist of the account
+func (s *WishlistItemRepository) DeleteByAccountId(accountId int) *gorm.DB {
+	wishlists := s.db.Unscoped().Model(&model.Wishlist{}).Select("id").Where("account_id = ?", accountId)
+	query := s.db.Unscoped().Where("wishlist_id IN (?)", wishlists).Delete(&model.WishlistItem{})
+	return query
+}
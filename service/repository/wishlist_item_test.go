@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func WishlistItemNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_WishlistItemOneByWishlistIdAndItemId(t *testing.T) {
+	tests := []struct {
+		name      string
+		rows      *sqlmock.Rows
+		want      model.WishlistItem
+		wantFound bool
+	}{
+		{
+			name:      "Item in wishlist",
+			rows:      sqlmock.NewRows([]string{"id", "wishlist_id", "item_id"}).AddRow(5, 2, 7),
+			want:      model.WishlistItem{ID: 5, WishlistID: 2, ItemID: 7},
+			wantFound: true,
+		},
+		{
+			name: "Item not in wishlist",
+			rows: sqlmock.NewRows([]string{"id", "wishlist_id", "item_id"}),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := WishlistItemNewMockDB()
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `wishlist_items` WHERE (wishlist_id = ? AND item_id = ?) AND `wishlist_items`.`deleted_at` IS NULL")).
+				WithArgs(2, 7).
+				WillReturnRows(tt.rows)
+
+			got, result := NewWishlistItemRepository(db).OneByWishlistIdAndItemId(2, 7)
+			assert.NoError(t, result.Error)
+			assert.Equal(t, tt.wantFound, result.RowsAffected > 0)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_WishlistItemDeleteByWishlistId(t *testing.T) {
+	tests := []struct {
+		name   string
+		isHard bool
+		sql    string
+	}{
+		{
+			name:   "Hard delete",
+			isHard: true,
+			sql:    "DELETE FROM `wishlist_items` WHERE wishlist_id = ?",
+		},
+		{
+			name: "Soft delete",
+			sql:  "UPDATE `wishlist_items` SET `deleted_at`=? WHERE wishlist_id = ? AND `wishlist_items`.`deleted_at` IS NULL",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := WishlistItemNewMockDB()
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(tt.sql)).WillReturnResult(sqlmock.NewResult(0, 3))
+			mock.ExpectCommit()
+
+			result := NewWishlistItemRepository(db).DeleteByWishlistId(2, tt.isHard)
+			assert.NoError(t, result.Error)
+			assert.Equal(t, int64(3), result.RowsAffected)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_WishlistItemDeleteByAccountId(t *testing.T) {
+	db, mock := WishlistItemNewMockDB()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `wishlist_items` WHERE wishlist_id IN (SELECT `id` FROM `wishlists` WHERE account_id = ?)")).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectCommit()
+
+	result := NewWishlistItemRepository(db).DeleteByAccountId(1)
+	assert.NoError(t, result.Error)
+	assert.Equal(t, int64(4), result.RowsAffected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/avarian/online-shopping-cart/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func WishlistNewMockDB() (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	gormDB, err := gorm.Open(mysql.New(mysql.Config{
+		Conn:                      db,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{})
+
+	if err != nil {
+		log.Printf("An error '%s' was not expected when opening gorm database", err)
+	}
+
+	return gormDB, mock
+}
+
+func Test_WishlistOneByIdAndAccountId(t *testing.T) {
+	tests := []struct {
+		name      string
+		accountId int
+		rows      *sqlmock.Rows
+		want      model.Wishlist
+		wantFound bool
+	}{
+		{
+			name:      "Own wishlist",
+			accountId: 1,
+			rows:      sqlmock.NewRows([]string{"id", "account_id", "name"}).AddRow(2, 1, "Birthday"),
+			want:      model.Wishlist{ID: 2, AccountID: 1, Name: "Birthday"},
+			wantFound: true,
+		},
+		{
+			// the id exists but belongs to another account, the query finds nothing
+			name:      "Wishlist of another account",
+			accountId: 3,
+			rows:      sqlmock.NewRows([]string{"id", "account_id", "name"}),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := WishlistNewMockDB()
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `wishlists` WHERE (id = ? AND account_id = ?) AND `wishlists`.`deleted_at` IS NULL")).
+				WithArgs(2, tt.accountId).
+				WillReturnRows(tt.rows)
+
+			got, result := NewWishlistRepository(db).OneByIdAndAccountId(2, tt.accountId)
+			assert.NoError(t, result.Error)
+			assert.Equal(t, tt.wantFound, result.RowsAffected > 0)
+			assert.Equal(t, tt.want, got)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_WishlistOneByShareToken(t *testing.T) {
+	t.Run("Shared wishlist with its items", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock := WishlistNewMockDB()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `wishlists` WHERE share_token = ? AND `wishlists`.`deleted_at` IS NULL")).
+			WithArgs("token").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "name", "share_token"}).AddRow(2, 1, "Birthday", "token"))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `wishlist_items` WHERE `wishlist_items`.`wishlist_id` = ? AND `wishlist_items`.`deleted_at` IS NULL")).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "wishlist_id", "item_id"}).AddRow(5, 2, 7))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `items` WHERE `items`.`id` = ?")).
+			WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(7, "Book"))
+
+		got, result := NewWishlistRepository(db).OneByShareToken("token", "WishlistItem.Item")
+		assert.NoError(t, result.Error)
+		assert.Equal(t, int64(1), result.RowsAffected)
+		assert.Len(t, got.WishlistItem, 1)
+		assert.Equal(t, "Book", got.WishlistItem[0].Item.Name)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown or revoked token", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock := WishlistNewMockDB()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `wishlists` WHERE share_token = ? AND `wishlists`.`deleted_at` IS NULL")).
+			WithArgs("revoked").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, result := NewWishlistRepository(db).OneByShareToken("revoked", "WishlistItem.Item")
+		assert.NoError(t, result.Error)
+		assert.Equal(t, int64(0), result.RowsAffected)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func Test_WishlistUpdateShareToken(t *testing.T) {
+	token := "token"
+
+	tests := []struct {
+		name  string
+		token *string
+	}{
+		{name: "Share", token: &token},
+		// a nil token revokes the link
+		{name: "Stop sharing", token: nil},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock := WishlistNewMockDB()
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta("UPDATE `wishlists` SET `share_token`=?,`updated_by`=?,`updated_at`=? WHERE id = ? AND `wishlists`.`deleted_at` IS NULL")).
+				WithArgs(tt.token, "email@mail.com", sqlmock.AnyArg(), 2).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			result := NewWishlistRepository(db).UpdateShareToken(2, tt.token, "email@mail.com")
+			assert.NoError(t, result.Error)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_WishlistAllByAccountId(t *testing.T) {
+	db, mock := WishlistNewMockDB()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `wishlists` WHERE account_id = ? AND `wishlists`.`deleted_at` IS NULL")).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "name"}).AddRow(2, 1, "Birthday").AddRow(3, 1, "Holiday"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `wishlist_items` WHERE `wishlist_items`.`wishlist_id` IN (?,?) AND `wishlist_items`.`deleted_at` IS NULL")).
+		WithArgs(2, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "wishlist_id", "item_id"}).AddRow(5, 3, 7))
+
+	got, result := NewWishlistRepository(db).AllByAccountId(1, "WishlistItem")
+	assert.NoError(t, result.Error)
+	assert.Len(t, got, 2)
+	assert.Empty(t, got[0].WishlistItem)
+	assert.Len(t, got[1].WishlistItem, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
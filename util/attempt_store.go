@@ -0,0 +1,85 @@
+package util
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// AttemptStore counts attempts in a sliding window and keeps lockouts, keys are e.g. an account or a client ip
+type AttemptStore interface {
+	// Hit records an attempt and returns how many were made within the window
+	Hit(ctx context.Context, key string, window time.Duration) (int, error)
+	// Incr increments a counter kept for ttl after its last increment
+	Incr(ctx context.Context, key string, ttl time.Duration) (int, error)
+	// Lock locks the key for ttl and forgets its attempts
+	Lock(ctx context.Context, key string, ttl time.Duration) error
+	// LockedFor returns how long the key stays locked, 0 when it is not
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	// Clear forgets attempts, counter and lock of the keys
+	Clear(ctx context.Context, keys ...string) error
+}
+
+// FallbackAttemptStore uses fallback whenever primary fails, so an unreachable redis does not stop logins
+type FallbackAttemptStore struct {
+	primary  AttemptStore
+	fallback AttemptStore
+}
+
+func NewFallbackAttemptStore(primary AttemptStore, fallback AttemptStore) *FallbackAttemptStore {
+	return &FallbackAttemptStore{
+		primary:  primary,
+		fallback: fallback,
+	}
+}
+
+func (s *FallbackAttemptStore) Hit(ctx context.Context, key string, window time.Duration) (int, error) {
+	n, err := s.primary.Hit(ctx, key, window)
+	if err != nil {
+		log.WithField("reason", err).Warn("attempt store unavailable, using fallback")
+		return s.fallback.Hit(ctx, key, window)
+	}
+	return n, nil
+}
+
+func (s *FallbackAttemptStore) Incr(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	n, err := s.primary.Incr(ctx, key, ttl)
+	if err != nil {
+		log.WithField("reason", err).Warn("attempt store unavailable, using fallback")
+		return s.fallback.Incr(ctx, key, ttl)
+	}
+	return n, nil
+}
+
+func (s *FallbackAttemptStore) Lock(ctx context.Context, key string, ttl time.Duration) error {
+	if err := s.primary.Lock(ctx, key, ttl); err != nil {
+		log.WithField("reason", err).Warn("attempt store unavailable, using fallback")
+		return s.fallback.Lock(ctx, key, ttl)
+	}
+	return nil
+}
+
+// LockedFor honours a lock of either store, a lock taken while primary was down stays in the fallback
+func (s *FallbackAttemptStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	fallback, err := s.fallback.LockedFor(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	primary, err := s.primary.LockedFor(ctx, key)
+	if err != nil {
+		log.WithField("reason", err).Warn("attempt store unavailable, using fallback")
+		return fallback, nil
+	}
+	if fallback > primary {
+		return fallback, nil
+	}
+	return primary, nil
+}
+
+func (s *FallbackAttemptStore) Clear(ctx context.Context, keys ...string) error {
+	if err := s.fallback.Clear(ctx, keys...); err != nil {
+		return err
+	}
+	return s.primary.Clear(ctx, keys...)
+}
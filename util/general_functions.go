@@ -1,5 +1,44 @@
 package util
 
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+)
+
 func ToPointerInt(i int) *int {
 	return &i
 }
+
+func ToPointerUint(i uint) *uint {
+	return &i
+}
+
+// RandomToken returns a hex encoded string of n random bytes
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// RandomDigits returns a string of n random decimal digits, e.g. for one time codes
+func RandomDigits(n int) (string, error) {
+	b := make([]byte, n)
+	for i := range b {
+		d, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		b[i] = byte('0' + d.Int64())
+	}
+	return string(b), nil
+}
+
+// HashToken returns the hex encoded sha256 of a token, for storing tokens handed out to clients
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,55 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Header carrying the guest token of an anonymous cart
+const GuestTokenHeader = "X-Guest-Token"
+
+// Purpose mixed into the guest token signature, a guest token signed with the shared secret can not
+// pass for any other value signed with it
+const guestTokenPurpose = "guest_cart"
+
+// NewGuestToken returns a random guest id and its token signed with secret, valid for ttl
+func NewGuestToken(secret string, ttl time.Duration) (string, string, time.Time, error) {
+	guestId, err := RandomToken(16)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	expiredAt := time.Now().Add(ttl).Truncate(time.Second)
+	payload := guestId + "." + strconv.FormatInt(expiredAt.Unix(), 10)
+	return guestId, payload + "." + signGuestToken(secret, payload), expiredAt, nil
+}
+
+// ParseGuestToken verifies the token signature and expiry and returns the guest id
+func ParseGuestToken(secret string, token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] == "" {
+		return "", errors.New("malformed guest token")
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(signGuestToken(secret, payload))) {
+		return "", errors.New("invalid guest token")
+	}
+	expiredAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", errors.New("malformed guest token")
+	}
+	if time.Now().Unix() >= expiredAt {
+		return "", errors.New("guest token expired")
+	}
+	return parts[0], nil
+}
+
+func signGuestToken(secret string, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(guestTokenPurpose + ":" + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,63 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GuestToken(t *testing.T) {
+	t.Run("Parse a token", func(t *testing.T) {
+		guestId, token, expiredAt, err := NewGuestToken("secret", time.Hour)
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), expiredAt, time.Second)
+
+		parsed, err := ParseGuestToken("secret", token)
+		assert.NoError(t, err)
+		assert.Equal(t, guestId, parsed)
+	})
+
+	t.Run("Refuse a token of another secret", func(t *testing.T) {
+		_, token, _, err := NewGuestToken("secret", time.Hour)
+		assert.NoError(t, err)
+
+		_, err = ParseGuestToken("other", token)
+		assert.EqualError(t, err, "invalid guest token")
+	})
+
+	t.Run("Refuse an expired token", func(t *testing.T) {
+		_, token, _, err := NewGuestToken("secret", -time.Second)
+		assert.NoError(t, err)
+
+		_, err = ParseGuestToken("secret", token)
+		assert.EqualError(t, err, "guest token expired")
+	})
+
+	t.Run("Refuse an extended expiry", func(t *testing.T) {
+		guestId, token, _, err := NewGuestToken("secret", -time.Second)
+		assert.NoError(t, err)
+
+		signature := token[len(token)-64:]
+		_, err = ParseGuestToken("secret", guestId+"."+strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)+"."+signature)
+		assert.EqualError(t, err, "invalid guest token")
+	})
+
+	t.Run("Refuse a value signed without the purpose", func(t *testing.T) {
+		payload := "guest." + strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(payload))
+
+		_, err := ParseGuestToken("secret", payload+"."+hex.EncodeToString(mac.Sum(nil)))
+		assert.EqualError(t, err, "invalid guest token")
+	})
+
+	t.Run("Refuse a token without expiry", func(t *testing.T) {
+		_, err := ParseGuestToken("secret", "guest."+signGuestToken("secret", "guest"))
+		assert.EqualError(t, err, "malformed guest token")
+	})
+}
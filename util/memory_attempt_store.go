@@ -0,0 +1,111 @@
+package util
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryCounter struct {
+	n     int
+	until time.Time
+}
+
+// MemoryAttemptStore is a process local AttemptStore, meant for tests, single instance setups and as fallback
+type MemoryAttemptStore struct {
+	mu       sync.Mutex
+	hits     map[string][]time.Time
+	counters map[string]memoryCounter
+	locks    map[string]time.Time
+}
+
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{
+		hits:     map[string][]time.Time{},
+		counters: map[string]memoryCounter{},
+		locks:    map[string]time.Time{},
+	}
+}
+
+func (s *MemoryAttemptStore) Hit(ctx context.Context, key string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.expire(now, window)
+
+	s.hits[key] = append(s.hits[key], now)
+	return len(s.hits[key]), nil
+}
+
+func (s *MemoryAttemptStore) Incr(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	counter := s.counters[key]
+	if !counter.until.After(now) {
+		counter.n = 0
+	}
+	counter.n++
+	counter.until = now.Add(ttl)
+	s.counters[key] = counter
+	return counter.n, nil
+}
+
+func (s *MemoryAttemptStore) Lock(ctx context.Context, key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.locks[key] = time.Now().Add(ttl)
+	delete(s.hits, key)
+	return nil
+}
+
+func (s *MemoryAttemptStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until, ok := s.locks[key]
+	if !ok {
+		return 0, nil
+	}
+	left := time.Until(until)
+	if left <= 0 {
+		delete(s.locks, key)
+		return 0, nil
+	}
+	return left, nil
+}
+
+func (s *MemoryAttemptStore) Clear(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, v := range keys {
+		delete(s.hits, v)
+		delete(s.counters, v)
+		delete(s.locks, v)
+	}
+	return nil
+}
+
+// expire drops attempts out of the window and ended counters so the maps do not grow forever
+func (s *MemoryAttemptStore) expire(now time.Time, window time.Duration) {
+	for k, hits := range s.hits {
+		i := 0
+		for i < len(hits) && !hits[i].After(now.Add(-window)) {
+			i++
+		}
+		if i == len(hits) {
+			delete(s.hits, k)
+			continue
+		}
+		s.hits[k] = hits[i:]
+	}
+	for k, counter := range s.counters {
+		if !counter.until.After(now) {
+			delete(s.counters, k)
+		}
+	}
+}
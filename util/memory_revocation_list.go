@@ -0,0 +1,41 @@
+package util
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryRevocationList is a process local RevocationList, meant for tests and single instance setups
+type MemoryRevocationList struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+func NewMemoryRevocationList() *MemoryRevocationList {
+	return &MemoryRevocationList{
+		revoked: map[string]time.Time{},
+	}
+}
+
+func (l *MemoryRevocationList) Revoke(ctx context.Context, id string, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for k, until := range l.revoked {
+		if !until.After(now) {
+			delete(l.revoked, k)
+		}
+	}
+	l.revoked[id] = now.Add(ttl)
+	return nil
+}
+
+func (l *MemoryRevocationList) IsRevoked(ctx context.Context, id string) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	until, ok := l.revoked[id]
+	return ok && until.After(time.Now()), nil
+}
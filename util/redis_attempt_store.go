@@ -0,0 +1,88 @@
+package util
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type RedisAttemptStore struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisAttemptStore(client *redis.Client) *RedisAttemptStore {
+	return &RedisAttemptStore{
+		client: client,
+		prefix: "attempts:",
+	}
+}
+
+// Hit keeps the attempts as a sorted set scored by time, older ones fall out of the window
+func (s *RedisAttemptStore) Hit(ctx context.Context, key string, window time.Duration) (int, error) {
+	member, err := RandomToken(8)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	hitsKey := s.prefix + "hits:" + key
+	var card *redis.IntCmd
+	if _, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, hitsKey, "-inf", strconv.FormatInt(now.Add(-window).UnixNano(), 10))
+		pipe.ZAdd(ctx, hitsKey, &redis.Z{Score: float64(now.UnixNano()), Member: member})
+		card = pipe.ZCard(ctx, hitsKey)
+		pipe.PExpire(ctx, hitsKey, window)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	return int(card.Val()), nil
+}
+
+func (s *RedisAttemptStore) Incr(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	countKey := s.prefix + "count:" + key
+	var incr *redis.IntCmd
+	if _, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, countKey)
+		pipe.PExpire(ctx, countKey, ttl)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	return int(incr.Val()), nil
+}
+
+func (s *RedisAttemptStore) Lock(ctx context.Context, key string, ttl time.Duration) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, s.prefix+"lock:"+key, 1, ttl)
+		pipe.Del(ctx, s.prefix+"hits:"+key)
+		return nil
+	})
+	return err
+}
+
+func (s *RedisAttemptStore) LockedFor(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(ctx, s.prefix+"lock:"+key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// negative when the key does not exist or has no expiry
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (s *RedisAttemptStore) Clear(ctx context.Context, keys ...string) error {
+	var redisKeys []string
+	for _, v := range keys {
+		redisKeys = append(redisKeys, s.prefix+"hits:"+v, s.prefix+"count:"+v, s.prefix+"lock:"+v)
+	}
+	if len(redisKeys) == 0 {
+		return nil
+	}
+	return s.client.Del(ctx, redisKeys...).Err()
+}
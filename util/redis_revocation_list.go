@@ -0,0 +1,32 @@
+package util
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type RedisRevocationList struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRedisRevocationList(client *redis.Client) *RedisRevocationList {
+	return &RedisRevocationList{
+		client: client,
+		prefix: "revoked:",
+	}
+}
+
+func (l *RedisRevocationList) Revoke(ctx context.Context, id string, ttl time.Duration) error {
+	return l.client.Set(ctx, l.prefix+id, 1, ttl).Err()
+}
+
+func (l *RedisRevocationList) IsRevoked(ctx context.Context, id string) (bool, error) {
+	n, err := l.client.Exists(ctx, l.prefix+id).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
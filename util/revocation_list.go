@@ -0,0 +1,12 @@
+package util
+
+import (
+	"context"
+	"time"
+)
+
+// RevocationList keeps revoked token or session ids until they would have expired anyway
+type RevocationList interface {
+	Revoke(ctx context.Context, id string, ttl time.Duration) error
+	IsRevoked(ctx context.Context, id string) (bool, error)
+}
@@ -1,6 +1,9 @@
 package util
 
 import (
+	"reflect"
+	"strings"
+
 	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
@@ -25,6 +28,17 @@ func ValidatorTranslate() *Validator {
 	trans, _ := uni.GetTranslator("en")
 
 	validate = validator.New()
+	// errors name fields the way requests do
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
 	en_translations.RegisterDefaultTranslations(validate, trans)
 
 	validator := Validator{